@@ -1,54 +1,465 @@
 package main
 
 import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/config"
+	"PR_reviewer_assign_service/internal/escalation"
 	"PR_reviewer_assign_service/internal/handlers"
+	"PR_reviewer_assign_service/internal/logging"
+	"PR_reviewer_assign_service/internal/metrics"
+	"PR_reviewer_assign_service/internal/outbox"
+	"PR_reviewer_assign_service/internal/ratelimit"
+	"PR_reviewer_assign_service/internal/reminders"
 	"PR_reviewer_assign_service/internal/service"
 	"PR_reviewer_assign_service/internal/storage"
-	"log"
+	"PR_reviewer_assign_service/internal/stream"
+	"PR_reviewer_assign_service/internal/tracing"
+	"PR_reviewer_assign_service/internal/webhooks"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+const usage = `Usage:
+  main [flags]               run the service
+  main config print [flags]  show effective settings, secrets are redacted
+  main config check [flags]  validate settings
+
+Settings come from flags, environment variables and -config file (YAML or JSON),
+in this order of precedence. Run "main -h" to list them.
+`
+
 func main() {
-	connection := os.Getenv("DATABASE_URL")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(configCommand(args[1:]))
+	}
+
+	cfg, err := config.Load("main", args)
+	if err == nil {
+		err = checkConfig(cfg)
+	}
+	if err != nil {
+		if err == flag.ErrHelp {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	// Structured logs
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fatal("Invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+
+	// Traces, OTLP settings from OTEL_EXPORTER_OTLP_*
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		fatal("Failed to configure tracing", "error", err)
+	}
 
 	// Create new storage: PostgreSQL
-	storage, err := storage.NewPostgresStorage(connection)
+	storage, err := storage.NewPostgresStorage(cfg.Database.URL)
 	if err != nil {
-		log.Fatalf("Failed to create storage: %v", err)
+		fatal("Failed to create storage", "error", err)
 	}
-	defer storage.Close()
+	storage.SetPool(poolConfig(cfg.Database))
+
+	// Start webhook deliveries in background, workers stop after the server is drained
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	dispatcher := webhooks.NewDispatcher(storage, webhooksConfig(cfg.Webhooks))
+	dispatcher.Start(ctx)
+
+	// Publish events from outbox to sinks
+	sinks, closeSinks, err := outboxSinks(cfg.Outbox, dispatcher)
+	if err != nil {
+		fatal("Failed to create outbox sinks", "error", err)
+	}
+	broker := stream.NewBroker()
+	outboxDispatcher := outbox.NewDispatcher(storage, outboxConfig(cfg.Outbox), sinks...)
+	outboxDispatcher.OnPublish(broker.Notify)
+	outboxDispatcher.Start(ctx)
 
 	// Create new Service
-	svc := service.NewService(storage)
+	svc := service.NewService(storage, dispatcher)
+	svc.SetDefaultReviewSLA(cfg.Review.SLAHours)
+	svc.SetReviewersPerPR(cfg.Review.ReviewersPerPR)
+	svc.SetIdempotencyTTL(time.Duration(cfg.Idempotency.TTLHours) * time.Hour)
+	if err := handlers.SetValidationRules(validationRules(cfg.Validation)); err != nil {
+		fatal("Invalid validation rules", "error", err)
+	}
+	handlers.SetMaxBodyBytes(int64(cfg.Server.MaxBodyBytes))
+
+	// Metrics of requests, database pool and assignments
+	registry := metrics.NewRegistry()
+	httpMetrics := handlers.NewHTTPMetrics(registry)
+	metrics.RegisterDBStats(registry, storage.Stats)
+	svc.RegisterMetrics(registry)
+
+	// Remind about overdue reviews and escalate stale ones in background
+	scheduler := reminders.NewScheduler(svc, reminderNotifier(cfg.Reminders), clock.System{}, reminders.Config{Interval: cfg.Reminders.Interval, RepeatEvery: cfg.Reminders.RepeatEvery})
+	scheduler.Start(ctx)
+	escalationJob := escalation.NewJob(svc, escalation.Config{Interval: cfg.Escalation.Interval})
+	escalationJob.Start(ctx)
+
+	// Authentication: API keys with scopes
+	access := handlers.NewAuth(svc)
+	if cfg.Auth.Disabled {
+		slog.Warn("Authentication is disabled")
+		access.Disable()
+	}
+	if cfg.Auth.JWT.JWKS != "" {
+		verifier, err := jwtVerifier(ctx, cfg.Auth.JWT)
+		if err != nil {
+			fatal("Failed to configure JWT authentication", "error", err)
+		}
+		access.UseJWT(verifier)
+	}
+	if key := cfg.Auth.AdminAPIKey; key != "" {
+		if err := svc.EnsureAPIKey(ctx, "bootstrap-admin", key, []auth.Scope{auth.ScopeAdmin}); err != nil {
+			fatal("Failed to create bootstrap admin key", "error", err)
+		}
+	}
+
+	// Rate limits per route group and credential
+	limits, err := rateLimits(cfg)
+	if err != nil {
+		fatal("Invalid rate limits", "error", err)
+	}
+	guard := func(group string, scope auth.Scope) func(http.HandlerFunc) http.HandlerFunc {
+		return func(h http.HandlerFunc) http.HandlerFunc {
+			return limits.Gate(group, access.Identify(limits.Limit(group, access.Require(scope, h))))
+		}
+	}
 
-	// Create handlers for requests
-	userHandler := handlers.NewUserHandler(svc)
-	teamHandler := handlers.NewTeamHandler(svc)
-	prHandler := handlers.NewPRHandler(svc)
+	// Retries of create requests with Idempotency-Key get the first response
+	idempotency := handlers.NewIdempotency(svc)
+	idempotency.Start(ctx)
 
 	// Handle functions
-	http.HandleFunc("/team/add", teamHandler.AddTeam)
-	http.HandleFunc("/team/get", teamHandler.GetTeam)
-	http.HandleFunc("/users/setIsActive", userHandler.SetIsActive)
-	http.HandleFunc("/pullRequest/create", prHandler.CreatePR)
-	http.HandleFunc("/pullRequest/merge", prHandler.Merge)
-	http.HandleFunc("/pullRequest/reassign", prHandler.Reassign)
-	http.HandleFunc("/users/getReview", userHandler.GetReview)
-	// Additional functions
-	http.HandleFunc("/users/statistics", userHandler.GetUserStatistics)
-	http.HandleFunc("/users/get", userHandler.GetStatistics)
-	http.HandleFunc("/team/statistics", teamHandler.GetTeamStatistics)
-	http.HandleFunc("/team/count", teamHandler.GetStatistics)
-	http.HandleFunc("/pullRequest/statistics", prHandler.GetStatistics)
+	router := handlers.NewRouter()
+	api := &handlers.API{
+		Users:       handlers.NewUserHandler(svc),
+		Teams:       handlers.NewTeamHandler(svc),
+		PRs:         handlers.NewPRHandler(svc),
+		Webhooks:    handlers.NewWebhookHandler(svc),
+		APIKeys:     handlers.NewAPIKeyHandler(svc),
+		Events:      handlers.NewEventHandler(svc, broker),
+		Idempotency: idempotency,
+	}
+	api.Register(router, guard)
+
+	// Metrics
+	router.Get("/metrics", registry.Handler)
+
+	// API documentation
+	router.Get("/openapi.json", handlers.OpenAPISpec)
+	router.Get("/docs", handlers.SwaggerUI)
 
 	// Handle HealthCheck
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	// Probes: liveness of the process, readiness of storage, schema and workers
+	health := handlers.NewHealth(cfg.Server.ReadinessTimeout)
+	health.Add("storage", svc.PingStorage)
+	health.Add("migrations", svc.CheckSchemaVersion)
+	health.Add("webhooks", running(dispatcher))
+	health.Add("outbox", running(outboxDispatcher))
+	health.Add("reminders", running(scheduler))
+	health.Add("escalation", running(escalationJob))
+	health.Add("idempotency", running(idempotency))
+	router.Get("/livez", health.Live)
+	router.Get("/readyz", health.Ready)
+
 	// Start the server
-	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           handlers.Tracing(handlers.RequestLogger(httpMetrics.Wrap(router))),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	server.RegisterOnShutdown(broker.Close)
+	shutdownTimeout := cfg.Server.ShutdownTimeout
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("Server stopped", "error", err)
+	case <-signals.Done():
+		slog.Info("Shutting down", "timeout", shutdownTimeout.String())
+	}
+	health.Shutdown()
+
+	// Drain requests, then stop workers, everything within the deadline
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain connections", "error", err)
+	}
+	stopWorkers()
+	if err := wait(shutdownCtx, dispatcher, outboxDispatcher, scheduler, escalationJob, idempotency); err != nil {
+		slog.Error("Background workers did not stop", "error", err)
+	}
+
+	closeSinks()
+	if err := storage.Close(); err != nil {
+		slog.Error("Failed to close storage", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// Log error and exit
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Readiness check of background worker
+func running(worker interface{ Running() bool }) handlers.Check {
+	return func(ctx context.Context) error {
+		if !worker.Running() {
+			return errors.New("not running")
+		}
+		return nil
+	}
+}
+
+// Wait for background workers until ctx is done
+func wait(ctx context.Context, workers ...interface{ Wait() }) error {
+	done := make(chan struct{})
+	go func() {
+		for _, worker := range workers {
+			worker.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Settings converted for components
+
+// Settings needed to run the service besides the ones config checks itself
+func checkConfig(cfg *config.Config) error {
+	errs := []error{cfg.ValidateRun()}
+	if cfg.RateLimits.Rules != "off" {
+		if _, err := rateLimitRules(cfg.RateLimits); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limits.rules: %v", err))
+		}
+		if _, err := credentialRateLimitRules(cfg.RateLimits); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limits.credentials: %v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func poolConfig(cfg config.DatabaseConfig) storage.PoolConfig {
+	return storage.PoolConfig{
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
+}
+
+func validationRules(cfg config.ValidationConfig) handlers.ValidationRules {
+	return handlers.ValidationRules{
+		TeamName: handlers.FieldRule(cfg.TeamName),
+		UserID:   handlers.FieldRule(cfg.UserID),
+		Username: handlers.FieldRule(cfg.Username),
+		PRID:     handlers.FieldRule(cfg.PRID),
+		PRName:   handlers.FieldRule(cfg.PRName),
+	}
+}
+
+func outboxConfig(cfg config.OutboxConfig) outbox.Config {
+	result := outbox.DefaultConfig()
+	result.PollInterval = cfg.PollInterval
+	result.BatchSize = cfg.BatchSize
+	result.MaxAttempts = cfg.MaxAttempts
+	return result
+}
+
+func webhooksConfig(cfg config.WebhooksConfig) webhooks.Config {
+	result := webhooks.DefaultConfig()
+	result.Workers = cfg.Workers
+	result.MaxAttempts = cfg.MaxAttempts
+	result.Timeout = cfg.Timeout
+	return result
+}
+
+// Default limits of route groups with overrides from rate_limits.rules
+func rateLimitRules(cfg config.RateLimitsConfig) (map[string]ratelimit.Rule, error) {
+	rules := ratelimit.DefaultRules()
+	custom, err := ratelimit.ParseRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	for group, rule := range custom {
+		if _, ok := rules[group]; !ok {
+			return nil, fmt.Errorf("unknown route group %q", group)
+		}
+		rules[group] = rule
+	}
+	return rules, nil
+}
+
+// Limits of credentials per route group from rate_limits.credentials
+func credentialRateLimitRules(cfg config.RateLimitsConfig) (map[string]map[string]ratelimit.Rule, error) {
+	rules, err := ratelimit.ParseCredentialRules(cfg.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	for group := range rules {
+		if _, ok := ratelimit.DefaultRules()[group]; !ok {
+			return nil, fmt.Errorf("unknown route group %q", group)
+		}
+	}
+	return rules, nil
+}
+
+// Sinks of outbox events: webhook, log, file
+func outboxSinks(cfg config.OutboxConfig, dispatcher *webhooks.Dispatcher) ([]outbox.Sink, func(), error) {
+	var sinks []outbox.Sink
+	closeSinks := func() {}
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, dispatcher)
+		case "log":
+			sinks = append(sinks, outbox.NewLogSink())
+		case "file":
+			fileSink, err := outbox.NewFileSink(cfg.File)
+			if err != nil {
+				return nil, nil, err
+			}
+			sinks = append(sinks, fileSink)
+			closeSinks = func() { fileSink.Close() }
+		default:
+			return nil, nil, fmt.Errorf("unknown outbox sink: %s", name)
+		}
+	}
+	return sinks, closeSinks, nil
+}
+
+// Notifier about overdue prs: log or webhook
+func reminderNotifier(cfg config.RemindersConfig) reminders.Notifier {
+	switch cfg.Notifier {
+	case "webhook":
+		return reminders.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret)
+	default:
+		return reminders.NewLogNotifier()
+	}
+}
+
+// Default limits changed by rate_limits.rules and rate_limits.credentials, "off" disables limiting
+func rateLimits(cfg *config.Config) (*handlers.RateLimits, error) {
+	limits := handlers.NewRateLimits(clock.System{})
+	if cfg.RateLimits.TrustProxy {
+		limits.TrustProxy()
+	}
+
+	if cfg.RateLimits.Rules == "off" {
+		slog.Warn("Rate limiting is disabled")
+		return limits, nil
+	}
+	rules, err := rateLimitRules(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	for group, rule := range rules {
+		limits.Set(group, rule)
+	}
+	credentials, err := credentialRateLimitRules(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	for group, rules := range credentials {
+		for credential, rule := range rules {
+			if err := limits.SetCredential(group, credential, rule); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return limits, nil
+}
+
+// JWT checks with keys from JWKS file or URL
+func jwtVerifier(ctx context.Context, cfg config.JWTConfig) (*auth.JWTVerifier, error) {
+	keys, err := auth.NewKeySet(ctx, cfg.JWKS)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtConfig := auth.DefaultJWTConfig()
+	jwtConfig.Issuer = cfg.Issuer
+	jwtConfig.Audience = cfg.Audience
+	jwtConfig.UserClaim = cfg.UserClaim
+	jwtConfig.RolesClaim = cfg.RolesClaim
+	jwtConfig.TeamClaim = cfg.TeamClaim
+	return auth.NewJWTVerifier(keys, jwtConfig), nil
+}
+
+// config print|check [flags]
+func configCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "check") {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	// Printed config may lack settings needed only to run, like database.url
+	cfg, err := config.Load("config "+args[0], args[1:])
+	if err == nil && args[0] == "check" {
+		err = checkConfig(cfg)
+	}
+	if err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		return 1
+	}
+
+	if args[0] == "print" {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "Configuration is valid")
+	return 0
 }
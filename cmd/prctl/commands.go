@@ -0,0 +1,223 @@
+package main
+
+import (
+	"PR_reviewer_assign_service/pkg/client"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type cli struct {
+	client *client.Client
+	out    *printer
+}
+
+func (c *cli) run(ctx context.Context, group, command string, args []string) error {
+	switch group + " " + command {
+	case "team create":
+		return c.teamCreate(ctx, args)
+	case "team get":
+		return c.withArgs(args, 1, func() error { return c.teamGet(ctx, args[0]) })
+	case "user activate":
+		return c.withArgs(args, 1, func() error { return c.userSetActive(ctx, args[0], true) })
+	case "user deactivate":
+		return c.withArgs(args, 1, func() error { return c.userSetActive(ctx, args[0], false) })
+	case "user reviews":
+		return c.withArgs(args, 1, func() error { return c.userReviews(ctx, args[0]) })
+	case "pr create":
+		return c.withArgs(args, 3, func() error { return c.prCreate(ctx, args[0], args[1], args[2]) })
+	case "pr merge":
+		return c.withArgs(args, 1, func() error { return c.prMerge(ctx, args[0]) })
+	case "pr reassign":
+		return c.withArgs(args, 2, func() error { return c.prReassign(ctx, args[0], args[1]) })
+	case "stats users":
+		return c.withArgs(args, 0, func() error { return c.statsUsers(ctx) })
+	case "stats user":
+		return c.withArgs(args, 1, func() error { return c.statsUser(ctx, args[0]) })
+	case "stats teams":
+		return c.withArgs(args, 0, func() error { return c.statsTeams(ctx) })
+	case "stats team":
+		return c.withArgs(args, 1, func() error { return c.statsTeam(ctx, args[0]) })
+	case "stats prs":
+		return c.withArgs(args, 0, func() error { return c.statsPRs(ctx) })
+	}
+	return fmt.Errorf("unknown command %q, see prctl -h", group+" "+command)
+}
+
+func (c *cli) withArgs(args []string, count int, command func() error) error {
+	if len(args) != count {
+		return fmt.Errorf("expected %d arguments, got %d", count, len(args))
+	}
+	return command()
+}
+
+// Teams
+
+func (c *cli) teamCreate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("team create", flag.ContinueOnError)
+	file := flags.String("f", "", "team file in YAML or JSON format")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("team file is required (-f)")
+	}
+
+	team, err := readTeam(*file)
+	if err != nil {
+		return err
+	}
+
+	created, err := c.client.AddTeam(ctx, *team)
+	if err != nil {
+		return err
+	}
+	return c.out.team(created)
+}
+
+// Read team from file, YAML uses the same field names as JSON API
+func readTeam(path string) (*client.Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	var team client.Team
+	if err := json.Unmarshal(data, &team); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &team, nil
+}
+
+func (c *cli) teamGet(ctx context.Context, teamName string) error {
+	team, err := c.client.GetTeam(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	return c.out.team(team)
+}
+
+// Users
+
+func (c *cli) userSetActive(ctx context.Context, userID string, active bool) error {
+	user, err := c.client.SetIsActive(ctx, userID, active)
+	if err != nil {
+		return err
+	}
+	return c.out.user(user)
+}
+
+func (c *cli) userReviews(ctx context.Context, userID string) error {
+	prs, err := c.client.GetReview(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return c.out.reviews(prs)
+}
+
+// Pull requests
+
+func (c *cli) prCreate(ctx context.Context, prID, name, authorID string) error {
+	pr, err := c.client.CreatePR(ctx, client.PullRequestCreateQuery{
+		PullRequestID:   prID,
+		PullRequestName: name,
+		AuthorID:        authorID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.pullRequest(pr)
+}
+
+func (c *cli) prMerge(ctx context.Context, prID string) error {
+	pr, err := c.client.MergePR(ctx, prID)
+	if err != nil {
+		return err
+	}
+	return c.out.pullRequest(pr)
+}
+
+func (c *cli) prReassign(ctx context.Context, prID, oldUserID string) error {
+	pr, replacedBy, err := c.client.Reassign(ctx, prID, oldUserID)
+	if err != nil {
+		return err
+	}
+	return c.out.reassign(pr, replacedBy)
+}
+
+// Statistics
+
+func (c *cli) statsUsers(ctx context.Context) error {
+	statistics, err := c.client.GetUsersStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	return c.out.values(statistics, [][2]string{
+		{"TOTAL USERS", fmt.Sprint(statistics.TotalUserNumber)},
+		{"ACTIVE USERS", fmt.Sprint(statistics.TotalActiveUserNumber)},
+	})
+}
+
+func (c *cli) statsUser(ctx context.Context, userID string) error {
+	statistics, err := c.client.GetUserStatistics(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return c.out.values(statistics, [][2]string{
+		{"USER", statistics.UserID},
+		{"USERNAME", statistics.Username},
+		{"TEAM", statistics.TeamName},
+		{"ACTIVE", fmt.Sprint(statistics.IsActive)},
+		{"ASSIGNMENTS", fmt.Sprint(statistics.AssignmentsCount)},
+	})
+}
+
+func (c *cli) statsTeams(ctx context.Context) error {
+	statistics, err := c.client.GetTeamsStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	return c.out.values(statistics, [][2]string{
+		{"TOTAL TEAMS", fmt.Sprint(statistics.TotalTeamNumber)},
+	})
+}
+
+func (c *cli) statsTeam(ctx context.Context, teamName string) error {
+	statistics, err := c.client.GetTeamStatistics(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	return c.out.values(statistics, [][2]string{
+		{"TEAM", statistics.TeamName},
+		{"MEMBERS", fmt.Sprint(statistics.MembersTotal)},
+		{"PULL REQUESTS", fmt.Sprint(statistics.PullRequestsTotal)},
+		{"OPEN PULL REQUESTS", fmt.Sprint(statistics.ActivePullRequestsTotal)},
+	})
+}
+
+func (c *cli) statsPRs(ctx context.Context) error {
+	statistics, err := c.client.GetPRStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	return c.out.values(statistics, [][2]string{
+		{"TOTAL PULL REQUESTS", fmt.Sprint(statistics.TotalPR)},
+		{"OPEN PULL REQUESTS", fmt.Sprint(statistics.TotalActivePR)},
+	})
+}
@@ -0,0 +1,80 @@
+// prctl is a command-line client of the PR reviewer assign service for operators.
+package main
+
+import (
+	"PR_reviewer_assign_service/pkg/client"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+const usage = `Usage: prctl [flags] <command> [arguments]
+
+Commands:
+  team create -f <file>              create team from YAML or JSON file
+  team get <team_name>               show team members
+  user activate <user_id>            mark user as active
+  user deactivate <user_id>          mark user as inactive
+  user reviews <user_id>             show user's review queue
+  pr create <pr_id> <name> <author>  create pull request
+  pr merge <pr_id>                   merge pull request
+  pr reassign <pr_id> <old_user_id>  replace reviewer of pull request
+  stats users                        users statistics
+  stats user <user_id>               statistics of one user
+  stats teams                        teams statistics
+  stats team <team_name>             statistics of one team
+  stats prs                          pull requests statistics
+
+Flags:
+`
+
+func main() {
+	flags := flag.NewFlagSet("prctl", flag.ExitOnError)
+	baseURL := flags.String("url", envOr("PRCTL_URL", "http://localhost:8080"), "service base URL (PRCTL_URL)")
+	token := flags.String("token", os.Getenv("PRCTL_TOKEN"), "API key (PRCTL_TOKEN)")
+	output := flags.String("o", "table", "output format: table or json")
+	timeout := flags.Duration("timeout", 30*time.Second, "request timeout")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if *output != "table" && *output != "json" {
+		fail(fmt.Errorf("unknown output format %q", *output))
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var opts []client.Option
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	cli := &cli{
+		client: client.New(*baseURL, opts...),
+		out:    newPrinter(os.Stdout, *output == "json"),
+	}
+	if err := cli.run(ctx, flags.Arg(0), flags.Arg(1), flags.Args()[2:]); err != nil {
+		fail(err)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "prctl:", err)
+	os.Exit(1)
+}
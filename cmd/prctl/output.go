@@ -0,0 +1,99 @@
+package main
+
+import (
+	"PR_reviewer_assign_service/pkg/client"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Prints results as aligned tables or as JSON
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, asJSON bool) *printer {
+	return &printer{w: w, json: asJSON}
+}
+
+func (p *printer) writeJSON(data interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (p *printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// Print name-value pairs
+func (p *printer) values(data interface{}, values [][2]string) error {
+	if p.json {
+		return p.writeJSON(data)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, value := range values {
+		fmt.Fprintf(tw, "%s:\t%s\n", value[0], value[1])
+	}
+	return tw.Flush()
+}
+
+func (p *printer) team(team *client.Team) error {
+	if p.json {
+		return p.writeJSON(team)
+	}
+	fmt.Fprintf(p.w, "Team %s\n", team.TeamName)
+	rows := make([][]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		rows = append(rows, []string{member.UserID, member.Username, fmt.Sprint(member.IsActive), fmt.Sprint(member.IsTeamLead)})
+	}
+	return p.table([]string{"USER", "USERNAME", "ACTIVE", "LEAD"}, rows)
+}
+
+func (p *printer) user(user *client.User) error {
+	if p.json {
+		return p.writeJSON(user)
+	}
+	return p.table([]string{"USER", "USERNAME", "TEAM", "ACTIVE"}, [][]string{
+		{user.UserID, user.Username, user.TeamName, fmt.Sprint(user.IsActive)},
+	})
+}
+
+func (p *printer) reviews(prs []client.PullRequestShort) error {
+	if p.json {
+		return p.writeJSON(prs)
+	}
+	rows := make([][]string, 0, len(prs))
+	for _, pr := range prs {
+		rows = append(rows, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status})
+	}
+	return p.table([]string{"PULL REQUEST", "NAME", "AUTHOR", "STATUS"}, rows)
+}
+
+func (p *printer) pullRequest(pr *client.PullRequest) error {
+	if p.json {
+		return p.writeJSON(pr)
+	}
+	return p.table([]string{"PULL REQUEST", "NAME", "AUTHOR", "STATUS", "REVIEWERS"}, [][]string{
+		{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, strings.Join(pr.AssignedReviewers, ",")},
+	})
+}
+
+func (p *printer) reassign(pr *client.PullRequest, replacedBy string) error {
+	if p.json {
+		return p.writeJSON(map[string]interface{}{"pr": pr, "replaced_by": replacedBy})
+	}
+	if err := p.pullRequest(pr); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.w, "Replaced by %s\n", replacedBy)
+	return nil
+}
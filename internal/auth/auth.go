@@ -0,0 +1,105 @@
+// Package auth describes authenticated callers and their permissions.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Permission to call group of endpoints
+type Scope string
+
+const (
+	ScopeRead       Scope = "read"
+	ScopeWriteTeams Scope = "write:teams"
+	ScopeWriteUsers Scope = "write:users"
+	ScopeWritePRs   Scope = "write:prs"
+	// Allows everything, including webhooks and API keys management
+	ScopeAdmin Scope = "admin"
+)
+
+func IsKnownScope(scope Scope) bool {
+	switch scope {
+	case ScopeRead, ScopeWriteTeams, ScopeWriteUsers, ScopeWritePRs, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// Role of token callers
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	// Modifies members and pull requests of own team
+	RoleTeamLead Role = "team-lead"
+	// Reads everything, works with own pull requests and activity
+	RoleMember Role = "member"
+)
+
+// Scopes granted to role
+func RoleScopes(role Role) []Scope {
+	switch role {
+	case RoleAdmin:
+		return []Scope{ScopeAdmin}
+	case RoleTeamLead:
+		return []Scope{ScopeRead, ScopeWriteTeams, ScopeWriteUsers, ScopeWritePRs}
+	case RoleMember:
+		return []Scope{ScopeRead, ScopeWriteUsers, ScopeWritePRs}
+	}
+	return nil
+}
+
+// Authenticated caller
+type Principal struct {
+	// Name of the API key or user id from token
+	Subject string
+	// Set for API keys
+	KeyID int64
+	// Set for tokens
+	UserID   string
+	TeamName string
+	Role     Role
+	Scopes   []Scope
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Caller of the request, nil if authentication is disabled
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Prefix of generated API keys, helps to find leaked keys
+const KeyPrefix = "prk_"
+
+// Generate new random API key
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+// Only hashes of API keys are stored
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
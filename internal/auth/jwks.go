@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Public keys for checking token signatures, loaded from JWKS file or URL
+type KeySet struct {
+	source string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// Time of the last refresh, failed ones too
+	attemptedAt time.Time
+	// Refresh in progress, concurrent callers wait for it
+	refreshing *refreshCall
+}
+
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+const (
+	// Remote keys are reloaded after this time
+	jwksTTL = time.Hour
+	// Remote keys are reloaded at most once in this time, even if identity provider is down
+	jwksMinRefresh = time.Minute
+)
+
+// Load keys from path or http(s) URL
+func NewKeySet(ctx context.Context, source string) (*KeySet, error) {
+	ks := &KeySet{
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) remote() bool {
+	return strings.HasPrefix(ks.source, "http://") || strings.HasPrefix(ks.source, "https://")
+}
+
+// Find key by id, empty id matches the only key of the set
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	key, ok := ks.lookup(kid)
+	now := ks.now()
+	// Keys may be rotated by identity provider, unknown ids of forged tokens don't reload keys more often
+	stale := !ok || now.Sub(ks.fetchedAt) > jwksTTL
+	call := ks.refreshing
+	if call == nil && ks.remote() && stale && now.Sub(ks.attemptedAt) > jwksMinRefresh {
+		call = ks.startRefresh(now)
+	}
+	ks.mu.Unlock()
+
+	if call != nil && !ok {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		ks.mu.RLock()
+		key, ok = ks.lookup(kid)
+		ks.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// Reload keys in background, called with mu locked. Known keys are used meanwhile
+func (ks *KeySet) startRefresh(now time.Time) *refreshCall {
+	call := &refreshCall{done: make(chan struct{})}
+	ks.refreshing = call
+	ks.attemptedAt = now
+	go func() {
+		// Callers may give up waiting, the result is kept for others
+		call.err = ks.refresh(context.Background())
+		ks.mu.Lock()
+		ks.refreshing = nil
+		ks.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	data, err := ks.read(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS from %s: %w", ks.source, err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS from %s: %w", ks.source, err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetchedAt = ks.now()
+	ks.attemptedAt = ks.fetchedAt
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !ks.remote() {
+		return os.ReadFile(ks.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// JSON Web Key, only RSA and P-256 EC keys are supported
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		// Skip encryption keys
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys")
+	}
+	return keys, nil
+}
+
+// Public key, nil for unsupported key types
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on P-256 curve")
+		}
+		return key, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url number")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Identity provider serving JWKS, counts fetches
+type testIDP struct {
+	server  *httptest.Server
+	fetches atomic.Int32
+	mu      sync.Mutex
+	jwks    []byte
+	down    bool
+}
+
+func newTestIDP(t *testing.T, keys ...testKey) *testIDP {
+	idp := &testIDP{jwks: jwksJSON(keys...)}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.fetches.Add(1)
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		if idp.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(idp.jwks)
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIDP) set(down bool, keys ...testKey) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.down = down
+	if len(keys) > 0 {
+		idp.jwks = jwksJSON(keys...)
+	}
+}
+
+func remoteKeySet(t *testing.T, idp *testIDP) (*KeySet, *clock.Manual) {
+	t.Helper()
+	ks, err := NewKeySet(context.Background(), idp.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewManual(time.Now())
+	ks.mu.Lock()
+	ks.now = clk.Now
+	ks.fetchedAt, ks.attemptedAt = clk.Now(), clk.Now()
+	ks.mu.Unlock()
+	return ks, clk
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t, "old"), newECKey(t, "new")
+	idp := newTestIDP(t, oldKey)
+	ks, clk := remoteKeySet(t, idp)
+
+	idp.set(false, oldKey, newKey)
+	// Loaded recently, unknown kid doesn't reload keys
+	if _, err := ks.Key(context.Background(), "new"); err == nil {
+		t.Fatal("key is found before refresh")
+	}
+	if got := idp.fetches.Load(); got != 1 {
+		t.Fatalf("fetches %d, want 1", got)
+	}
+
+	clk.Advance(jwksMinRefresh + time.Second)
+	if _, err := ks.Key(context.Background(), "new"); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if got := idp.fetches.Load(); got != 2 {
+		t.Fatalf("fetches %d, want 2", got)
+	}
+}
+
+func TestKeySetRefreshIsThrottledWhileIDPIsDown(t *testing.T) {
+	key := newRSAKey(t, "k1")
+	idp := newTestIDP(t, key)
+	ks, clk := remoteKeySet(t, idp)
+	idp.set(true)
+	clk.Advance(jwksMinRefresh + time.Second)
+
+	// Forged tokens with random kids
+	for _, kid := range []string{"a", "b", "c", "d"} {
+		if _, err := ks.Key(context.Background(), kid); err == nil {
+			t.Fatalf("unknown key %q is found", kid)
+		}
+	}
+	if got := idp.fetches.Load(); got != 2 {
+		t.Fatalf("fetches %d, want 2: one on start and one failed refresh", got)
+	}
+	// Known key still works
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("known key: %v", err)
+	}
+
+	clk.Advance(jwksMinRefresh + time.Second)
+	ks.Key(context.Background(), "e")
+	if got := idp.fetches.Load(); got != 3 {
+		t.Fatalf("fetches %d, want 3 after throttle interval", got)
+	}
+}
+
+func TestKeySetConcurrentRefreshIsShared(t *testing.T) {
+	oldKey, newKey := newRSAKey(t, "old"), newRSAKey(t, "new")
+	idp := newTestIDP(t, oldKey)
+	ks, clk := remoteKeySet(t, idp)
+	idp.set(false, oldKey, newKey)
+	clk.Advance(jwksMinRefresh + time.Second)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Key(context.Background(), "new"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	if got := idp.fetches.Load(); got != 2 {
+		t.Errorf("fetches %d, want 2: one on start and one shared refresh", got)
+	}
+	for err := range errs {
+		t.Errorf("key: %v", err)
+	}
+}
+
+func TestKeySetExpiredKeysAreReloadedInBackground(t *testing.T) {
+	key := newRSAKey(t, "k1")
+	idp := newTestIDP(t, key)
+	ks, clk := remoteKeySet(t, idp)
+	clk.Advance(jwksTTL + time.Second)
+
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("known key: %v", err)
+	}
+	// Wait for background refresh
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ks.mu.RLock()
+		refreshed := ks.fetchedAt.Equal(clk.Now())
+		ks.mu.RUnlock()
+		if refreshed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("keys aren't reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := idp.fetches.Load(); got != 2 {
+		t.Errorf("fetches %d, want 2", got)
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Settings of bearer JWT checks and claims mapping
+type JWTConfig struct {
+	// Checked if not empty
+	Issuer   string
+	Audience string
+	// Claim with user id
+	UserClaim string
+	// Claim with role or list of roles: admin, team-lead, member
+	RolesClaim string
+	// Claim with team of team leads and members
+	TeamClaim string
+	// Allowed clock difference with identity provider
+	Leeway time.Duration
+}
+
+func DefaultJWTConfig() JWTConfig {
+	return JWTConfig{
+		UserClaim:  "sub",
+		RolesClaim: "roles",
+		TeamClaim:  "team_name",
+		Leeway:     time.Minute,
+	}
+}
+
+// Checks RS256/ES256 tokens signed by keys from KeySet
+type JWTVerifier struct {
+	keys   *KeySet
+	config JWTConfig
+	now    func() time.Time
+}
+
+var ErrInvalidToken = errors.New("invalid token")
+
+func NewJWTVerifier(keys *KeySet, config JWTConfig) *JWTVerifier {
+	return &JWTVerifier{keys: keys, config: config, now: time.Now}
+}
+
+// Token looks like JWT, not like API key
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Check token and map its claims to caller
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	// Header
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Signature
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// Claims
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return v.principal(claims)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key doesn't match algorithm %s", alg)
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], signature)
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key doesn't match algorithm %s", alg)
+		}
+		// Signature is r and s, 32 bytes each
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, hash[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+
+	// Expiration is required
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.config.Leeway)) {
+		return fmt.Errorf("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+
+	if v.config.Issuer != "" && claims["iss"] != v.config.Issuer {
+		return fmt.Errorf("unexpected issuer")
+	}
+	if v.config.Audience != "" && !containsString(claims["aud"], v.config.Audience) {
+		return fmt.Errorf("unexpected audience")
+	}
+	return nil
+}
+
+func (v *JWTVerifier) principal(claims map[string]interface{}) (*Principal, error) {
+	userID, _ := claims[v.config.UserClaim].(string)
+	if userID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.config.UserClaim)
+	}
+	teamName, _ := claims[v.config.TeamClaim].(string)
+
+	// Highest of known roles
+	var role Role
+	for _, r := range []Role{RoleAdmin, RoleTeamLead, RoleMember} {
+		if containsString(claims[v.config.RolesClaim], string(r)) {
+			role = r
+			break
+		}
+	}
+
+	return &Principal{
+		Subject:  userID,
+		UserID:   userID,
+		TeamName: teamName,
+		Role:     role,
+		Scopes:   RoleScopes(role),
+	}, nil
+}
+
+// Claim equals value or is a list that contains it
+func containsString(claim interface{}, value string) bool {
+	switch c := claim.(type) {
+	case string:
+		return c == value
+	case []interface{}:
+		for _, item := range c {
+			if s, ok := item.(string); ok && s == value {
+				return true
+			}
+		}
+	}
+	return false
+}
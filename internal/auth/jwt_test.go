@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+// Signing key of test identity provider
+type testKey struct {
+	kid     string
+	alg     string
+	private crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: "RS256", private: key}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: "ES256", private: key}
+}
+
+func (k testKey) jwk() map[string]string {
+	switch key := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig",
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256",
+			"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+	}
+	panic("unsupported key")
+}
+
+func (k testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	hash := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := k.private.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func jwksJSON(keys ...testKey) []byte {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// Key set from local JWKS file
+func keyFile(t *testing.T, keys ...testKey) *KeySet {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(keys...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeySet(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func testVerifier(ks *KeySet) *JWTVerifier {
+	config := DefaultJWTConfig()
+	config.Issuer = "https://idp.example.com"
+	config.Audience = "pr-reviewer"
+	v := NewJWTVerifier(ks, config)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":       "u1",
+		"iss":       "https://idp.example.com",
+		"aud":       []string{"other", "pr-reviewer"},
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"roles":     []string{"member"},
+		"team_name": "backend",
+	}
+}
+
+func TestJWTVerify(t *testing.T) {
+	for _, key := range []testKey{newRSAKey(t, "rsa-1"), newECKey(t, "ec-1")} {
+		t.Run(key.alg, func(t *testing.T) {
+			v := testVerifier(keyFile(t, key))
+
+			principal, err := v.Verify(context.Background(), key.sign(t, validClaims()))
+			if err != nil {
+				t.Fatalf("valid token: %v", err)
+			}
+			if principal.UserID != "u1" || principal.TeamName != "backend" || principal.Role != RoleMember {
+				t.Errorf("principal %+v", principal)
+			}
+
+			for name, change := range map[string]func(map[string]interface{}){
+				"expired":       func(c map[string]interface{}) { c["exp"] = testNow.Add(-2 * time.Minute).Unix() },
+				"no exp":        func(c map[string]interface{}) { delete(c, "exp") },
+				"not yet valid": func(c map[string]interface{}) { c["nbf"] = testNow.Add(2 * time.Minute).Unix() },
+				"wrong issuer":  func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+				"wrong aud":     func(c map[string]interface{}) { c["aud"] = "other" },
+				"no subject":    func(c map[string]interface{}) { delete(c, "sub") },
+			} {
+				claims := validClaims()
+				change(claims)
+				if _, err := v.Verify(context.Background(), key.sign(t, claims)); !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("%s: error %v, want ErrInvalidToken", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTVerifyLeeway(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	v := testVerifier(keyFile(t, key))
+
+	// Expired less than leeway ago
+	claims := validClaims()
+	claims["exp"] = testNow.Add(-30 * time.Second).Unix()
+	if _, err := v.Verify(context.Background(), key.sign(t, claims)); err != nil {
+		t.Errorf("token within leeway: %v", err)
+	}
+}
+
+func TestJWTVerifyRejectsForeignSignature(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	v := testVerifier(keyFile(t, key))
+
+	// Unknown kid
+	if _, err := v.Verify(context.Background(), newRSAKey(t, "rsa-2").sign(t, validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown kid: error %v", err)
+	}
+	// Known kid, another key
+	if _, err := v.Verify(context.Background(), newRSAKey(t, "rsa-1").sign(t, validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("forged signature: error %v", err)
+	}
+	// Algorithm of another key type
+	ecKey := newECKey(t, "rsa-1")
+	if _, err := v.Verify(context.Background(), ecKey.sign(t, validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("algorithm mismatch: error %v", err)
+	}
+	// Tampered claims
+	token := key.sign(t, validClaims())
+	claims := validClaims()
+	claims["roles"] = "admin"
+	forged := key.sign(t, claims)
+	parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+	if _, err := v.Verify(context.Background(), parts[0]+"."+forgedParts[1]+"."+parts[2]); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered claims: error %v", err)
+	}
+}
+
+func TestJWTRoleMapping(t *testing.T) {
+	key := newECKey(t, "ec-1")
+	v := testVerifier(keyFile(t, key))
+
+	for _, tc := range []struct {
+		roles interface{}
+		want  Role
+	}{
+		{"admin", RoleAdmin},
+		{[]string{"member", "team-lead"}, RoleTeamLead},
+		{[]string{"member", "admin", "team-lead"}, RoleAdmin},
+		{[]string{"member"}, RoleMember},
+		{[]string{"auditor"}, ""},
+		{nil, ""},
+	} {
+		claims := validClaims()
+		if tc.roles == nil {
+			delete(claims, "roles")
+		} else {
+			claims["roles"] = tc.roles
+		}
+		principal, err := v.Verify(context.Background(), key.sign(t, claims))
+		if err != nil {
+			t.Fatalf("roles %v: %v", tc.roles, err)
+		}
+		if principal.Role != tc.want {
+			t.Errorf("roles %v: role %q, want %q", tc.roles, principal.Role, tc.want)
+		}
+		if len(principal.Scopes) != len(RoleScopes(tc.want)) {
+			t.Errorf("roles %v: scopes %v, want %v", tc.roles, principal.Scopes, RoleScopes(tc.want))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Source of current time, so time dependent logic can be tested
+type Clock interface {
+	Now() time.Time
+}
+
+// Real time
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Time that moves only when told to
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) Set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
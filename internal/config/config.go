@@ -0,0 +1,290 @@
+// Package config holds typed service settings loaded from file, environment and flags.
+// It has plain settings only, cmd/main.go converts them for components.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Fields are named by yaml tag in files and flags ("server.addr"), env tag is the environment variable.
+// Fields with secret tag are redacted when printed.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimits  RateLimitsConfig  `yaml:"rate_limits"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Review      ReviewConfig      `yaml:"review"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	Reminders   RemindersConfig   `yaml:"reminders"`
+	Escalation  EscalationConfig  `yaml:"escalation"`
+}
+
+type ServerConfig struct {
+	Addr             string        `yaml:"addr" env:"SERVER_ADDR" usage:"address to listen on"`
+	ReadTimeout      time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" usage:"time to read request"`
+	WriteTimeout     time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" usage:"time to write response"`
+	IdleTimeout      time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" usage:"keep-alive connection idle time"`
+	ShutdownTimeout  time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"deadline to drain requests and stop workers"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"READINESS_TIMEOUT" usage:"timeout of every readiness check"`
+	MaxBodyBytes     int           `yaml:"max_body_bytes" env:"MAX_BODY_BYTES" usage:"largest accepted request body"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url" env:"DATABASE_URL" secret:"true" usage:"PostgreSQL connection string"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 - unlimited"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"maximum connection age"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"maximum connection idle time"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" usage:"debug, info, warn or error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" usage:"json or text"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" usage:"none, otlp or stdout"`
+}
+
+type AuthConfig struct {
+	Disabled    bool      `yaml:"disabled" env:"AUTH_DISABLED" usage:"accept requests without credentials"`
+	AdminAPIKey string    `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true" usage:"bootstrap admin API key"`
+	JWT         JWTConfig `yaml:"jwt"`
+}
+
+type JWTConfig struct {
+	JWKS       string `yaml:"jwks" env:"JWT_JWKS" usage:"JWKS file or URL, enables JWT authentication"`
+	Issuer     string `yaml:"issuer" env:"JWT_ISSUER" usage:"required iss claim"`
+	Audience   string `yaml:"audience" env:"JWT_AUDIENCE" usage:"required aud claim"`
+	UserClaim  string `yaml:"user_claim" env:"JWT_USER_CLAIM" usage:"claim with user id"`
+	RolesClaim string `yaml:"roles_claim" env:"JWT_ROLES_CLAIM" usage:"claim with roles"`
+	TeamClaim  string `yaml:"team_claim" env:"JWT_TEAM_CLAIM" usage:"claim with team name"`
+}
+
+type RateLimitsConfig struct {
+	Rules       string `yaml:"rules" env:"RATE_LIMITS" usage:"overrides of group limits (prs=5:10,read=100:200) or off"`
+	Credentials string `yaml:"credentials" env:"RATE_LIMIT_CREDENTIALS" usage:"limits of credentials (key:3/read=100:200,user:u1/prs=20:40)"`
+	TrustProxy  bool   `yaml:"trust_proxy" env:"RATE_LIMIT_TRUST_PROXY" usage:"take client IP from X-Forwarded-For"`
+}
+
+type IdempotencyConfig struct {
+	TTLHours int `yaml:"ttl_hours" env:"IDEMPOTENCY_TTL_HOURS" usage:"hours to keep responses of idempotent requests"`
+}
+
+type ReviewConfig struct {
+	ReviewersPerPR int `yaml:"reviewers_per_pr" env:"REVIEWERS_PER_PR" usage:"reviewers assigned to new pull request"`
+	SLAHours       int `yaml:"sla_hours" env:"REVIEW_SLA_HOURS" usage:"review SLA of teams without own settings"`
+}
+
+// Nested rules get environment variables by prefix: VALIDATION_USERNAME_PATTERN
+type ValidationConfig struct {
+	TeamName FieldRuleConfig `yaml:"team_name" env:"VALIDATION_TEAM_NAME"`
+	UserID   FieldRuleConfig `yaml:"user_id" env:"VALIDATION_USER_ID"`
+	Username FieldRuleConfig `yaml:"username" env:"VALIDATION_USERNAME"`
+	PRID     FieldRuleConfig `yaml:"pr_id" env:"VALIDATION_PR_ID"`
+	PRName   FieldRuleConfig `yaml:"pr_name" env:"VALIDATION_PR_NAME"`
+}
+
+type FieldRuleConfig struct {
+	Pattern   string `yaml:"pattern" env:"PATTERN" usage:"regular expression the value must match, anchor it with ^ and $"`
+	MinLength int    `yaml:"min_length" env:"MIN_LENGTH" usage:"minimum length in characters"`
+	MaxLength int    `yaml:"max_length" env:"MAX_LENGTH" usage:"maximum length in characters"`
+}
+
+type OutboxConfig struct {
+	Sinks        []string      `yaml:"sinks" env:"OUTBOX_SINKS" usage:"comma separated sinks: webhook, log, file"`
+	File         string        `yaml:"file" env:"OUTBOX_FILE" usage:"path of file sink"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" usage:"how often to publish events"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" usage:"events published at once"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" usage:"attempts before event is dead and isn't published"`
+}
+
+type WebhooksConfig struct {
+	Workers     int           `yaml:"workers" env:"WEBHOOK_WORKERS" usage:"parallel deliveries"`
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" usage:"attempts before delivery fails"`
+	Timeout     time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" usage:"timeout of one attempt"`
+}
+
+type RemindersConfig struct {
+	Notifier      string        `yaml:"notifier" env:"REMINDER_NOTIFIER" usage:"log or webhook"`
+	WebhookURL    string        `yaml:"webhook_url" env:"REMINDER_WEBHOOK_URL" usage:"URL of webhook notifier"`
+	WebhookSecret string        `yaml:"webhook_secret" env:"REMINDER_WEBHOOK_SECRET" secret:"true" usage:"secret to sign reminders"`
+	Interval      time.Duration `yaml:"interval" env:"REMINDER_INTERVAL" usage:"how often to look for overdue pull requests"`
+	RepeatEvery   time.Duration `yaml:"repeat_every" env:"REMINDER_REPEAT_EVERY" usage:"minimal time between reminders about one pull request"`
+}
+
+type EscalationConfig struct {
+	Interval time.Duration `yaml:"interval" env:"ESCALATION_INTERVAL" usage:"how often to look for stale reviews"`
+}
+
+// Settings used when nothing is configured, same as defaults of components
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:             ":8080",
+			ReadTimeout:      15 * time.Second,
+			WriteTimeout:     30 * time.Second,
+			IdleTimeout:      2 * time.Minute,
+			ShutdownTimeout:  30 * time.Second,
+			ReadinessTimeout: 2 * time.Second,
+			MaxBodyBytes:     1 << 20,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Log:     LogConfig{Level: "info", Format: "json"},
+		Tracing: TracingConfig{Exporter: "none"},
+		Auth: AuthConfig{
+			JWT: JWTConfig{UserClaim: "sub", RolesClaim: "roles", TeamClaim: "team_name"},
+		},
+		Idempotency: IdempotencyConfig{TTLHours: 24},
+		Review:      ReviewConfig{ReviewersPerPR: 2, SLAHours: 48},
+		// Letters of any alphabet are allowed in names, ids stay ASCII
+		Validation: ValidationConfig{
+			TeamName: FieldRuleConfig{Pattern: `^[\p{L}\p{M}\p{N}_.\-\s]*$`, MinLength: 1, MaxLength: 100},
+			UserID:   FieldRuleConfig{Pattern: `^[a-zA-Z0-9\-]*$`, MinLength: 1, MaxLength: 50},
+			Username: FieldRuleConfig{Pattern: `^[\p{L}\p{M}]*$`, MinLength: 2, MaxLength: 50},
+			PRID:     FieldRuleConfig{Pattern: `^[a-zA-Z0-9\-]*$`, MinLength: 1, MaxLength: 50},
+			PRName:   FieldRuleConfig{Pattern: `^[\p{L}\p{M}\p{N}_.\-\s]*$`, MinLength: 1, MaxLength: 150},
+		},
+		Outbox: OutboxConfig{
+			Sinks:        []string{"webhook"},
+			PollInterval: time.Second,
+			BatchSize:    100,
+			MaxAttempts:  10,
+		},
+		Webhooks: WebhooksConfig{
+			Workers:     4,
+			MaxAttempts: 5,
+			Timeout:     10 * time.Second,
+		},
+		Reminders: RemindersConfig{
+			Notifier:    "log",
+			Interval:    5 * time.Minute,
+			RepeatEvery: 24 * time.Hour,
+		},
+		Escalation: EscalationConfig{Interval: 10 * time.Minute},
+	}
+}
+
+// Check settings, all problems are returned together
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	// Server
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ReadinessTimeout > 0, "server.readiness_timeout must be positive")
+	check(c.Server.MaxBodyBytes >= 1024, "server.max_body_bytes must be at least 1024")
+
+	// Database, url is checked by ValidateRun
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must not exceed database.max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+
+	// Log and tracing
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level %q is unknown", c.Log.Level)
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format %q is unknown", c.Log.Format)
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		check(false, "tracing.exporter %q is unknown", c.Tracing.Exporter)
+	}
+
+	// Auth
+	if c.Auth.JWT.JWKS != "" {
+		check(c.Auth.JWT.UserClaim != "", "auth.jwt.user_claim is required")
+		check(c.Auth.JWT.RolesClaim != "", "auth.jwt.roles_claim is required")
+		check(c.Auth.JWT.TeamClaim != "", "auth.jwt.team_claim is required")
+	}
+
+	// Rate limits are parsed by cmd/main.go, it knows route groups
+
+	// Domain
+	check(c.Idempotency.TTLHours >= 1, "idempotency.ttl_hours must be at least 1")
+	check(c.Review.ReviewersPerPR >= 1 && c.Review.ReviewersPerPR <= 10, "review.reviewers_per_pr must be from 1 to 10")
+	check(c.Review.SLAHours >= 1, "review.sla_hours must be at least 1")
+
+	// Validation rules, longer values wouldn't fit columns of schema.sql
+	for _, rule := range []struct {
+		name   string
+		column int
+		FieldRuleConfig
+	}{
+		{"team_name", 100, c.Validation.TeamName},
+		{"user_id", 50, c.Validation.UserID},
+		{"username", 50, c.Validation.Username},
+		{"pr_id", 50, c.Validation.PRID},
+		{"pr_name", 150, c.Validation.PRName},
+	} {
+		_, err := regexp.Compile(rule.Pattern)
+		check(err == nil, "validation.%s.pattern: %v", rule.name, err)
+		check(rule.MinLength >= 0, "validation.%s.min_length must not be negative", rule.name)
+		check(rule.MaxLength >= 1 && rule.MaxLength >= rule.MinLength, "validation.%s.max_length must be at least 1 and not less than minimum", rule.name)
+		check(rule.MaxLength <= rule.column, "validation.%s.max_length must be at most %d, width of database column", rule.name, rule.column)
+	}
+
+	// Background work
+	check(len(c.Outbox.Sinks) > 0, "outbox.sinks must not be empty")
+	for _, sink := range c.Outbox.Sinks {
+		switch sink {
+		case "webhook", "log":
+		case "file":
+			check(c.Outbox.File != "", "outbox.file is required by file sink")
+		default:
+			check(false, "outbox.sinks: unknown sink %q", sink)
+		}
+	}
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval must be positive")
+	check(c.Outbox.BatchSize >= 1, "outbox.batch_size must be at least 1")
+	check(c.Outbox.MaxAttempts >= 1, "outbox.max_attempts must be at least 1")
+	check(c.Webhooks.Workers >= 1, "webhooks.workers must be at least 1")
+	check(c.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts must be at least 1")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	switch c.Reminders.Notifier {
+	case "log":
+	case "webhook":
+		_, err := url.ParseRequestURI(c.Reminders.WebhookURL)
+		check(err == nil, "reminders.webhook_url must be valid URL for webhook notifier")
+	default:
+		check(false, "reminders.notifier %q is unknown", c.Reminders.Notifier)
+	}
+	check(c.Reminders.Interval > 0, "reminders.interval must be positive")
+	check(c.Reminders.RepeatEvery > 0, "reminders.repeat_every must be positive")
+	check(c.Escalation.Interval > 0, "escalation.interval must be positive")
+
+	return errors.Join(errs...)
+}
+
+// Settings required to run the service, but not to print or check its config
+func (c *Config) ValidateRun() error {
+	if c.Database.URL == "" {
+		return errors.New("database.url is required")
+	}
+	return nil
+}
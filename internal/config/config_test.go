@@ -0,0 +1,153 @@
+package config_test
+
+import (
+	"PR_reviewer_assign_service/internal/config"
+	"PR_reviewer_assign_service/internal/handlers"
+	"PR_reviewer_assign_service/internal/outbox"
+	"PR_reviewer_assign_service/internal/reminders"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/tracing"
+	"PR_reviewer_assign_service/internal/webhooks"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  addr: ":7000"
+  read_timeout: 7s
+log:
+  level: warn
+review:
+  reviewers_per_pr: 3
+  sla_hours: 12
+`)
+	t.Setenv(config.EnvFile, path)
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("REVIEW_SLA_HOURS", "24")
+	t.Setenv("VALIDATION_USERNAME_MAX_LENGTH", "40")
+
+	cfg, err := config.Load("test", []string{"-review.sla_hours=36", "-server.write_timeout=1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name      string
+		got, want any
+	}{
+		{"default", cfg.Server.IdleTimeout, 2 * time.Minute},
+		{"file over default", cfg.Server.Addr, ":7000"},
+		{"file over default", cfg.Review.ReviewersPerPR, 3},
+		{"env over file", cfg.Log.Level, "error"},
+		{"env of nested rule", cfg.Validation.Username.MaxLength, 40},
+		{"flag over env and file", cfg.Review.SLAHours, 36},
+		{"flag over default", cfg.Server.WriteTimeout, time.Minute},
+		{"file field untouched by flags", cfg.Server.ReadTimeout, 7 * time.Second},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestLoadFlagOfConfigFile(t *testing.T) {
+	t.Setenv(config.EnvFile, writeFile(t, "log:\n  level: warn\n"))
+	path := writeFile(t, "log:\n  level: debug\n")
+	cfg, err := config.Load("test", []string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("log level %s, want debug from -config file", cfg.Log.Level)
+	}
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	t.Setenv(config.EnvFile, writeFile(t, "review:\n  reviewers_per_pr: 0\nlog:\n  format: xml\n"))
+	_, err := config.Load("test", []string{"-validation.pr_id.max_length=60"})
+	if err == nil {
+		t.Fatal("invalid config is loaded")
+	}
+	for _, want := range []string{"review.reviewers_per_pr", "log.format", "validation.pr_id.max_length"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't report %s", err, want)
+		}
+	}
+
+	// Unknown fields of file are errors too
+	t.Setenv(config.EnvFile, writeFile(t, "server:\n  adress: \":9000\"\n"))
+	if _, err := config.Load("test", nil); err == nil {
+		t.Error("unknown field is accepted")
+	}
+}
+
+func TestPrintWithoutDatabaseURL(t *testing.T) {
+	t.Setenv(config.EnvFile, "")
+	t.Setenv("DATABASE_URL", "")
+	cfg, err := config.Load("test", []string{"-auth.admin_api_key=s3cr3t-key"})
+	if err != nil {
+		t.Fatalf("config without database url isn't loaded: %v", err)
+	}
+	if err := cfg.ValidateRun(); err == nil {
+		t.Error("service runs without database url")
+	}
+
+	var out bytes.Buffer
+	if err := config.Print(&out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "s3cr3t-key") || !strings.Contains(out.String(), "admin_api_key: REDACTED") {
+		t.Errorf("secret isn't redacted:\n%s", out.String())
+	}
+}
+
+// Defaults of config are plain values, they must follow defaults of components
+func TestDefaultMatchesComponents(t *testing.T) {
+	cfg := config.Default()
+	outboxDefaults := outbox.DefaultConfig()
+	webhookDefaults := webhooks.DefaultConfig()
+	reminderDefaults := reminders.DefaultConfig()
+	rules := handlers.DefaultValidationRules()
+
+	for _, test := range []struct {
+		name      string
+		got, want any
+	}{
+		{"server.max_body_bytes", int64(cfg.Server.MaxBodyBytes), int64(handlers.DefaultMaxBodyBytes)},
+		{"tracing.exporter", cfg.Tracing.Exporter, tracing.ExporterNone},
+		{"idempotency.ttl_hours", time.Duration(cfg.Idempotency.TTLHours) * time.Hour, service.DefaultIdempotencyTTL},
+		{"review.reviewers_per_pr", cfg.Review.ReviewersPerPR, service.DefaultReviewersPerPR},
+		{"review.sla_hours", cfg.Review.SLAHours, service.DefaultReviewSLAHours},
+		{"validation.team_name", handlers.FieldRule(cfg.Validation.TeamName), rules.TeamName},
+		{"validation.user_id", handlers.FieldRule(cfg.Validation.UserID), rules.UserID},
+		{"validation.username", handlers.FieldRule(cfg.Validation.Username), rules.Username},
+		{"validation.pr_id", handlers.FieldRule(cfg.Validation.PRID), rules.PRID},
+		{"validation.pr_name", handlers.FieldRule(cfg.Validation.PRName), rules.PRName},
+		{"outbox.poll_interval", cfg.Outbox.PollInterval, outboxDefaults.PollInterval},
+		{"outbox.batch_size", cfg.Outbox.BatchSize, outboxDefaults.BatchSize},
+		{"outbox.max_attempts", cfg.Outbox.MaxAttempts, outboxDefaults.MaxAttempts},
+		{"webhooks.workers", cfg.Webhooks.Workers, webhookDefaults.Workers},
+		{"webhooks.max_attempts", cfg.Webhooks.MaxAttempts, webhookDefaults.MaxAttempts},
+		{"webhooks.timeout", cfg.Webhooks.Timeout, webhookDefaults.Timeout},
+		{"reminders.interval", cfg.Reminders.Interval, reminderDefaults.Interval},
+		{"reminders.repeat_every", cfg.Reminders.RepeatEvery, reminderDefaults.RepeatEvery},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
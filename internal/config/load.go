@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variable with path of config file, same as -config flag
+const EnvFile = "CONFIG_FILE"
+
+// Load settings with precedence: flags, environment, file (YAML or JSON), defaults.
+// Flags are named by field path: -server.addr=:9090, file path is given by -config or CONFIG_FILE.
+func Load(name string, args []string) (*Config, error) {
+	cfg := Default()
+
+	// Flags are parsed first to find the file, but applied last
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := flags.String("config", os.Getenv(EnvFile), "path of YAML or JSON config file")
+	values := make(map[string]string)
+	for _, f := range fields(&cfg) {
+		path := f.path
+		usage := f.usage
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		store := func(value string) error {
+			values[path] = value
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			flags.BoolFunc(path, usage, store)
+		} else {
+			flags.Func(path, usage, store)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	if *file != "" {
+		if err := loadFile(&cfg, *file); err != nil {
+			return nil, err
+		}
+	}
+
+	// Environment and flags are applied to fields of the loaded config
+	for _, f := range fields(&cfg) {
+		if f.env != "" {
+			if value, ok := os.LookupEnv(f.env); ok {
+				if err := f.set(value); err != nil {
+					return nil, fmt.Errorf("%s: %v", f.env, err)
+				}
+			}
+		}
+	}
+	for _, f := range fields(&cfg) {
+		if value, ok := values[f.path]; ok {
+			if err := f.set(value); err != nil {
+				return nil, fmt.Errorf("-%s: %v", f.path, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// JSON is valid YAML, so both are read by YAML decoder
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Leaf setting of config
+type field struct {
+	path   string
+	env    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// All leaf settings of cfg in declaration order
+func fields(cfg *Config) []field {
+	var result []field
+	var walk func(v reflect.Value, prefix, envPrefix string)
+	walk = func(v reflect.Value, prefix, envPrefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			path := prefix + sf.Tag.Get("yaml")
+			env := sf.Tag.Get("env")
+			if sf.Type.Kind() == reflect.Struct {
+				// env tag of struct is prefix of its fields, for types used more than once
+				if env != "" {
+					env += "_"
+				}
+				walk(v.Field(i), path+".", envPrefix+env)
+				continue
+			}
+			if env != "" {
+				env = envPrefix + env
+			}
+			result = append(result, field{
+				path:   path,
+				env:    env,
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "")
+	return result
+}
+
+// Parse value from environment or flag
+func (f field) set(value string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		f.value.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		f.value.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		f.value.SetInt(int64(d))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Copy of settings with secrets replaced
+func (c Config) Redacted() Config {
+	for _, f := range fields(&c) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+	return c
+}
+
+// Write effective settings as YAML, secrets are redacted
+func Print(w io.Writer, cfg *Config) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -13,9 +13,23 @@ const (
 	ErrorCodeNotAssigned ErrorCode = "NOT_ASSIGNED" // 409
 	ErrorCodeNoCandidate ErrorCode = "NO_CANDIDATE" // 409
 	ErrorCodeNotFound    ErrorCode = "NOT_FOUND"    // 404
+	// Webhooks
+	ErrorCodeDeliveryNotFailed ErrorCode = "DELIVERY_NOT_FAILED" // 409
+	// Authentication
+	ErrorCodeUnauthorized      ErrorCode = "UNAUTHORIZED"       // 401
+	ErrorCodeInsufficientScope ErrorCode = "INSUFFICIENT_SCOPE" // 403
+	ErrorCodeForbidden         ErrorCode = "FORBIDDEN"          // 403
+	// Idempotency keys
+	ErrorCodeIdempotencyKeyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED" // 422
+	ErrorCodeRequestInProgress    ErrorCode = "REQUEST_IN_PROGRESS"    // 409
+	// Rate limiting
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED" // 429
 	// "Basic" cases
-	ErrorCodeInvalidInput ErrorCode = "INVALID_INPUT"  // 400
-	ErrorCodeInternal     ErrorCode = "INTERNAL_ERROR" // 500
+	ErrorCodeInvalidInput         ErrorCode = "INVALID_INPUT"          // 400
+	ErrorCodeMethodNotAllowed     ErrorCode = "METHOD_NOT_ALLOWED"     // 405
+	ErrorCodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"      // 413
+	ErrorCodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE" // 415
+	ErrorCodeInternal             ErrorCode = "INTERNAL_ERROR"         // 500
 )
 
 // Mapping codes
@@ -49,10 +63,50 @@ var errorMapping = map[ErrorCode]ErrorInfo{
 		Status:  http.StatusNotFound,
 		Message: "Resourse not found",
 	},
+	ErrorCodeDeliveryNotFailed: {
+		Status:  http.StatusConflict,
+		Message: "Only failed deliveries can be replayed",
+	},
+	ErrorCodeUnauthorized: {
+		Status:  http.StatusUnauthorized,
+		Message: "Missing or invalid credentials",
+	},
+	ErrorCodeInsufficientScope: {
+		Status:  http.StatusForbidden,
+		Message: "Credentials don't allow this operation",
+	},
+	ErrorCodeForbidden: {
+		Status:  http.StatusForbidden,
+		Message: "Operation is not allowed for this user",
+	},
+	ErrorCodeIdempotencyKeyReused: {
+		Status:  http.StatusUnprocessableEntity,
+		Message: "Idempotency key was used with another request",
+	},
+	ErrorCodeRequestInProgress: {
+		Status:  http.StatusConflict,
+		Message: "Request with this idempotency key is in progress",
+	},
+	ErrorCodeRateLimited: {
+		Status:  http.StatusTooManyRequests,
+		Message: "Too many requests",
+	},
 	ErrorCodeInvalidInput: {
 		Status:  http.StatusBadRequest,
 		Message: "Invalid input",
 	},
+	ErrorCodeMethodNotAllowed: {
+		Status:  http.StatusMethodNotAllowed,
+		Message: "Method not allowed",
+	},
+	ErrorCodePayloadTooLarge: {
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: "Request body is too large",
+	},
+	ErrorCodeUnsupportedMediaType: {
+		Status:  http.StatusUnsupportedMediaType,
+		Message: "Unsupported content type",
+	},
 	ErrorCodeInternal: {
 		Status:  http.StatusInternalServerError,
 		Message: "Internal service error",
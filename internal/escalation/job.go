@@ -0,0 +1,87 @@
+package escalation
+
+import (
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	// How often to look for stale reviews
+	Interval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{Interval: 10 * time.Minute}
+}
+
+// Replaces reviewers who exceeded escalation time in teams with auto escalation
+type Job struct {
+	service *service.Service
+	config  Config
+	wg      sync.WaitGroup
+	running atomic.Bool
+}
+
+func NewJob(service *service.Service, config Config) *Job {
+	return &Job{service: service, config: config}
+}
+
+// Start job in background, stops when ctx is done
+func (j *Job) Start(ctx context.Context) {
+	j.wg.Add(1)
+	j.running.Store(true)
+	go func() {
+		defer j.wg.Done()
+		defer j.running.Store(false)
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Wait until background work stops after ctx is done
+func (j *Job) Wait() {
+	j.wg.Wait()
+}
+
+// Check that background work is running
+func (j *Job) Running() bool {
+	return j.running.Load()
+}
+
+// Escalate stale reviews once, skipped while another instance escalates them.
+// Instance that runs next finds only reviews that are still stale.
+func (j *Job) Run(ctx context.Context) {
+	ran, err := j.service.RunExclusive(ctx, storage.JobEscalation, j.escalate)
+	if err != "" {
+		slog.ErrorContext(ctx, "Failed to lock escalation", "error_code", err)
+		return
+	}
+	if !ran {
+		slog.DebugContext(ctx, "Reviews are escalated by another instance")
+	}
+}
+
+func (j *Job) escalate(ctx context.Context) {
+	changes, err := j.service.EscalateStaleReviews(ctx)
+	if err != "" {
+		slog.ErrorContext(ctx, "Failed to escalate stale reviews", "error_code", err)
+		return
+	}
+	for _, change := range changes {
+		slog.InfoContext(ctx, "Review escalated", "pull_request_id", change.PullRequestID, "old_reviewer_id", change.OldReviewerID, "new_reviewer_id", change.NewReviewerID)
+	}
+}
@@ -0,0 +1,178 @@
+package escalation
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type jobTest struct {
+	t       *testing.T
+	ctx     context.Context
+	clock   *clock.Manual
+	storage *storagetest.Memory
+	service *service.Service
+	job     *Job
+}
+
+// Team backend of author u1 and reviewers u2..u6, reviews escalate after 2 hours
+func newJobTest(t *testing.T, reviewersPerPR int, settings models.TeamSettings) *jobTest {
+	t.Helper()
+	clk := clock.NewManual(testNow)
+	store := storagetest.NewMemory(clk)
+	s := service.NewService(store, nil)
+	s.SetClock(clk)
+	s.SetReviewersPerPR(reviewersPerPR)
+
+	tt := &jobTest{t: t, ctx: context.Background(), clock: clk, storage: store, service: s, job: NewJob(s, DefaultConfig())}
+	team := models.Team{TeamName: "backend"}
+	for _, id := range []string{"u1", "u2", "u3", "u4", "u5", "u6"} {
+		team.Members = append(team.Members, models.TeamMember{UserID: id, Username: id, IsActive: true})
+	}
+	if err := s.CreateTeam(tt.ctx, &team); err != "" {
+		t.Fatalf("create team: %s", err)
+	}
+	settings.TeamName = "backend"
+	if err := s.SetTeamSettings(tt.ctx, &settings); err != "" {
+		t.Fatalf("set settings: %s", err)
+	}
+	return tt
+}
+
+func (tt *jobTest) createPR(id string) *models.PullRequest {
+	tt.t.Helper()
+	pr, err := tt.service.CreatePullRequest(tt.ctx, models.PullRequestCreateQuery{PullRequestID: id, PullRequestName: id, AuthorID: "u1"})
+	if err != "" {
+		tt.t.Fatalf("create pr: %s", err)
+	}
+	return pr
+}
+
+func (tt *jobTest) history(id string) []models.ReviewerChange {
+	tt.t.Helper()
+	history, err := tt.service.GetReviewerHistory(tt.ctx, id)
+	if err != "" {
+		tt.t.Fatalf("history: %s", err)
+	}
+	return history
+}
+
+// Run job after d and check number of escalations in history of pr
+func (tt *jobTest) runAfter(d time.Duration, id string, wantEscalations int) []models.ReviewerChange {
+	tt.t.Helper()
+	tt.clock.Advance(d)
+	tt.job.Run(tt.ctx)
+	history := tt.history(id)
+	escalations := 0
+	for _, change := range history {
+		if change.Reason == models.ReassignReasonEscalation {
+			escalations++
+		}
+	}
+	if escalations != wantEscalations {
+		tt.t.Fatalf("after %v: %d escalations, want %d: %+v", tt.clock.Now().Sub(testNow), escalations, wantEscalations, history)
+	}
+	return history
+}
+
+func TestEscalationUpToMaxEscalations(t *testing.T) {
+	tt := newJobTest(t, 1, models.TeamSettings{ReviewSLAHours: 24, AutoEscalate: true, EscalateAfterHours: 2, MaxEscalations: 2})
+	pr := tt.createPR("pr-1")
+	first := pr.AssignedReviewers[0]
+
+	// Not stale yet
+	tt.runAfter(time.Hour, "pr-1", 0)
+	tt.runAfter(time.Hour, "pr-1", 0)
+
+	// Stale reviewer is replaced, change is recorded with its time
+	history := tt.runAfter(time.Second, "pr-1", 1)
+	change := history[0]
+	if change.OldReviewerID != first || change.NewReviewerID == first || change.NewReviewerID == "u1" {
+		t.Errorf("change %+v of reviewer %s", change, first)
+	}
+	if !change.ChangedAt.Equal(testNow.Add(2*time.Hour + time.Second)) {
+		t.Errorf("changed at %v", change.ChangedAt)
+	}
+	pr, _ = tt.storage.GetPR(tt.ctx, "pr-1")
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != change.NewReviewerID {
+		t.Errorf("reviewers %v, want %s", pr.AssignedReviewers, change.NewReviewerID)
+	}
+	outbox := tt.storage.OutboxEvents()
+	last := outbox[len(outbox)-1]
+	if last.EventType != string(events.TypeReviewerReassigned) || !strings.Contains(string(last.Payload), `"reason":"ESCALATION"`) {
+		t.Errorf("last event %s %s, want reviewer.reassigned with ESCALATION reason", last.EventType, last.Payload)
+	}
+
+	// New reviewer gets the full escalation time
+	tt.runAfter(0, "pr-1", 1)
+	tt.runAfter(2*time.Hour, "pr-1", 1)
+	history = tt.runAfter(time.Second, "pr-1", 2)
+	if history[1].OldReviewerID != change.NewReviewerID {
+		t.Errorf("second change %+v, want replacement of %s", history[1], change.NewReviewerID)
+	}
+
+	// Limit is reached
+	tt.runAfter(24*time.Hour, "pr-1", 2)
+}
+
+func TestEscalationLimitCountsChangesOfTheSameRun(t *testing.T) {
+	tt := newJobTest(t, 2, models.TeamSettings{ReviewSLAHours: 24, AutoEscalate: true, EscalateAfterHours: 2, MaxEscalations: 1})
+	tt.createPR("pr-1")
+
+	// Both reviewers are stale, only one is replaced
+	tt.runAfter(3*time.Hour, "pr-1", 1)
+	tt.runAfter(3*time.Hour, "pr-1", 1)
+}
+
+func TestEscalationIgnoresManualChanges(t *testing.T) {
+	tt := newJobTest(t, 1, models.TeamSettings{ReviewSLAHours: 24, AutoEscalate: true, EscalateAfterHours: 2, MaxEscalations: 1})
+	pr := tt.createPR("pr-1")
+
+	if _, _, err := tt.service.Reassign(tt.ctx, models.PullRequestReassignQuery{PullRequestID: "pr-1", OldUserID: pr.AssignedReviewers[0]}); err != "" {
+		t.Fatalf("reassign: %s", err)
+	}
+	history := tt.runAfter(3*time.Hour, "pr-1", 1)
+	if len(history) != 2 || history[0].Reason != models.ReassignReasonManual {
+		t.Errorf("history %+v, want manual change and escalation", history)
+	}
+}
+
+func TestEscalationSkipsMergedAndDisabled(t *testing.T) {
+	tt := newJobTest(t, 1, models.TeamSettings{ReviewSLAHours: 24, AutoEscalate: true, EscalateAfterHours: 2, MaxEscalations: 1})
+	tt.createPR("pr-1")
+	if _, err := tt.service.MergePullRequest(tt.ctx, models.PullRequestMergeQuery{PullRequestID: "pr-1"}); err != "" {
+		t.Fatalf("merge: %s", err)
+	}
+	tt.runAfter(3*time.Hour, "pr-1", 0)
+
+	tt.createPR("pr-2")
+	if err := tt.service.SetTeamSettings(tt.ctx, &models.TeamSettings{TeamName: "backend", ReviewSLAHours: 24, EscalateAfterHours: 2, MaxEscalations: 1}); err != "" {
+		t.Fatalf("disable escalation: %s", err)
+	}
+	tt.runAfter(3*time.Hour, "pr-2", 0)
+}
+
+func TestEscalationSkippedWhileAnotherInstanceRuns(t *testing.T) {
+	tt := newJobTest(t, 1, models.TeamSettings{ReviewSLAHours: 24, AutoEscalate: true, EscalateAfterHours: 2, MaxEscalations: 1})
+	tt.createPR("pr-1")
+
+	// Other instance holds the job lock
+	ran, err := tt.storage.RunExclusive(tt.ctx, storage.JobEscalation, func(ctx context.Context) {
+		tt.runAfter(3*time.Hour, "pr-1", 0)
+	})
+	if !ran || err != nil {
+		t.Fatalf("lock: %v, %v", ran, err)
+	}
+
+	// Lock is released after the run
+	tt.runAfter(0, "pr-1", 1)
+}
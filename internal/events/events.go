@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Event types
+type Type string
+
+const (
+	TypePRCreated          Type = "pr.created"
+	TypeReviewerAssigned   Type = "reviewer.assigned"
+	TypeReviewerReassigned Type = "reviewer.reassigned"
+	TypePRMerged           Type = "pr.merged"
+	TypeUserDeactivated    Type = "user.deactivated"
+	TypeUserActivated      Type = "user.activated"
+)
+
+var knownTypes = map[Type]bool{
+	TypePRCreated:          true,
+	TypeReviewerAssigned:   true,
+	TypeReviewerReassigned: true,
+	TypePRMerged:           true,
+	TypeUserDeactivated:    true,
+	TypeUserActivated:      true,
+}
+
+// Check that event type is supported
+func IsKnown(t Type) bool {
+	return knownTypes[t]
+}
+
+// Something that happened in the service
+type Event struct {
+	ID         string      `json:"event_id"`
+	Type       Type        `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	TeamName   string      `json:"team_name,omitempty"`
+	UserIDs    []string    `json:"user_ids,omitempty"`
+	Data       interface{} `json:"data"`
+}
+
+// Payloads of events
+type ReviewerAssignedData struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+}
+
+type ReviewerReassignedData struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+	Reason        string `json:"reason"`
+}
+
+// Create new event with random id
+func New(t Type, data interface{}) Event {
+	return Event{
+		ID:         newID(),
+		Type:       t,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Set team and users the event is about, used to filter events
+func (e Event) About(teamName string, userIds ...string) Event {
+	e.TeamName = teamName
+	e.UserIDs = userIds
+	return e
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().String()))[:32]
+	}
+	return hex.EncodeToString(b)
+}
+
+// Interface for everything that can receive events
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
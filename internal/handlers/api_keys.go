@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"log/slog"
+	"net/http"
+)
+
+// Handler for API key management requests
+type APIKeyHandler struct {
+	service *service.Service
+}
+
+func NewAPIKeyHandler(service *service.Service) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+/*
+/apiKeys/add - APIKeyCreateQuery
+*/
+func (h *APIKeyHandler) AddKey(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var key models.APIKeyCreateQuery
+
+	if err := decodeRequest(r, &key); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateAPIKeyCreateQuery(key); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Create key
+	slog.InfoContext(r.Context(), "Creating API key", "name", key.Name)
+	created, err := h.service.CreateAPIKey(r.Context(), key)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key created", "key_id", created.KeyID)
+
+	// Send response
+	writeJSON(w, http.StatusCreated, APIKeyResponse{APIKey: created})
+}
+
+/*
+/apiKeys/list - get method
+*/
+func (h *APIKeyHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	// Get keys
+	slog.DebugContext(r.Context(), "Receiving API keys")
+	keys, err := h.service.GetAPIKeys(r.Context())
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "API keys received")
+
+	// Send response
+	writeJSON(w, http.StatusOK, APIKeysResponse{APIKeys: keys})
+}
+
+/*
+/apiKeys/revoke - APIKeyIDQuery
+*/
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var key models.APIKeyIDQuery
+
+	if err := decodeRequest(r, &key); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateAPIKeyIDQuery(key); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Revoke key
+	slog.InfoContext(r.Context(), "Revoking API key", "key_id", key.KeyID)
+	if err := h.service.RevokeAPIKey(r.Context(), key.KeyID); err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key revoked", "key_id", key.KeyID)
+
+	// Send response
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/service"
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Middleware that authenticates requests and checks scopes of routes
+type Auth struct {
+	service  *service.Service
+	jwt      *auth.JWTVerifier
+	disabled bool
+}
+
+func NewAuth(service *service.Service) *Auth {
+	return &Auth{service: service}
+}
+
+// Accept bearer JWTs checked by verifier besides API keys
+func (a *Auth) UseJWT(verifier *auth.JWTVerifier) {
+	a.jwt = verifier
+}
+
+// Let all requests through, for local development
+func (a *Auth) Disable() {
+	a.disabled = true
+}
+
+// Result of authentication kept in request context between middlewares
+type authResult struct {
+	principal *auth.Principal
+	// Set when credentials are missing or invalid
+	code    errors.ErrorCode
+	message string
+}
+
+type authResultKey struct{}
+
+// Authenticate caller and keep the result in context, so rate limits count requests per caller.
+// Missing or invalid credentials are reported by Require.
+func (a *Auth) Identify(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.disabled {
+			next(w, r)
+			return
+		}
+		result := a.authenticate(r)
+		ctx := context.WithValue(r.Context(), authResultKey{}, result)
+		if result.principal != nil {
+			ctx = auth.WithPrincipal(ctx, result.principal)
+		}
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// Wrap handler so it's called only for callers with scope
+func (a *Auth) Require(scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.disabled {
+			next(w, r)
+			return
+		}
+
+		// Authenticate, unless Identify did it
+		result, ok := r.Context().Value(authResultKey{}).(authResult)
+		if !ok {
+			result = a.authenticate(r)
+		}
+		switch result.code {
+		case "":
+		case errors.ErrorCodeUnauthorized:
+			writeUnauthorized(w, result.message)
+			return
+		default:
+			writeError(w, result.code)
+			return
+		}
+		principal := result.principal
+
+		// Authorize
+		if !principal.HasScope(scope) {
+			writeErrorMessage(w, errors.ErrorCodeInsufficientScope, "Scope "+string(scope)+" is required")
+			return
+		}
+
+		next(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// Find caller by token or API key
+func (a *Auth) authenticate(r *http.Request) authResult {
+	key := credentials(r)
+	if key == "" {
+		return authResult{code: errors.ErrorCodeUnauthorized, message: "API key or token is required"}
+	}
+
+	// Token from identity provider
+	if a.jwt != nil && auth.IsJWT(key) {
+		principal, err := a.jwt.Verify(r.Context(), key)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Token rejected", "error", err)
+			return authResult{code: errors.ErrorCodeUnauthorized, message: "Token is invalid or expired"}
+		}
+		return authResult{principal: principal}
+	}
+
+	// API key
+	principal, err := a.service.AuthenticateAPIKey(r.Context(), key)
+	if err == errors.ErrorCodeUnauthorized {
+		return authResult{code: err, message: "API key is invalid or revoked"}
+	}
+	if err != "" {
+		return authResult{code: err}
+	}
+	return authResult{principal: principal}
+}
+
+// Key of caller in rate limits and idempotency scopes
+func principalKey(principal *auth.Principal) string {
+	if principal.KeyID != 0 {
+		return "key:" + strconv.FormatInt(principal.KeyID, 10)
+	}
+	return "user:" + principal.UserID
+}
+
+// Key from "Authorization: Bearer <key>" or "X-API-Key: <key>"
+func credentials(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pr-reviewer"`)
+	writeErrorMessage(w, errors.ErrorCodeUnauthorized, message)
+}
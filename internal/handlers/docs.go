@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// OpenAPI specification of the service
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Swagger UI page that loads the specification from /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>PR Reviewer Assign Service API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+		};
+	</script>
+</body>
+</html>
+`
+
+/*
+/openapi.json - get method
+*/
+func OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
+
+/*
+/docs - get method
+*/
+func SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
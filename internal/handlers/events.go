@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/stream"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	eventsBatchSize   = 100
+	heartbeatInterval = 15 * time.Second
+	// Server WriteTimeout is replaced by deadline for every write
+	streamWriteTimeout = 2 * heartbeatInterval
+)
+
+// Handler for event stream requests
+type EventHandler struct {
+	service *service.Service
+	broker  *stream.Broker
+}
+
+func NewEventHandler(service *service.Service, broker *stream.Broker) *EventHandler {
+	return &EventHandler{service: service, broker: broker}
+}
+
+/*
+/events/stream?team_name=...&user_id=... - Server-Sent Events
+Resumes after the id from Last-Event-ID header (or last_event_id parameter)
+*/
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	filter := models.EventStreamQuery{
+		TeamName: r.URL.Query().Get("team_name"),
+		UserID:   r.URL.Query().Get("user_id"),
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// Validate input
+	if violations := ValidateEventStreamQuery(filter); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorMessage(w, errors.ErrorCodeInternal, "Streaming is not supported")
+		return
+	}
+
+	// Find position to start from
+	var last int64
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Last event ID "+lastEventID+" is invalid")
+			return
+		}
+		last = seq
+	} else {
+		seq, err := h.service.GetLastEventSeq(r.Context())
+		if err != "" {
+			writeError(w, err)
+			return
+		}
+		last = seq
+	}
+
+	// Subscribe before reading, so no event is missed between reading and waiting
+	notifications, unsubscribe := h.broker.Subscribe()
+	defer unsubscribe()
+
+	// Stream lives longer than server WriteTimeout, only stuck writes are cut
+	controller := http.NewResponseController(w)
+	extendDeadline := func() {
+		if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			slog.WarnContext(r.Context(), "Failed to set stream write deadline", "error", err)
+		}
+	}
+	extendDeadline()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	slog.InfoContext(r.Context(), "Event stream opened", "team_name", filter.TeamName, "user_id", filter.UserID, "after", last)
+	defer slog.InfoContext(r.Context(), "Event stream closed", "team_name", filter.TeamName, "user_id", filter.UserID)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		// Send everything published after last sent event
+		extendDeadline()
+		for {
+			outbox, err := h.service.GetEvents(r.Context(), last, filter, eventsBatchSize)
+			if err != "" {
+				return
+			}
+			for _, event := range outbox {
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.StreamSeq, event.EventType, event.Payload); err != nil {
+					return
+				}
+				last = event.StreamSeq
+			}
+			flusher.Flush()
+
+			if len(outbox) < eventsBatchSize {
+				break
+			}
+		}
+
+		// Wait for new events
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-notifications:
+			if !ok {
+				// Server is shutting down
+				return
+			}
+		case <-heartbeat.C:
+			extendDeadline()
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"PR_reviewer_assign_service/internal/stream"
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type streamTest struct {
+	t       *testing.T
+	ctx     context.Context
+	storage *storagetest.Memory
+	service *service.Service
+	broker  *stream.Broker
+	server  *httptest.Server
+}
+
+// Team backend of u1..u4, events are published only by publish
+func newStreamTest(t *testing.T) *streamTest {
+	t.Helper()
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	store := storagetest.NewMemory(clk)
+	svc := service.NewService(store, nil)
+	svc.SetClock(clk)
+	broker := stream.NewBroker()
+	server := httptest.NewServer(http.HandlerFunc(NewEventHandler(svc, broker).Stream))
+	t.Cleanup(server.Close)
+	t.Cleanup(broker.Close)
+
+	tt := &streamTest{t: t, ctx: context.Background(), storage: store, service: svc, broker: broker, server: server}
+	team := models.Team{TeamName: "backend"}
+	for _, id := range []string{"u1", "u2", "u3", "u4"} {
+		team.Members = append(team.Members, models.TeamMember{UserID: id, Username: id, IsActive: true})
+	}
+	if err := svc.CreateTeam(tt.ctx, &team); err != "" {
+		t.Fatalf("create team: %s", err)
+	}
+	return tt
+}
+
+// Create pull request, its events are saved to outbox
+func (tt *streamTest) createPR(id string) {
+	tt.t.Helper()
+	if _, err := tt.service.CreatePullRequest(tt.ctx, models.PullRequestCreateQuery{PullRequestID: id, PullRequestName: id, AuthorID: "u1"}); err != "" {
+		tt.t.Fatalf("create %s: %s", id, err)
+	}
+}
+
+// Publish outbox events like outbox dispatcher does and wake streams
+func (tt *streamTest) publish() {
+	tt.t.Helper()
+	outbox, err := tt.storage.ClaimUnpublishedEvents(tt.ctx, 100, time.Minute)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	for _, event := range outbox {
+		if err := tt.storage.MarkEventPublished(tt.ctx, event.Seq); err != nil {
+			tt.t.Fatal(err)
+		}
+	}
+	tt.broker.Notify()
+}
+
+type sseEvent struct {
+	id, name, data string
+}
+
+// Open stream, returned channel gets events until test ends
+func (tt *streamTest) open(query, lastEventID string) <-chan sseEvent {
+	tt.t.Helper()
+	ctx, cancel := context.WithCancel(tt.ctx)
+	tt.t.Cleanup(cancel)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, tt.server.URL+"/events/stream"+query, nil)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	if lastEventID != "" {
+		r.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		tt.t.Fatalf("stream: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan sseEvent, 100)
+	go func() {
+		defer resp.Body.Close()
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.id != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// Expect events with given ids in order
+func (tt *streamTest) expect(events <-chan sseEvent, ids ...string) []sseEvent {
+	tt.t.Helper()
+	var got []sseEvent
+	for _, id := range ids {
+		select {
+		case event := <-events:
+			if event.id != id {
+				tt.t.Fatalf("event id %s, want %s", event.id, id)
+			}
+			got = append(got, event)
+		case <-time.After(2 * time.Second):
+			tt.t.Fatalf("no event %s", id)
+		}
+	}
+	return got
+}
+
+func (tt *streamTest) expectNone(events <-chan sseEvent) {
+	tt.t.Helper()
+	select {
+	case event := <-events:
+		tt.t.Fatalf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventStreamResumesAfterLastEventID(t *testing.T) {
+	tt := newStreamTest(t)
+	// pr.created and two reviewer.assigned of each pull request: 1..6
+	tt.createPR("pr-1")
+	tt.createPR("pr-2")
+	tt.publish()
+
+	// Events after 3 are replayed in order, then new ones are pushed
+	events := tt.open("", "3")
+	got := tt.expect(events, "4", "5", "6")
+	if got[0].name != "pr.created" || !strings.Contains(got[0].data, `"pr-2"`) {
+		t.Errorf("event 4 %+v, want pr.created of pr-2", got[0])
+	}
+	tt.expectNone(events)
+
+	tt.createPR("pr-3")
+	tt.publish()
+	tt.expect(events, "7", "8", "9")
+
+	// Reconnect from the last received event gets nothing twice
+	resumed := tt.open("?last_event_id=9", "")
+	tt.expectNone(resumed)
+	tt.createPR("pr-4")
+	tt.publish()
+	tt.expect(resumed, "10", "11", "12")
+	tt.expect(events, "10", "11", "12")
+}
+
+func TestEventStreamStartsAtLatestWithoutLastEventID(t *testing.T) {
+	tt := newStreamTest(t)
+	tt.createPR("pr-1")
+	tt.publish()
+
+	events := tt.open("", "")
+	tt.expectNone(events)
+	tt.createPR("pr-2")
+	tt.publish()
+	tt.expect(events, "4", "5", "6")
+}
+
+func TestEventStreamFilters(t *testing.T) {
+	tt := newStreamTest(t)
+	tt.createPR("pr-1")
+	tt.publish()
+
+	// Events of other team aren't sent
+	tt.expectNone(tt.open("?team_name=frontend", "0"))
+	tt.expect(tt.open("?team_name=backend", "0"), "1", "2", "3")
+
+	// Reviewer gets pr.created and own assignment
+	pr, _ := tt.storage.GetPR(tt.ctx, "pr-1")
+	got := tt.expect(tt.open("?user_id="+pr.AssignedReviewers[0], "0"), "1")
+	if got[0].name != "pr.created" {
+		t.Errorf("event 1 %+v, want pr.created", got[0])
+	}
+}
+
+func TestEventStreamInvalidLastEventID(t *testing.T) {
+	tt := newStreamTest(t)
+	for _, id := range []string{"abc", "-1"} {
+		r, _ := http.NewRequest(http.MethodGet, tt.server.URL+"/events/stream", nil)
+		r.Header.Set("Last-Event-ID", id)
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Last-Event-ID %s: status %d, want 400", id, resp.StatusCode)
+		}
+	}
+}
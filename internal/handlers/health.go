@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check of dependency, nil error means healthy
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Handler for liveness and readiness probes
+type Health struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// Every readiness check gets timeout
+func NewHealth(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// Add readiness check, called before serving requests
+func (h *Health) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Readiness fails from now on, so traffic is moved away before connections are drained
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+/* /livez - Query, process is able to serve requests */
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: StatusOK})
+}
+
+/* /readyz - Query, dependencies are available, 503 if any check fails */
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: StatusOK, Checks: make(map[string]CheckResult, len(h.checks))}
+	if h.shuttingDown.Load() {
+		response.Status = StatusFail
+		response.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: "server is shutting down"}
+		writeJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	// Checks run in parallel, each with own timeout
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			result := h.run(r.Context(), c.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[c.name] = result
+			if result.Status != StatusOK {
+				response.Status = StatusFail
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if response.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+func (h *Health) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Storage that doesn't answer while down, and has a schema of another version
+type downStorage struct {
+	*storagetest.Memory
+	down          bool
+	schemaVersion int
+}
+
+func (s *downStorage) Ping(ctx context.Context) error {
+	if s.down {
+		return goerrors.New("connection refused")
+	}
+	return nil
+}
+
+func (s *downStorage) GetSchemaVersion(ctx context.Context) (int, error) {
+	if s.down {
+		return 0, goerrors.New("connection refused")
+	}
+	return s.schemaVersion, nil
+}
+
+func ready(t *testing.T, health *Health) (int, HealthResponse) {
+	t.Helper()
+	w := serve(http.HandlerFunc(health.Ready), http.MethodGet, "/readyz")
+	var response HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, response
+}
+
+func TestReadyFailsWhenDependencyIsDown(t *testing.T) {
+	memory := storagetest.NewMemory(clock.System{})
+	schemaVersion, _ := memory.GetSchemaVersion(context.Background())
+	store := &downStorage{Memory: memory, schemaVersion: schemaVersion}
+	svc := service.NewService(store, nil)
+	health := NewHealth(time.Second)
+	health.Add("storage", svc.PingStorage)
+	health.Add("migrations", svc.CheckSchemaVersion)
+
+	if status, response := ready(t, health); status != http.StatusOK || response.Status != StatusOK || len(response.Checks) != 2 {
+		t.Fatalf("healthy: %d %+v", status, response)
+	}
+
+	store.down = true
+	status, response := ready(t, health)
+	if status != http.StatusServiceUnavailable || response.Status != StatusFail {
+		t.Errorf("storage is down: %d %s, want 503 fail", status, response.Status)
+	}
+	for _, name := range []string{"storage", "migrations"} {
+		if check := response.Checks[name]; check.Status != StatusFail || check.Error != "connection refused" {
+			t.Errorf("check %s: %+v", name, check)
+		}
+	}
+
+	// Old schema isn't ready either
+	store.down, store.schemaVersion = false, schemaVersion-1
+	status, response = ready(t, health)
+	if status != http.StatusServiceUnavailable || response.Checks["storage"].Status != StatusOK || response.Checks["migrations"].Status != StatusFail {
+		t.Errorf("old schema: %d %+v", status, response)
+	}
+
+	// Liveness doesn't depend on dependencies
+	if w := serve(http.HandlerFunc(health.Live), http.MethodGet, "/livez"); w.Code != http.StatusOK {
+		t.Errorf("livez: %d", w.Code)
+	}
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	health := NewHealth(10 * time.Millisecond)
+	health.Add("hanging", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	health.Add("workers", func(ctx context.Context) error { return nil })
+
+	status, response := ready(t, health)
+	if status != http.StatusServiceUnavailable || response.Checks["hanging"].Error != context.DeadlineExceeded.Error() || response.Checks["workers"].Status != StatusOK {
+		t.Errorf("hanging check: %d %+v", status, response)
+	}
+}
+
+func TestReadyFailsOnShutdown(t *testing.T) {
+	health := NewHealth(time.Second)
+	health.Add("workers", func(ctx context.Context) error { return nil })
+	health.Shutdown()
+
+	status, response := ready(t, health)
+	if status != http.StatusServiceUnavailable || response.Checks["shutdown"].Status != StatusFail {
+		t.Errorf("shutting down: %d %+v", status, response)
+	}
+}
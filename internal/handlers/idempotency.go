@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/service"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// Set on replayed responses
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Middleware that replays saved responses of retried requests with the same Idempotency-Key
+type Idempotency struct {
+	service *service.Service
+	wg      sync.WaitGroup
+	running atomic.Bool
+}
+
+func NewIdempotency(service *service.Service) *Idempotency {
+	return &Idempotency{service: service}
+}
+
+// Remove expired responses in background, stops when ctx is done
+func (i *Idempotency) Start(ctx context.Context) {
+	i.wg.Add(1)
+	i.running.Store(true)
+	go func() {
+		defer i.wg.Done()
+		defer i.running.Store(false)
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if deleted, err := i.service.PurgeIdempotencyRecords(ctx); err != "" {
+					slog.ErrorContext(ctx, "Failed to remove expired idempotency keys", "error_code", err)
+				} else if deleted > 0 {
+					slog.InfoContext(ctx, "Removed expired idempotency keys", "count", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// Wait until background work stops after ctx is done
+func (i *Idempotency) Wait() {
+	i.wg.Wait()
+}
+
+// Check that background work is running
+func (i *Idempotency) Running() bool {
+	return i.running.Load()
+}
+
+// Wrap handler, requests without Idempotency-Key are handled as usual
+func (i *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > 255 {
+			writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Idempotency key must be at most 255 characters long")
+			return
+		}
+
+		// Same key with another body or path is rejected
+		body, err := readBody(r)
+		if err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(r)
+		saved, code := i.service.BeginIdempotentRequest(r.Context(), scope, key, requestHash(r.Method, r.URL.Path, body))
+		if code != "" {
+			writeError(w, code)
+			return
+		}
+
+		// Retry of finished request
+		if saved != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(HeaderIdempotentReplayed, "true")
+			w.WriteHeader(saved.StatusCode)
+			w.Write(saved.Response)
+			return
+		}
+
+		// First request, it must finish before its key is unlocked by timeout
+		handlerCtx, cancel := context.WithTimeout(r.Context(), service.IdempotentRequestTimeout)
+		defer cancel()
+		recorder := &responseRecorder{ResponseWriter: w}
+		next(recorder, r.WithContext(handlerCtx))
+
+		// Save response even if client has gone, nothing written means 200 like in net/http
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if recorder.status >= http.StatusInternalServerError {
+			// Failure may be temporary, let client retry
+			i.service.AbortIdempotentRequest(ctx, scope, key)
+			return
+		}
+		if code := i.service.CompleteIdempotentRequest(ctx, scope, key, recorder.status, recorder.body.Bytes()); code != "" {
+			slog.ErrorContext(ctx, "Failed to save response for idempotency key", "idempotency_key", key, "error_code", code)
+		}
+	}
+}
+
+// Hash of request to tell retries from other requests with the same key
+func requestHash(method, path string, body []byte) string {
+	hash := sha256.Sum256([]byte(method + " " + path + "\n" + string(body)))
+	return hex.EncodeToString(hash[:])
+}
+
+// Keys of different callers don't clash
+func idempotencyScope(r *http.Request) string {
+	principal := auth.FromContext(r.Context())
+	if principal == nil {
+		return "anonymous"
+	}
+	return principalKey(principal)
+}
+
+// Writes response to client and keeps a copy
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type idempotencyTest struct {
+	t       *testing.T
+	clock   *clock.Manual
+	service *service.Service
+	// Calls of wrapped handler
+	calls int
+	// Response of wrapped handler, nothing is written if status is 0
+	status int
+	body   string
+	// Wrapped handler reports it's entered and waits for release if they are set
+	entered chan struct{}
+	release chan struct{}
+	handler http.HandlerFunc
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	svc := service.NewService(storagetest.NewMemory(clk), nil)
+	svc.SetClock(clk)
+	svc.SetIdempotencyTTL(time.Hour)
+
+	tt := &idempotencyTest{t: t, clock: clk, service: svc, status: http.StatusCreated, body: `{"team":{}}`}
+	tt.handler = NewIdempotency(svc).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		tt.calls++
+		if tt.release != nil {
+			tt.entered <- struct{}{}
+			<-tt.release
+		}
+		if tt.status != 0 {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}
+	})
+	return tt
+}
+
+// Request of caller with API key id, key is not sent if empty
+func (tt *idempotencyTest) do(keyID int64, path, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		r.Header.Set(HeaderIdempotencyKey, key)
+	}
+	r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: "ci", KeyID: keyID}))
+	w := httptest.NewRecorder()
+	tt.handler(w, r)
+	return w
+}
+
+func (tt *idempotencyTest) expect(w *httptest.ResponseRecorder, status int, body string, calls int) {
+	tt.t.Helper()
+	if w.Code != status || w.Body.String() != body {
+		tt.t.Errorf("response %d %s, want %d %s", w.Code, w.Body, status, body)
+	}
+	if tt.calls != calls {
+		tt.t.Errorf("handler called %d times, want %d", tt.calls, calls)
+	}
+}
+
+func (tt *idempotencyTest) expectError(w *httptest.ResponseRecorder, status int, code string) {
+	tt.t.Helper()
+	var response ErrorRespone
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		tt.t.Fatal(err)
+	}
+	if w.Code != status || string(response.Error.Code) != code {
+		tt.t.Errorf("error %d %s, want %d %s", w.Code, response.Error.Code, status, code)
+	}
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	tt := newIdempotencyTest(t)
+
+	first := tt.do(1, "/team/add", "key-1", `{"team_name":"backend"}`)
+	tt.expect(first, http.StatusCreated, `{"team":{}}`, 1)
+	if first.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Error("first response is marked replayed")
+	}
+
+	// Handler would answer differently now, retry still gets the first response
+	tt.status, tt.body = http.StatusBadRequest, `{}`
+	replay := tt.do(1, "/team/add", "key-1", `{"team_name":"backend"}`)
+	tt.expect(replay, http.StatusCreated, `{"team":{}}`, 1)
+	if replay.Header().Get(HeaderIdempotentReplayed) != "true" || replay.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replay headers %v", replay.Header())
+	}
+
+	// Key of another caller doesn't clash
+	tt.expect(tt.do(2, "/team/add", "key-1", `{"team_name":"backend"}`), http.StatusBadRequest, `{}`, 2)
+	// Requests without key aren't saved
+	tt.expect(tt.do(1, "/team/add", "", `{"team_name":"backend"}`), http.StatusBadRequest, `{}`, 3)
+	tt.expect(tt.do(1, "/team/add", "", `{"team_name":"backend"}`), http.StatusBadRequest, `{}`, 4)
+
+	// Expired key is used again
+	tt.clock.Advance(time.Hour)
+	tt.expect(tt.do(1, "/team/add", "key-1", `{"team_name":"backend"}`), http.StatusBadRequest, `{}`, 5)
+}
+
+func TestIdempotencyKeyReusedWithAnotherRequest(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.do(1, "/team/add", "key-1", `{"team_name":"backend"}`)
+
+	tt.expectError(tt.do(1, "/team/add", "key-1", `{"team_name":"frontend"}`), http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED")
+	tt.expectError(tt.do(1, "/pullRequest/create", "key-1", `{"team_name":"backend"}`), http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED")
+	if tt.calls != 1 {
+		t.Errorf("handler called %d times, want 1", tt.calls)
+	}
+}
+
+func TestIdempotencyRequestInProgress(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.entered, tt.release = make(chan struct{}), make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- tt.do(1, "/team/add", "key-1", `{}`) }()
+	<-tt.entered
+
+	// Retry while first request is handled
+	tt.expectError(tt.do(1, "/team/add", "key-1", `{}`), http.StatusConflict, "REQUEST_IN_PROGRESS")
+
+	close(tt.release)
+	first := <-done
+	tt.release = nil
+	tt.expect(first, http.StatusCreated, `{"team":{}}`, 1)
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusCreated, `{"team":{}}`, 1)
+}
+
+func TestIdempotencyTakesOverLostRequest(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	// Instance handling the request has gone before saving response
+	if _, err := tt.service.BeginIdempotentRequest(context.Background(), "key:1", "key-1", requestHash(http.MethodPost, "/team/add", []byte(`{}`))); err != "" {
+		t.Fatal(err)
+	}
+	tt.expectError(tt.do(1, "/team/add", "key-1", `{}`), http.StatusConflict, "REQUEST_IN_PROGRESS")
+
+	tt.clock.Advance(2 * service.IdempotentRequestTimeout)
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusCreated, `{"team":{}}`, 1)
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.status, tt.body = http.StatusInternalServerError, `{"error":{}}`
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusInternalServerError, `{"error":{}}`, 1)
+
+	// Retry is handled again and its response is saved
+	tt.status, tt.body = http.StatusCreated, `{"team":{}}`
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusCreated, `{"team":{}}`, 2)
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusCreated, `{"team":{}}`, 2)
+
+	// Client errors are final
+	tt.status, tt.body = http.StatusBadRequest, `{"error":{}}`
+	tt.expect(tt.do(1, "/team/add", "key-2", `{}`), http.StatusBadRequest, `{"error":{}}`, 3)
+	tt.status = http.StatusCreated
+	tt.expect(tt.do(1, "/team/add", "key-2", `{}`), http.StatusBadRequest, `{"error":{}}`, 3)
+}
+
+func TestIdempotencyEmptyResponseIsSavedAs200(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.status = 0
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusOK, ``, 1)
+	tt.expect(tt.do(1, "/team/add", "key-1", `{}`), http.StatusOK, ``, 1)
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.expectError(tt.do(1, "/team/add", strings.Repeat("k", 256), `{}`), http.StatusBadRequest, "INVALID_INPUT")
+	if tt.calls != 0 {
+		t.Errorf("handler called %d times", tt.calls)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/logging"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// Incoming request ids are accepted only if they are safe to log
+var requestIDRegExp = regexp.MustCompile(`^[a-zA-Z0-9\-_\.]{1,128}$`)
+
+// Middleware that assigns request id and logs every request with its result
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Propagate id of the caller or assign new one
+		id := r.Header.Get(HeaderRequestID)
+		if !requestIDRegExp.MatchString(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []any{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if recorder.errorCode != "" {
+			attrs = append(attrs, slog.String("error_code", string(recorder.errorCode)))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "Request handled", attrs...)
+	})
+}
+
+// Remembers status, error code and route of response
+type statusRecorder struct {
+	http.ResponseWriter
+	status    int
+	errorCode errors.ErrorCode
+	route     string
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Needed by event stream
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Lets http.ResponseController reach the connection
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Save error code in recorders, even through other wrappers
+func recordErrorCode(w http.ResponseWriter, code errors.ErrorCode) {
+	eachRecorder(w, func(r *statusRecorder) { r.errorCode = code })
+}
+
+// Save path pattern of matched route in recorders
+func recordRoute(w http.ResponseWriter, pattern string) {
+	eachRecorder(w, func(r *statusRecorder) { r.route = pattern })
+}
+
+func eachRecorder(w http.ResponseWriter, f func(r *statusRecorder)) {
+	for {
+		if r, ok := w.(*statusRecorder); ok {
+			f(r)
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = unwrapper.Unwrap()
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Request counters and latency histograms per route and error code
+type HTTPMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+func NewHTTPMetrics(registry *metrics.Registry) *HTTPMetrics {
+	m := &HTTPMetrics{
+		requests: metrics.NewCounterVec("http_requests_total", "Total number of HTTP requests.",
+			"method", "route", "status", "error_code"),
+		duration: metrics.NewHistogramVec("http_request_duration_seconds", "Latency of HTTP requests.",
+			metrics.DefaultBuckets, "method", "route", "error_code"),
+	}
+	registry.Register(m.requests, m.duration)
+	return m
+}
+
+// Middleware that measures requests, route is the path pattern, "unmatched" for unknown paths
+func (m *HTTPMetrics) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := recorder.route
+		if route == "" {
+			route = "unmatched"
+		}
+		code := string(recorder.errorCode)
+
+		m.requests.Inc(r.Method, route, strconv.Itoa(status), code)
+		m.duration.Observe(time.Since(start).Seconds(), r.Method, route, code)
+	})
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/ratelimit"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"PR_reviewer_assign_service/internal/stream"
+	"PR_reviewer_assign_service/internal/webhooks"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	adminKey  = "admin-key"
+	readerKey = "reader-key"
+)
+
+// Server with the real routes of the API on memory storage, every response
+// is checked against openapi.json
+type specTest struct {
+	t       *testing.T
+	spec    map[string]any
+	server  *httptest.Server
+	clock   *clock.Manual
+	storage *storagetest.Memory
+	// Operations called, "post /team/add"
+	called map[string]bool
+}
+
+func newSpecTest(t *testing.T) *specTest {
+	t.Helper()
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	store := storagetest.NewMemory(clk)
+	svc := service.NewService(store, webhooks.NewDispatcher(store, webhooks.DefaultConfig()))
+	svc.SetClock(clk)
+	for key, scope := range map[string]auth.Scope{adminKey: auth.ScopeAdmin, readerKey: auth.ScopeRead} {
+		if err := svc.EnsureAPIKey(context.Background(), key, key, []auth.Scope{scope}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	access := NewAuth(svc)
+	limits := NewRateLimits(clk)
+	limits.Set("users", ratelimit.Rule{Rate: 1, Burst: 3})
+	router := NewRouter()
+	api := &API{
+		Users:       NewUserHandler(svc),
+		Teams:       NewTeamHandler(svc),
+		PRs:         NewPRHandler(svc),
+		Webhooks:    NewWebhookHandler(svc),
+		APIKeys:     NewAPIKeyHandler(svc),
+		Events:      NewEventHandler(svc, stream.NewBroker()),
+		Idempotency: NewIdempotency(svc),
+	}
+	api.Register(router, func(group string, scope auth.Scope) func(http.HandlerFunc) http.HandlerFunc {
+		return func(h http.HandlerFunc) http.HandlerFunc {
+			return limits.Gate(group, access.Identify(limits.Limit(group, access.Require(scope, h))))
+		}
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return &specTest{t: t, spec: spec, server: server, clock: clk, storage: store, called: make(map[string]bool)}
+}
+
+// Send request with JSON body, or without body if it's nil, check response against spec
+func (tt *specTest) call(method, path, key string, body any, headers map[string]string, want int) map[string]any {
+	tt.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			tt.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	r, err := http.NewRequest(method, tt.server.URL+path, reader)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+
+	name := method + " " + path
+	if resp.StatusCode != want {
+		tt.t.Fatalf("%s: status %d, want %d: %s", name, resp.StatusCode, want, data)
+	}
+	template, operation := tt.operation(method, r.URL.Path)
+	if operation == nil {
+		tt.t.Fatalf("%s: operation isn't documented", name)
+	}
+	tt.called[strings.ToLower(method)+" "+template] = true
+
+	response := object(object(operation["responses"])[fmt.Sprint(resp.StatusCode)])
+	if response == nil {
+		tt.t.Fatalf("%s: status %d isn't documented", name, resp.StatusCode)
+	}
+	content := object(object(response["content"])["application/json"])
+	if content == nil {
+		if len(data) > 0 {
+			tt.t.Errorf("%s: status %d has no documented body, got %s", name, resp.StatusCode, data)
+		}
+		return nil
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		tt.t.Errorf("%s: content type %q", name, ct)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		tt.t.Fatalf("%s: invalid JSON %s: %v", name, data, err)
+	}
+	for _, problem := range tt.validate(object(content["schema"]), value, "body") {
+		tt.t.Errorf("%s %d: %s", name, resp.StatusCode, problem)
+	}
+	result, _ := value.(map[string]any)
+	return result
+}
+
+// Call read endpoint with query parameters by GET and with the same JSON body by POST
+func (tt *specTest) read(path string, params map[string]any, want int) map[string]any {
+	tt.t.Helper()
+	query := url.Values{}
+	for name, value := range params {
+		query.Set(name, fmt.Sprint(value))
+	}
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	tt.call(http.MethodGet, target, adminKey, nil, nil, want)
+	return tt.call(http.MethodPost, path, adminKey, params, nil, want)
+}
+
+func (tt *specTest) post(path string, body any, want int) map[string]any {
+	tt.t.Helper()
+	return tt.call(http.MethodPost, path, adminKey, body, nil, want)
+}
+
+// Operation of spec path that matches request path, exact paths before templates
+func (tt *specTest) operation(method, path string) (string, map[string]any) {
+	paths := object(tt.spec["paths"])
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return strings.Count(templates[i], "{") < strings.Count(templates[j], "{")
+	})
+
+	segments := splitPath(path)
+	for _, template := range templates {
+		want := splitPath(template)
+		if len(want) != len(segments) {
+			continue
+		}
+		match := true
+		for i := range want {
+			if !strings.HasPrefix(want[i], "{") && want[i] != segments[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return template, object(object(paths[template])[strings.ToLower(method)])
+		}
+	}
+	return "", nil
+}
+
+// Problems of value that doesn't match schema. Objects must not have undocumented
+// properties unless schema allows additional ones, so spec can't fall behind responses
+func (tt *specTest) validate(schema map[string]any, value any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved := object(object(object(tt.spec["components"])["schemas"])[name])
+		if resolved == nil {
+			return []string{at + ": unknown schema " + ref}
+		}
+		return tt.validate(resolved, value, at)
+	}
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []string{at + ": null isn't allowed"}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v isn't one of %v", at, value, enum)}
+	}
+
+	var problems []string
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't object", at, value)}
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := fields[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: required %s is missing", at, name))
+			}
+		}
+		properties := object(schema["properties"])
+		for name, field := range fields {
+			if property := object(properties[name]); property != nil {
+				problems = append(problems, tt.validate(property, field, at+"."+name)...)
+			} else if additional := object(schema["additionalProperties"]); additional != nil {
+				problems = append(problems, tt.validate(additional, field, at+"."+name)...)
+			} else if schema["additionalProperties"] != true {
+				problems = append(problems, fmt.Sprintf("%s: %s isn't documented", at, name))
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't array", at, value)}
+		}
+		for i, item := range items {
+			problems = append(problems, tt.validate(object(schema["items"]), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't string", at, value)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q isn't date-time", at, s))
+			}
+		}
+		if n, ok := schema["minLength"].(float64); ok && float64(len(s)) < n {
+			problems = append(problems, fmt.Sprintf("%s: %q is shorter than %v", at, s, n))
+		}
+		if n, ok := schema["maxLength"].(float64); ok && float64(len(s)) > n {
+			problems = append(problems, fmt.Sprintf("%s: %q is longer than %v", at, s, n))
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || (typ == "integer" && n != math.Trunc(n)) {
+			return []string{fmt.Sprintf("%s: %v isn't %s", at, value, typ)}
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is less than %v", at, n, min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			problems = append(problems, fmt.Sprintf("%s: %v is greater than %v", at, n, max))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: %v isn't boolean", at, value)}
+		}
+	}
+	return problems
+}
+
+func object(value any) map[string]any {
+	m, _ := value.(map[string]any)
+	return m
+}
+
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	tt := newSpecTest(t)
+	backend := models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true, IsTeamLead: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Carol", IsActive: true},
+		{UserID: "u4", Username: "Dave", IsActive: true},
+	}}
+	frontend := models.Team{TeamName: "frontend", Members: []models.TeamMember{
+		{UserID: "u5", Username: "Eve", IsActive: true},
+	}}
+
+	// Credentials and rate limits
+	tt.call(http.MethodPost, "/team/add", "", backend, nil, http.StatusUnauthorized)
+	tt.call(http.MethodPost, "/team/add", "wrong-key", backend, nil, http.StatusUnauthorized)
+	tt.call(http.MethodPost, "/team/add", readerKey, backend, nil, http.StatusForbidden)
+
+	// Teams, retry with the same Idempotency-Key gets the first response
+	retry := map[string]string{HeaderIdempotencyKey: "create-backend"}
+	tt.call(http.MethodPost, "/team/add", adminKey, backend, retry, http.StatusCreated)
+	tt.call(http.MethodPost, "/team/add", adminKey, backend, retry, http.StatusCreated)
+	tt.call(http.MethodPost, "/team/add", adminKey, frontend, retry, http.StatusUnprocessableEntity)
+	tt.post("/team/add", backend, http.StatusBadRequest)
+	tt.post("/team/add", models.Team{}, http.StatusBadRequest)
+	tt.post("/team/add", frontend, http.StatusCreated)
+	tt.read("/team/get", map[string]any{"team_name": "backend"}, http.StatusOK)
+	tt.read("/team/get", map[string]any{"team_name": "mobile"}, http.StatusNotFound)
+	tt.read("/team/get", map[string]any{"team_name": ""}, http.StatusBadRequest)
+	tt.call(http.MethodGet, "/teams/backend", adminKey, nil, nil, http.StatusOK)
+	tt.call(http.MethodGet, "/teams/mobile", adminKey, nil, nil, http.StatusNotFound)
+	tt.post("/team/addMember", models.TeamMemberAddQuery{TeamName: "backend", UserID: "u6", Username: "Frank", IsActive: true}, http.StatusOK)
+	tt.post("/team/addMember", models.TeamMemberAddQuery{TeamName: "mobile", UserID: "u7", Username: "Gina", IsActive: true}, http.StatusNotFound)
+	tt.post("/team/addMember", map[string]any{"team_name": "backend", "user_id": "u7"}, http.StatusBadRequest)
+	tt.post("/team/removeMember", models.TeamMemberRemoveQuery{TeamName: "backend", UserID: "u6"}, http.StatusOK)
+	tt.post("/team/removeMember", models.TeamMemberRemoveQuery{TeamName: "backend", UserID: "u5"}, http.StatusNotFound)
+	tt.post("/team/removeMember", models.TeamMemberRemoveQuery{TeamName: "backend"}, http.StatusBadRequest)
+
+	// Users, group "users" allows 3 requests at once
+	tt.post("/users/setIsActive", models.UserActiveQuery{UserID: "u5", IsActive: false}, http.StatusOK)
+	tt.post("/users/setIsActive", models.UserActiveQuery{UserID: "u9", IsActive: false}, http.StatusNotFound)
+	tt.post("/users/setIsActive", map[string]any{"user_id": "u5"}, http.StatusBadRequest)
+	tt.post("/users/setIsActive", models.UserActiveQuery{UserID: "u5", IsActive: true}, http.StatusTooManyRequests)
+	tt.clock.Advance(time.Second)
+	tt.post("/users/setIsActive", models.UserActiveQuery{UserID: "u5", IsActive: true}, http.StatusOK)
+
+	// Pull requests
+	created := tt.post("/pullRequest/create", models.PullRequestCreateQuery{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}, http.StatusCreated)
+	tt.post("/pullRequest/create", models.PullRequestCreateQuery{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}, http.StatusConflict)
+	tt.post("/pullRequest/create", models.PullRequestCreateQuery{PullRequestID: "pr-2", PullRequestName: "Fix", AuthorID: "u9"}, http.StatusNotFound)
+	tt.post("/pullRequest/create", models.PullRequestCreateQuery{PullRequestID: "pr-2"}, http.StatusBadRequest)
+	retry = map[string]string{HeaderIdempotencyKey: "create-pr-2"}
+	tt.call(http.MethodPost, "/pullRequest/create", adminKey, models.PullRequestCreateQuery{PullRequestID: "pr-2", PullRequestName: "Fix", AuthorID: "u2"}, retry, http.StatusCreated)
+	tt.call(http.MethodPost, "/pullRequest/create", adminKey, models.PullRequestCreateQuery{PullRequestID: "pr-2", PullRequestName: "Fix", AuthorID: "u2"}, retry, http.StatusCreated)
+
+	reviewer := created["assigned_reviewers"].([]any)[0].(string)
+	tt.post("/pullRequest/reassign", models.PullRequestReassignQuery{PullRequestID: "pr-1", OldUserID: reviewer}, http.StatusOK)
+	tt.post("/pullRequest/reassign", models.PullRequestReassignQuery{PullRequestID: "pr-1", OldUserID: "u1"}, http.StatusConflict)
+	tt.post("/pullRequest/reassign", models.PullRequestReassignQuery{PullRequestID: "pr-9", OldUserID: "u2"}, http.StatusNotFound)
+	tt.post("/pullRequest/reassign", models.PullRequestReassignQuery{PullRequestID: "pr-1"}, http.StatusBadRequest)
+	tt.read("/pullRequest/history", map[string]any{"pull_request_id": "pr-1"}, http.StatusOK)
+	tt.read("/pullRequest/history", map[string]any{"pull_request_id": "pr-9"}, http.StatusNotFound)
+	tt.read("/pullRequest/history", map[string]any{"pull_request_id": ""}, http.StatusBadRequest)
+
+	// Review SLA, pr-1 and pr-2 are overdue after a day
+	tt.read("/team/getSettings", map[string]any{"team_name": "backend"}, http.StatusOK)
+	tt.read("/team/getSettings", map[string]any{"team_name": "mobile"}, http.StatusNotFound)
+	tt.read("/team/getSettings", map[string]any{"team_name": ""}, http.StatusBadRequest)
+	tt.post("/team/setSettings", models.TeamSettings{TeamName: "backend", ReviewSLAHours: 12}, http.StatusOK)
+	tt.post("/team/setSettings", models.TeamSettings{TeamName: "mobile", ReviewSLAHours: 12}, http.StatusNotFound)
+	tt.post("/team/setSettings", models.TeamSettings{TeamName: "backend", ReviewSLAHours: -1}, http.StatusBadRequest)
+	tt.clock.Advance(24 * time.Hour)
+	tt.read("/pullRequest/overdue", map[string]any{"team_name": "backend"}, http.StatusOK)
+	tt.read("/pullRequest/overdue", map[string]any{"team_name": strings.Repeat("t", 300)}, http.StatusBadRequest)
+
+	tt.post("/pullRequest/merge", models.PullRequestMergeQuery{PullRequestID: "pr-1"}, http.StatusOK)
+	tt.post("/pullRequest/merge", models.PullRequestMergeQuery{PullRequestID: "pr-9"}, http.StatusNotFound)
+	tt.post("/pullRequest/merge", models.PullRequestMergeQuery{}, http.StatusBadRequest)
+	tt.post("/pull-requests/pr-2/merge", nil, http.StatusOK)
+	tt.post("/pull-requests/pr-9/merge", nil, http.StatusNotFound)
+	tt.post("/pull-requests/"+strings.Repeat("p", 300)+"/merge", nil, http.StatusBadRequest)
+
+	// Reviews and statistics
+	tt.read("/users/getReview", map[string]any{"user_id": reviewer}, http.StatusOK)
+	tt.read("/users/getReview", map[string]any{"user_id": "u9"}, http.StatusNotFound)
+	tt.read("/users/getReview", map[string]any{"user_id": ""}, http.StatusBadRequest)
+	tt.call(http.MethodGet, "/users/"+reviewer+"/reviews?status=MERGED", adminKey, nil, nil, http.StatusOK)
+	tt.call(http.MethodGet, "/users/u9/reviews", adminKey, nil, nil, http.StatusNotFound)
+	tt.call(http.MethodGet, "/users/u1/reviews?limit=1000", adminKey, nil, nil, http.StatusBadRequest)
+	tt.read("/users/statistics", map[string]any{}, http.StatusOK)
+	tt.read("/users/get", map[string]any{"user_id": "u2"}, http.StatusOK)
+	tt.read("/users/get", map[string]any{"user_id": "u9"}, http.StatusNotFound)
+	tt.read("/users/get", map[string]any{"user_id": ""}, http.StatusBadRequest)
+	tt.read("/team/statistics", map[string]any{"team_name": "backend"}, http.StatusOK)
+	tt.read("/team/statistics", map[string]any{"team_name": "mobile"}, http.StatusNotFound)
+	tt.read("/team/statistics", map[string]any{"team_name": ""}, http.StatusBadRequest)
+	tt.read("/team/count", map[string]any{}, http.StatusOK)
+	tt.read("/pullRequest/statistics", map[string]any{}, http.StatusOK)
+
+	// Paginated lists, first pages have next cursor
+	for _, path := range []string{"/team/list", "/users/list", "/pullRequest/list", "/pullRequest/search"} {
+		page := tt.read(path, map[string]any{"limit": 1}, http.StatusOK)
+		if page["next_cursor"] == nil {
+			t.Errorf("%s: no next cursor", path)
+		}
+		tt.read(path, map[string]any{"limit": 1, "cursor": page["next_cursor"]}, http.StatusOK)
+		tt.read(path, map[string]any{"limit": 1000}, http.StatusBadRequest)
+	}
+	for _, path := range []string{"/teams", "/users", "/pull-requests", "/pull-requests/search"} {
+		tt.call(http.MethodGet, path+"?limit=1", adminKey, nil, nil, http.StatusOK)
+		tt.call(http.MethodGet, path+"?limit=1000", adminKey, nil, nil, http.StatusBadRequest)
+	}
+
+	// Webhooks and their deliveries
+	webhook := object(tt.post("/webhooks/add", models.WebhookCreateQuery{URL: "https://example.com/hook", Events: []string{"pr.merged"}, Secret: "s"}, http.StatusCreated)["webhook"])
+	tt.post("/webhooks/add", models.WebhookCreateQuery{URL: "ftp://example.com", Events: []string{"pr.merged"}}, http.StatusBadRequest)
+	tt.read("/webhooks/list", map[string]any{}, http.StatusOK)
+	webhookID := int64(webhook["webhook_id"].(float64))
+	delivery, _ := tt.storage.CreateDelivery(context.Background(), &models.WebhookDelivery{
+		WebhookID: webhookID, EventID: "event-1", EventType: "pr.merged", Payload: []byte(`{}`), Status: webhooks.StatusFailed, Attempts: 3, LastError: "HTTP 500",
+	})
+	tt.read("/webhooks/deliveries/failed", map[string]any{"webhook_id": webhookID}, http.StatusOK)
+	tt.read("/webhooks/deliveries/failed", map[string]any{"webhook_id": "first"}, http.StatusBadRequest)
+	tt.post("/webhooks/deliveries/replay", models.DeliveryIDQuery{DeliveryID: delivery.DeliveryID}, http.StatusAccepted)
+	tt.post("/webhooks/deliveries/replay", models.DeliveryIDQuery{DeliveryID: delivery.DeliveryID}, http.StatusConflict)
+	tt.post("/webhooks/deliveries/replay", models.DeliveryIDQuery{DeliveryID: 999}, http.StatusNotFound)
+	tt.post("/webhooks/deliveries/replay", models.DeliveryIDQuery{}, http.StatusBadRequest)
+	tt.post("/webhooks/delete", models.WebhookIDQuery{WebhookID: webhookID}, http.StatusNoContent)
+	tt.post("/webhooks/delete", models.WebhookIDQuery{WebhookID: webhookID}, http.StatusNotFound)
+	tt.post("/webhooks/delete", models.WebhookIDQuery{}, http.StatusBadRequest)
+
+	// API keys
+	key := object(tt.post("/apiKeys/add", models.APIKeyCreateQuery{Name: "ci", Scopes: []string{"write:prs"}}, http.StatusCreated)["api_key"])
+	tt.post("/apiKeys/add", models.APIKeyCreateQuery{Name: "ci", Scopes: []string{"root"}}, http.StatusBadRequest)
+	tt.read("/apiKeys/list", map[string]any{}, http.StatusOK)
+	keyID := int64(key["key_id"].(float64))
+	tt.post("/apiKeys/revoke", models.APIKeyIDQuery{KeyID: keyID}, http.StatusNoContent)
+	tt.post("/apiKeys/revoke", models.APIKeyIDQuery{KeyID: 999}, http.StatusNotFound)
+	tt.post("/apiKeys/revoke", models.APIKeyIDQuery{}, http.StatusBadRequest)
+
+	// Every operation of the API is covered, except the long-lived event stream
+	// and service endpoints that aren't registered by API
+	for template, item := range object(tt.spec["paths"]) {
+		if !strings.Contains(template, "/") || slices.Contains([]string{"/events/stream", "/health", "/openapi.json", "/docs", "/livez", "/readyz", "/metrics"}, template) {
+			continue
+		}
+		for method := range object(item) {
+			if !tt.called[method+" "+template] {
+				t.Errorf("%s %s isn't called", strings.ToUpper(method), template)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	goerrors "errors"
+	"strings"
+	"time"
+)
+
+// Page sizes of list endpoints
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// Cursor is opaque for clients: base64 of creation time and id of the last item
+func encodeCursor(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(value string) (*models.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	// Time has no spaces, id (team name) may have
+	createdAt, id, ok := strings.Cut(string(raw), " ")
+	if !ok || id == "" {
+		return nil, goerrors.New("cursor has no id")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Cursor{CreatedAt: t, ID: id}, nil
+}
+
+// Search cursor also keeps sort and order, so it can't be used with other ones
+type searchCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k"`
+	ID         string `json:"i"`
+}
+
+func encodeSearchCursor(cursor *models.SearchCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(searchCursor(*cursor))
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSearchCursor(value string) (*models.SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, goerrors.New("cursor has no id")
+	}
+	// Key of time sorts is RFC 3339, other keys are compared as text
+	switch cursor.Sort {
+	case "created_at", "merged_at":
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Key); err != nil {
+			return nil, err
+		}
+	case "name":
+	default:
+		return nil, goerrors.New("cursor has unknown sort")
+	}
+	after := models.SearchCursor(cursor)
+	return &after, nil
+}
+
+// validation of page size, zero means default
+func (v *violations) checkLimit(limit int) int {
+	if limit < 0 || limit > MaxPageLimit {
+		v.add("limit", ruleRange, "Limit must be from 1 to 100")
+	}
+	if limit == 0 {
+		return DefaultPageLimit
+	}
+	return limit
+}
+
+// validation of optional time range, to must be later than from
+func (v *violations) checkTimeRange(fromField, from, toField, to string) (*time.Time, *time.Time) {
+	fromTime := v.checkTime(fromField, from)
+	toTime := v.checkTime(toField, to)
+	if fromTime != nil && toTime != nil && !fromTime.Before(*toTime) {
+		v.add(toField, ruleRange, toField+" must be later than "+fromField)
+	}
+	return fromTime, toTime
+}
+
+// validation of page and creation date range, returns filter for storage
+func (v *violations) checkPage(limit int, cursor, createdFrom, createdTo string) models.ListFilter {
+	filter := models.ListFilter{Limit: v.checkLimit(limit)}
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			v.add("cursor", ruleFormat, "Cursor is invalid, use next_cursor of the previous page")
+		}
+		filter.After = after
+	}
+	filter.CreatedFrom, filter.CreatedTo = v.checkTimeRange("created_from", createdFrom, "created_to", createdTo)
+	return filter
+}
+
+// validation for optional RFC 3339 time, storage keeps times in UTC
+func (v *violations) checkTime(field, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.add(field, ruleFormat, field+" must be RFC 3339 time, e.g. 2024-01-31T10:00:00Z")
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// validation for optional pull request status
+func (v *violations) checkStatus(value string) {
+	if value != "" && value != "OPEN" && value != "MERGED" {
+		v.add("status", ruleEnum, "Status must be OPEN or MERGED")
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"encoding/base64"
+	"testing"
+)
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	cursors := []models.SearchCursor{
+		{Sort: "created_at", Descending: true, Key: "2024-01-31T10:00:00.123456Z", ID: "pr-1"},
+		{Sort: "merged_at", Key: "1970-01-01T00:00:00Z", ID: "pr-2"},
+		{Sort: "name", Key: "Исправить 50%", ID: "pr-3"},
+	}
+	for _, cursor := range cursors {
+		decoded, err := decodeSearchCursor(encodeSearchCursor(&cursor))
+		if err != nil {
+			t.Fatalf("decode %+v: %v", cursor, err)
+		}
+		if *decoded != cursor {
+			t.Errorf("decoded %+v, want %+v", *decoded, cursor)
+		}
+	}
+}
+
+func TestSearchCursorRejectsTamperedKey(t *testing.T) {
+	raw := map[string]string{
+		"bad time":     `{"s":"created_at","k":"garbage","i":"pr-1"}`,
+		"bad merged":   `{"s":"merged_at","k":"2024-13-01","i":"pr-1"}`,
+		"unknown sort": `{"s":"author","k":"u1","i":"pr-1"}`,
+		"no id":        `{"s":"name","k":"fix"}`,
+	}
+	for name, value := range raw {
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(value))
+		if _, err := decodeSearchCursor(cursor); err == nil {
+			t.Errorf("%s: cursor is accepted", name)
+		}
+		_, violations := ValidatePullRequestSearchQuery(models.PullRequestSearchQuery{Sort: "created_at", Cursor: cursor})
+		if len(violations) != 1 || violations[0].Field != "cursor" {
+			t.Errorf("%s: violations %v, want one for cursor", name, violations)
+		}
+	}
+}
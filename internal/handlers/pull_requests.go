@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"PR_reviewer_assign_service/internal/errors"
 	"PR_reviewer_assign_service/internal/models"
 	"PR_reviewer_assign_service/internal/service"
-	"encoding/json"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -25,25 +24,25 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var pr models.PullRequestCreateQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &pr); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidatePullRequestCreateQuery(pr); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidatePullRequestCreateQuery(pr); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Create pull request
-	log.Printf("Creating PR: %s", pr.PullRequestID)
+	slog.InfoContext(r.Context(), "Creating PR", "pull_request_id", pr.PullRequestID)
 	pullRequest, err := h.service.CreatePullRequest(r.Context(), pr)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("PR created: %s", pr.PullRequestID)
+	slog.InfoContext(r.Context(), "PR created", "pull_request_id", pr.PullRequestID)
 
 	// Send response
 	writeJSON(w, http.StatusCreated, pullRequest)
@@ -56,25 +55,25 @@ func (h *PRHandler) Merge(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var pr models.PullRequestMergeQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &pr); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Valiedate input
-	if err, msg := ValidatePullRequestMergeQuery(pr); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidatePullRequestMergeQuery(pr); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Merge pr
-	log.Printf("merging PR: %s", pr.PullRequestID)
+	slog.InfoContext(r.Context(), "Merging PR", "pull_request_id", pr.PullRequestID)
 	pullRequest, err := h.service.MergePullRequest(r.Context(), pr)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("PR merged: %s", pr.PullRequestID)
+	slog.InfoContext(r.Context(), "PR merged", "pull_request_id", pr.PullRequestID)
 
 	// Send Response
 	writeJSON(w, http.StatusOK, pullRequest)
@@ -87,25 +86,25 @@ func (h *PRHandler) Reassign(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var pr models.PullRequestReassignQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &pr); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Valiedate input
-	if err, msg := ValidatePullRequestReassignQuery(pr); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidatePullRequestReassignQuery(pr); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Reassign user
-	log.Printf("Reassigning user: %s, in PR: %s", pr.OldUserID, pr.PullRequestID)
+	slog.InfoContext(r.Context(), "Reassigning reviewer", "pull_request_id", pr.PullRequestID, "old_user_id", pr.OldUserID)
 	pullRequest, newCandidate, err := h.service.Reassign(r.Context(), pr)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("User: %s, reassigned to: %s, in PR: %s", pr.OldUserID, *newCandidate, pr.PullRequestID)
+	slog.InfoContext(r.Context(), "Reviewer reassigned", "pull_request_id", pr.PullRequestID, "old_user_id", pr.OldUserID, "new_user_id", *newCandidate)
 
 	// Send Response
 	writeJSON(w, http.StatusOK, PRReassignReasponse{
@@ -117,14 +116,140 @@ func (h *PRHandler) Reassign(w http.ResponseWriter, r *http.Request) {
 // Additional functions
 func (h *PRHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
 	// Get teams statistics
-	log.Printf("Receiving Pull Requests' statistics")
+	slog.DebugContext(r.Context(), "Receiving PRs statistics")
 	statistics, err := h.service.GetPRStatistics(r.Context())
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("Pull Requests' statistics received")
+	slog.DebugContext(r.Context(), "PRs statistics received")
 
 	// Send response
 	writeJSON(w, http.StatusOK, statistics)
 }
+
+/*
+/pullRequest/overdue - TeamNameQuery (optional)
+*/
+func (h *PRHandler) GetOverdue(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty body means all teams
+	var teamName models.TeamNameQuery
+
+	if err := decodeRequest(r, &teamName); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if teamName.TeamName != "" {
+		if violations := ValidateTeamNameQuery(teamName); len(violations) > 0 {
+			writeViolations(w, violations)
+			return
+		}
+	}
+
+	// Get overdue prs
+	slog.DebugContext(r.Context(), "Receiving overdue PRs")
+	prs, err := h.service.GetOverduePRs(r.Context(), teamName.TeamName)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Overdue PRs received")
+
+	// Send response
+	writeJSON(w, http.StatusOK, OverduePRsResponse{PullRequests: prs})
+}
+
+/*
+/pullRequest/history - PullRequestMergeQuery
+*/
+func (h *PRHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var pr models.PullRequestMergeQuery
+
+	if err := decodeRequest(r, &pr); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidatePullRequestMergeQuery(pr); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Get reviewers history
+	slog.DebugContext(r.Context(), "Receiving reviewers history", "pull_request_id", pr.PullRequestID)
+	history, err := h.service.GetReviewerHistory(r.Context(), pr.PullRequestID)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Reviewers history received", "pull_request_id", pr.PullRequestID)
+
+	// Send response
+	writeJSON(w, http.StatusOK, ReviewerHistoryResponse{
+		PullRequestID: pr.PullRequestID,
+		History:       history,
+	})
+}
+
+/*
+/pullRequest/list - PullRequestListQuery (optional)
+*/
+func (h *PRHandler) ListPullRequests(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty query means first page of all pull requests
+	var query models.PullRequestListQuery
+
+	if err := decodeRequest(r, &query); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	filter, violations := ValidatePullRequestListQuery(query)
+	if len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Get pull requests
+	slog.DebugContext(r.Context(), "Listing pull requests", "status", filter.Status, "author_id", filter.AuthorID, "team_name", filter.TeamName)
+	prs, cursor, err := h.service.ListPullRequests(r.Context(), filter)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+
+	// Send response
+	writeJSON(w, http.StatusOK, PRsListResponse{PullRequests: prs, NextCursor: encodeCursor(cursor)})
+}
+
+func (h *PRHandler) SearchPullRequests(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty query means first page of all pull requests
+	var query models.PullRequestSearchQuery
+
+	if err := decodeRequest(r, &query); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	filter, violations := ValidatePullRequestSearchQuery(query)
+	if len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Search pull requests
+	slog.DebugContext(r.Context(), "Searching pull requests", "name", filter.Name, "sort", filter.Sort, "descending", filter.Descending)
+	prs, cursor, err := h.service.SearchPullRequests(r.Context(), filter)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+
+	// Send response
+	writeJSON(w, http.StatusOK, PRsListResponse{PullRequests: prs, NextCursor: encodeSearchCursor(cursor)})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/ratelimit"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware that limits requests of each caller, or IP without valid credentials, per route group
+type RateLimits struct {
+	limiters map[string]*ratelimit.Limiter
+	clock    clock.Clock
+	// Take client IP from X-Forwarded-For, only behind trusted proxy
+	trustProxy bool
+}
+
+func NewRateLimits(clock clock.Clock) *RateLimits {
+	return &RateLimits{
+		limiters: make(map[string]*ratelimit.Limiter),
+		clock:    clock,
+	}
+}
+
+// Limit requests of route group
+func (l *RateLimits) Set(group string, rule ratelimit.Rule) {
+	l.limiters[group] = ratelimit.NewLimiter(rule, l.clock)
+}
+
+// Limit requests of caller (key:<API key ID> or user:<token subject>) in route group with own rule,
+// the group must be limited by Set first
+func (l *RateLimits) SetCredential(group, credential string, rule ratelimit.Rule) error {
+	limiter, ok := l.limiters[group]
+	if !ok {
+		return fmt.Errorf("route group %q isn't limited", group)
+	}
+	limiter.SetKeyRule(credential, rule)
+	return nil
+}
+
+func (l *RateLimits) TrustProxy() {
+	l.trustProxy = true
+}
+
+// Wrap handler of route group before authentication: when IP has no requests left,
+// its credentials aren't checked, so random keys can't load the database
+func (l *RateLimits) Gate(group string, next http.HandlerFunc) http.HandlerFunc {
+	limiter, ok := l.limiters[group]
+	if !ok {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if credentials(r) != "" {
+			if ready, wait := limiter.Ready("ip:" + l.clientIP(r)); !ready {
+				writeRateLimited(w, wait)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// Wrap handler of route group after Auth.Identify, groups without rule aren't limited
+func (l *RateLimits) Limit(group string, next http.HandlerFunc) http.HandlerFunc {
+	limiter, ok := l.limiters[group]
+	if !ok {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if allowed, wait := limiter.Allow(l.clientKey(r)); !allowed {
+			writeRateLimited(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	seconds := max(int(math.Ceil(wait.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeErrorMessage(w, errors.ErrorCodeRateLimited, "Too many requests, retry in "+(time.Duration(seconds)*time.Second).String())
+}
+
+// Authenticated caller, requests with missing or invalid credentials are counted per IP
+func (l *RateLimits) clientKey(r *http.Request) string {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		return principalKey(principal)
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *RateLimits) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
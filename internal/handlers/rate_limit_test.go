@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/ratelimit"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Storage with API keys only, counts lookups
+type keyStorage struct {
+	storage.Storage
+	keys    map[string]*models.APIKey
+	lookups int
+}
+
+func (s *keyStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	s.lookups++
+	return s.keys[hash], nil
+}
+
+type rateLimitTest struct {
+	t       *testing.T
+	clock   *clock.Manual
+	storage *keyStorage
+	limits  *RateLimits
+	handler http.HandlerFunc
+}
+
+// Route of group "prs" guarded like in main: 2 requests at once, 1 per second
+func newRateLimitTest(t *testing.T) *rateLimitTest {
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	keys := &keyStorage{keys: map[string]*models.APIKey{
+		auth.HashKey("key-3"): {KeyID: 3, Name: "ci", Scopes: []string{string(auth.ScopeWritePRs)}},
+		auth.HashKey("key-4"): {KeyID: 4, Name: "bot", Scopes: []string{string(auth.ScopeWritePRs)}},
+	}}
+	access := NewAuth(service.NewService(keys, nil))
+	limits := NewRateLimits(clk)
+	limits.Set("prs", ratelimit.Rule{Rate: 1, Burst: 2})
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	return &rateLimitTest{
+		t:       t,
+		clock:   clk,
+		storage: keys,
+		limits:  limits,
+		handler: limits.Gate("prs", access.Identify(limits.Limit("prs", access.Require(auth.ScopeWritePRs, ok)))),
+	}
+}
+
+func (tt *rateLimitTest) do(ip, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+	r.RemoteAddr = ip + ":40000"
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	tt.handler(w, r)
+	return w
+}
+
+func (tt *rateLimitTest) expect(w *httptest.ResponseRecorder, status int) {
+	tt.t.Helper()
+	if w.Code != status {
+		tt.t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	tt := newRateLimitTest(t)
+	tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusNoContent)
+	tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusNoContent)
+
+	w := tt.do("10.0.0.1", "key-3")
+	tt.expect(w, http.StatusTooManyRequests)
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After %q, want 1", got)
+	}
+	var response ErrorRespone
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error.Code != "RATE_LIMITED" {
+		t.Errorf("code %q, want RATE_LIMITED", response.Error.Code)
+	}
+
+	tt.clock.Advance(time.Second)
+	tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusNoContent)
+}
+
+func TestRateLimitKeyedOnPrincipal(t *testing.T) {
+	tt := newRateLimitTest(t)
+
+	// Same key from different IPs shares one bucket
+	tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusNoContent)
+	tt.expect(tt.do("10.0.0.2", "key-3"), http.StatusNoContent)
+	tt.expect(tt.do("10.0.0.3", "key-3"), http.StatusTooManyRequests)
+
+	// Other key from the same IP isn't limited
+	tt.expect(tt.do("10.0.0.1", "key-4"), http.StatusNoContent)
+
+	// Nor are anonymous requests from that IP, they get 401
+	tt.expect(tt.do("10.0.0.1", ""), http.StatusUnauthorized)
+}
+
+func TestRateLimitInvalidKeysCountedPerIP(t *testing.T) {
+	tt := newRateLimitTest(t)
+
+	// Random keys are counted in bucket of IP
+	tt.expect(tt.do("10.0.0.1", "random-1"), http.StatusUnauthorized)
+	tt.expect(tt.do("10.0.0.1", "random-2"), http.StatusUnauthorized)
+	tt.expect(tt.do("10.0.0.1", "random-3"), http.StatusTooManyRequests)
+	lookups := tt.storage.lookups
+
+	// Once IP is limited, keys aren't looked up
+	for i := 0; i < 10; i++ {
+		tt.expect(tt.do("10.0.0.1", "random-x"), http.StatusTooManyRequests)
+	}
+	if tt.storage.lookups != lookups {
+		t.Errorf("%d lookups of keys from limited IP", tt.storage.lookups-lookups)
+	}
+
+	// Missing credentials share the same bucket
+	tt.expect(tt.do("10.0.0.1", ""), http.StatusTooManyRequests)
+
+	// Other IPs aren't affected
+	tt.expect(tt.do("10.0.0.2", "random-4"), http.StatusUnauthorized)
+	tt.expect(tt.do("10.0.0.2", "key-3"), http.StatusNoContent)
+}
+
+func TestRateLimitCredentialRule(t *testing.T) {
+	tt := newRateLimitTest(t)
+	if err := tt.limits.SetCredential("prs", "key:3", ratelimit.Rule{Rate: 1, Burst: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rule of unknown group isn't ignored silently
+	if err := tt.limits.SetCredential("pr", "key:4", ratelimit.Rule{Rate: 1, Burst: 5}); err == nil {
+		t.Error("rule of unknown group is accepted")
+	}
+
+	for i := 0; i < 5; i++ {
+		tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusNoContent)
+	}
+	tt.expect(tt.do("10.0.0.1", "key-3"), http.StatusTooManyRequests)
+
+	tt.expect(tt.do("10.0.0.1", "key-4"), http.StatusNoContent)
+	tt.expect(tt.do("10.0.0.1", "key-4"), http.StatusNoContent)
+	tt.expect(tt.do("10.0.0.1", "key-4"), http.StatusTooManyRequests)
+}
+
+func TestRateLimitTrustProxy(t *testing.T) {
+	tt := newRateLimitTest(t)
+	do := func(forwarded string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		r.RemoteAddr = "10.0.0.254:40000"
+		r.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		tt.handler(w, r)
+		return w
+	}
+
+	// Without trusted proxy all clients behind it share its IP
+	tt.expect(do("192.0.2.1"), http.StatusUnauthorized)
+	tt.expect(do("192.0.2.2"), http.StatusUnauthorized)
+	tt.expect(do("192.0.2.3"), http.StatusTooManyRequests)
+
+	tt.limits.TrustProxy()
+	tt.expect(do("192.0.2.4, 10.0.0.254"), http.StatusUnauthorized)
+	tt.expect(do("192.0.2.4, 10.0.0.254"), http.StatusUnauthorized)
+	tt.expect(do("192.0.2.4, 10.0.0.254"), http.StatusTooManyRequests)
+	tt.expect(do("192.0.2.5"), http.StatusUnauthorized)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"bytes"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Default limit of request body
+const DefaultMaxBodyBytes = 1 << 20
+
+var maxBodyBytes int64 = DefaultMaxBodyBytes
+
+// Change limit of request body, called before serving requests
+func SetMaxBodyBytes(n int64) {
+	maxBodyBytes = n
+}
+
+var (
+	errBodyTooLarge         = goerrors.New("request body is too large")
+	errUnsupportedMediaType = goerrors.New("request body must be application/json")
+)
+
+// Invalid fields found while decoding: missing required, unknown or of wrong type
+type decodeError struct {
+	violations []models.Violation
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("%d invalid fields", len(e.violations))
+}
+
+/*
+Decode request parameters into dst.
+Parameters are taken from JSON body, or from query string if body is empty,
+path parameters override both. Returns io.EOF if request has no parameters.
+Body must be a single JSON object of known fields, fields tagged required:"true"
+must be present even if their value is false or empty. Body without Content-Type
+is taken as JSON, like older clients send it.
+*/
+func decodeRequest(r *http.Request, dst interface{}) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	params := r.URL.Query()
+	for name, value := range PathParams(r) {
+		params.Set(name, value)
+	}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		if contentType := r.Header.Get("Content-Type"); contentType != "" {
+			if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" {
+				return errUnsupportedMediaType
+			}
+		}
+		if err := decodeJSON(body, dst); err != nil {
+			return err
+		}
+		var v violations
+		requiredFields(body, reflect.TypeOf(dst).Elem(), "", &v)
+		if len(v) > 0 {
+			return &decodeError{violations: v}
+		}
+		return decodeParams(pathValues(r), dst)
+	}
+
+	if len(params) == 0 {
+		return io.EOF
+	}
+	var v violations
+	t := reflect.TypeOf(dst).Elem()
+	for name := range r.URL.Query() {
+		if !hasField(t, name) {
+			v.add(name, ruleUnknown, "Unknown parameter "+name)
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if t.Field(i).Tag.Get("required") == "true" && !params.Has(name) {
+			v.add(name, ruleRequired, name+" is required")
+		}
+	}
+	if len(v) > 0 {
+		return &decodeError{violations: v}
+	}
+	return decodeParams(params, dst)
+}
+
+// Read whole body, at most maxBodyBytes
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	return body, nil
+}
+
+// Strict decoding: unknown fields and data after the object are rejected
+func decodeJSON(body []byte, dst interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if goerrors.As(err, &typeErr) && typeErr.Field != "" {
+			return &decodeError{violations: []models.Violation{{
+				Field:   typeErr.Field,
+				Rule:    ruleType,
+				Message: fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type),
+			}}}
+		}
+		// Unknown field error has no type of its own
+		if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if name, err := strconv.Unquote(name); err == nil {
+				return &decodeError{violations: []models.Violation{{
+					Field:   name,
+					Rule:    ruleUnknown,
+					Message: "Unknown field " + name,
+				}}}
+			}
+		}
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return goerrors.New("unexpected data after JSON object")
+	}
+	return nil
+}
+
+// Report fields tagged required:"true" that are absent or null in data, nested objects included
+func requiredFields(data json.RawMessage, t reflect.Type, path string, v *violations) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		requiredFields(data, t.Elem(), path, v)
+	case reflect.Slice:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return
+		}
+		for i, item := range items {
+			requiredFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), v)
+		}
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonName(t.Field(i))
+			if name == "" || name == "-" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			value, ok := object[name]
+			if !ok || string(value) == "null" {
+				if t.Field(i).Tag.Get("required") == "true" {
+					v.add(fieldPath, ruleRequired, fieldPath+" is required")
+				}
+				continue
+			}
+			requiredFields(value, t.Field(i).Type, fieldPath, v)
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func hasField(t reflect.Type, name string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Error response for failed decodeRequest
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var invalid *decodeError
+	switch {
+	case goerrors.As(err, &invalid):
+		writeViolations(w, invalid.violations)
+	case goerrors.Is(err, errBodyTooLarge):
+		writeErrorMessage(w, errors.ErrorCodePayloadTooLarge, fmt.Sprintf("Request body must be at most %d bytes", maxBodyBytes))
+	case goerrors.Is(err, errUnsupportedMediaType):
+		writeErrorMessage(w, errors.ErrorCodeUnsupportedMediaType, "Request body must be application/json")
+	case goerrors.Is(err, io.EOF):
+		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid request format: no parameters")
+	default:
+		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid request format: "+strings.TrimPrefix(err.Error(), "json: "))
+	}
+}
+
+func pathValues(r *http.Request) url.Values {
+	values := url.Values{}
+	for name, value := range PathParams(r) {
+		values.Set(name, value)
+	}
+	return values
+}
+
+// Set fields of struct dst by their json names
+func decodeParams(values url.Values, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if name == "" || name == "-" || !values.Has(name) {
+			continue
+		}
+		value := values.Get(name)
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("parameter %s must be boolean", name)
+			}
+			field.SetBool(b)
+		case reflect.Int, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parameter %s must be integer", name)
+			}
+			field.SetInt(n)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("parameter %s is not supported in query", name)
+			}
+			field.Set(reflect.ValueOf(values[name]))
+		default:
+			return fmt.Errorf("parameter %s is not supported in query", name)
+		}
+	}
+	return nil
+}
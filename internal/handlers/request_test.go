@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func jsonRequest(target, contentType, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+// Violations of decodeRequest error as "field:rule", nil if it isn't decodeError
+func decodeViolations(err error) []string {
+	var invalid *decodeError
+	if !goerrors.As(err, &invalid) {
+		return nil
+	}
+	var got []string
+	for _, v := range invalid.violations {
+		got = append(got, v.Field+":"+v.Rule)
+	}
+	slices.Sort(got)
+	return got
+}
+
+// Status and error code that writeDecodeError responds with
+func decodeResponse(t *testing.T, err error) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	writeDecodeError(w, err)
+	var response ErrorRespone
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, string(response.Error.Code)
+}
+
+func TestDecodeRequestRequiredFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		violations []string
+	}{
+		{"missing", `{"user_id":"u1"}`, []string{"is_active:required"}},
+		{"null", `{"user_id":"u1","is_active":null}`, []string{"is_active:required"}},
+		{"explicit false", `{"user_id":"u1","is_active":false}`, nil},
+		{"empty optional string", `{"user_id":"","is_active":true}`, nil},
+		{"wrong type", `{"user_id":"u1","is_active":"no"}`, []string{"is_active:type"}},
+		{"unknown field", `{"user_id":"u1","is_actve":false}`, []string{"is_actve:unknown"}},
+	}
+	for _, test := range tests {
+		var query models.UserActiveQuery
+		err := decodeRequest(jsonRequest("/users/setIsActive", "application/json", test.body), &query)
+		if got := decodeViolations(err); !slices.Equal(got, test.violations) {
+			t.Errorf("%s: violations %v, want %v (error %v)", test.name, got, test.violations, err)
+		}
+		if test.violations == nil && err != nil {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+	}
+}
+
+func TestDecodeRequestRequiredNestedFields(t *testing.T) {
+	body := `{"team_name":"backend","members":[
+		{"user_id":"u1","username":"Alice","is_active":false},
+		{"user_id":"u2","username":"Bob"},
+		{"user_id":"u3","username":"Carol","is_active":null}
+	]}`
+	var team models.Team
+	err := decodeRequest(jsonRequest("/team/add", "application/json", body), &team)
+	want := []string{"members[1].is_active:required", "members[2].is_active:required"}
+	if got := decodeViolations(err); !slices.Equal(got, want) {
+		t.Errorf("violations %v, want %v", got, want)
+	}
+	if status, code := decodeResponse(t, err); status != http.StatusBadRequest || code != "INVALID_INPUT" {
+		t.Errorf("response %d %s, want 400 INVALID_INPUT", status, code)
+	}
+}
+
+func TestDecodeRequestRejectsTrailingData(t *testing.T) {
+	for _, body := range []string{`{"user_id":"u1","is_active":true} {}`, `{"user_id":"u1","is_active":true} x`, `[{}]`} {
+		var query models.UserActiveQuery
+		err := decodeRequest(jsonRequest("/users/setIsActive", "application/json", body), &query)
+		if err == nil {
+			t.Errorf("%s is accepted", body)
+			continue
+		}
+		if status, code := decodeResponse(t, err); status != http.StatusBadRequest || code != "INVALID_INPUT" {
+			t.Errorf("%s: response %d %s, want 400 INVALID_INPUT", body, status, code)
+		}
+	}
+}
+
+func TestDecodeRequestBodyLimit(t *testing.T) {
+	// Body of exactly the limit is read, one byte more is rejected
+	body := `{"user_id":"u1","is_active":true}`
+	defer SetMaxBodyBytes(maxBodyBytes)
+	SetMaxBodyBytes(int64(len(body)))
+
+	var query models.UserActiveQuery
+	if err := decodeRequest(jsonRequest("/users/setIsActive", "application/json", body), &query); err != nil {
+		t.Errorf("body of limit size: %v", err)
+	}
+	err := decodeRequest(jsonRequest("/users/setIsActive", "application/json", body+" "), &query)
+	if status, code := decodeResponse(t, err); status != http.StatusRequestEntityTooLarge || code != "PAYLOAD_TOO_LARGE" {
+		t.Errorf("response %d %s, want 413 PAYLOAD_TOO_LARGE", status, code)
+	}
+}
+
+func TestDecodeRequestContentType(t *testing.T) {
+	body := `{"user_id":"u1","is_active":true}`
+	for contentType, wantErr := range map[string]bool{
+		"application/json":                false,
+		"application/json; charset=utf-8": false,
+		// Older clients send JSON without Content-Type
+		"":                                  false,
+		"text/plain":                        true,
+		"application/x-www-form-urlencoded": true,
+	} {
+		var query models.UserActiveQuery
+		err := decodeRequest(jsonRequest("/users/setIsActive", contentType, body), &query)
+		if !wantErr {
+			if err != nil || query.UserID != "u1" || !query.IsActive {
+				t.Errorf("Content-Type %q: %+v, %v", contentType, query, err)
+			}
+			continue
+		}
+		if status, code := decodeResponse(t, err); status != http.StatusUnsupportedMediaType || code != "UNSUPPORTED_MEDIA_TYPE" {
+			t.Errorf("Content-Type %q: response %d %s, want 415 UNSUPPORTED_MEDIA_TYPE", contentType, status, code)
+		}
+	}
+}
+
+func TestDecodeRequestQuery(t *testing.T) {
+	var query models.UserActiveQuery
+	err := decodeRequest(httptest.NewRequest(http.MethodGet, "/users/setIsActive?user_id=u1&is_active=false", nil), &query)
+	if err != nil || query.UserID != "u1" || query.IsActive {
+		t.Errorf("query: %+v, %v", query, err)
+	}
+
+	err = decodeRequest(httptest.NewRequest(http.MethodGet, "/users/setIsActive?user_id=u1&is_actve=false", nil), &query)
+	if got, want := decodeViolations(err), []string{"is_active:required", "is_actve:unknown"}; !slices.Equal(got, want) {
+		t.Errorf("violations %v, want %v", got, want)
+	}
+
+	if err := decodeRequest(httptest.NewRequest(http.MethodGet, "/users/setIsActive", nil), &query); !goerrors.Is(err, io.EOF) {
+		t.Errorf("no parameters: %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeRequestPathParamsOverrideBody(t *testing.T) {
+	r := jsonRequest("/pull-requests/pr-2/merge", "application/json", `{"pull_request_id":"pr-1"}`)
+	r = r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, map[string]string{"pull_request_id": "pr-2"}))
+	var query models.PullRequestMergeQuery
+	if err := decodeRequest(r, &query); err != nil || query.PullRequestID != "pr-2" {
+		t.Errorf("query %+v, %v, want pr-2 from path", query, err)
+	}
+}
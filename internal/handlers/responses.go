@@ -5,6 +5,7 @@ import (
 	"PR_reviewer_assign_service/internal/models"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 // Response structs
@@ -24,6 +25,8 @@ type ErrorRespone struct {
 	Error struct {
 		Code    errors.ErrorCode `json:"code"`
 		Message string           `json:"message"`
+		// Every invalid field of INVALID_INPUT responses
+		Details []models.Violation `json:"details,omitempty"`
 	} `json:"error"`
 }
 
@@ -35,6 +38,67 @@ type PRReassignReasponse struct {
 type GetReviewResponse struct {
 	UserID       string                    `json:"user_id"`
 	PullRequests []models.PullRequestShort `json:"pull_requests"`
+	NextCursor   string                    `json:"next_cursor,omitempty"`
+}
+
+// Pages of lists, next_cursor is missing on the last page
+type TeamsListResponse struct {
+	Teams      []models.TeamSummary `json:"teams"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+type UsersListResponse struct {
+	Users      []models.User `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type PRsListResponse struct {
+	PullRequests []models.PullRequest `json:"pull_requests"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+type WebhookResponse struct {
+	Webhook *models.Webhook `json:"webhook"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []models.Webhook `json:"webhooks"`
+}
+
+type DeliveryResponse struct {
+	Delivery *models.WebhookDelivery `json:"delivery"`
+}
+
+type DeliveriesResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+type OverduePRsResponse struct {
+	PullRequests []models.OverduePR `json:"pull_requests"`
+}
+
+type ReviewerHistoryResponse struct {
+	PullRequestID string                  `json:"pull_request_id"`
+	History       []models.ReviewerChange `json:"history"`
+}
+
+type APIKeyResponse struct {
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
 }
 
 // Response functions
@@ -49,18 +113,30 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 
 func writeError(w http.ResponseWriter, code errors.ErrorCode) {
 	status, message := errors.GetInfo(code)
-	writeErrorResponse(w, code, status, message)
+	writeErrorResponse(w, code, status, message, nil)
 }
 
 func writeErrorMessage(w http.ResponseWriter, code errors.ErrorCode, message string) {
 	status, _ := errors.GetInfo(code)
-	writeErrorResponse(w, code, status, message)
+	writeErrorResponse(w, code, status, message, nil)
+}
+
+// Message joins messages of all violations, details list them by field
+func writeViolations(w http.ResponseWriter, violations []models.Violation) {
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.Message
+	}
+	status, _ := errors.GetInfo(errors.ErrorCodeInvalidInput)
+	writeErrorResponse(w, errors.ErrorCodeInvalidInput, status, strings.Join(messages, "; "), violations)
 }
 
-func writeErrorResponse(w http.ResponseWriter, code errors.ErrorCode, status int, message string) {
+func writeErrorResponse(w http.ResponseWriter, code errors.ErrorCode, status int, message string, details []models.Violation) {
 	response := ErrorRespone{}
 	response.Error.Code = code
 	response.Error.Message = message
+	response.Error.Details = details
+	recordErrorCode(w, code)
 
 	w.Header().Set("Content-type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Router that checks request methods and supports path parameters: /teams/{team_name}.
+// HEAD is answered by GET handlers, OPTIONS lists methods of the path in Allow header.
+type Router struct {
+	routes []route
+}
+
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+type pathParamsKey struct{}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Register handler for method and path pattern
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc) {
+	rt.routes = append(rt.routes, route{
+		method:   method,
+		pattern:  pattern,
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+func (rt *Router) Get(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodGet, pattern, handler)
+}
+
+func (rt *Router) Post(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPost, pattern, handler)
+}
+
+// Register read endpoint: GET with query parameters, or legacy POST/GET with JSON body
+func (rt *Router) Read(pattern string, handler http.HandlerFunc) {
+	rt.Get(pattern, handler)
+	rt.Post(pattern, handler)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+
+	var allowed []string
+	for _, route := range rt.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		recordRoute(w, route.pattern)
+		// HEAD is served by GET handler, server drops the body
+		if route.method != r.Method && !(r.Method == http.MethodHead && route.method == http.MethodGet) {
+			allowed = append(allowed, route.method)
+			continue
+		}
+
+		if len(params) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+		}
+		route.handler(w, r)
+		return
+	}
+
+	// Path exists, but not for this method
+	if len(allowed) > 0 {
+		if slices.Contains(allowed, http.MethodGet) {
+			allowed = append(allowed, http.MethodHead)
+		}
+		allowed = append(allowed, http.MethodOptions)
+		slices.Sort(allowed)
+		w.Header().Set("Allow", strings.Join(slices.Compact(allowed), ", "))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeError(w, errors.ErrorCodeMethodNotAllowed)
+		return
+	}
+	writeErrorMessage(w, errors.ErrorCodeNotFound, "Path "+r.URL.Path+" not found")
+}
+
+func (r *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// Parameters from request path
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
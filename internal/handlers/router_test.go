@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Handler that answers with its name and path parameters
+func echo(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + fmt.Sprint(PathParams(r))))
+	}
+}
+
+func serve(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRouterPathParams(t *testing.T) {
+	router := NewRouter()
+	router.Get("/teams", echo("teams"))
+	router.Get("/teams/{team_name}", echo("team"))
+	router.Get("/users/{user_id}/reviews", echo("reviews"))
+	router.Post("/pull-requests/{pull_request_id}/merge", echo("merge"))
+
+	tests := []struct {
+		method, target, want string
+	}{
+		{http.MethodGet, "/teams", "teams map[]"},
+		{http.MethodGet, "/teams/", "teams map[]"},
+		{http.MethodGet, "/teams/backend", "team map[team_name:backend]"},
+		{http.MethodGet, "/teams/back%20end", "team map[team_name:back end]"},
+		{http.MethodGet, "/users/u1/reviews", "reviews map[user_id:u1]"},
+		{http.MethodPost, "/pull-requests/pr-1/merge", "merge map[pull_request_id:pr-1]"},
+	}
+	for _, test := range tests {
+		w := serve(router, test.method, test.target)
+		if w.Code != http.StatusOK || w.Body.String() != test.want {
+			t.Errorf("%s %s: %d %s, want %s", test.method, test.target, w.Code, w.Body, test.want)
+		}
+	}
+
+	// Parameters can't be empty or span segments
+	for _, target := range []string{"/users//reviews", "/users/u1/u2/reviews", "/pull-requests/merge"} {
+		if w := serve(router, http.MethodGet, target); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: %d, want 404", target, w.Code)
+		}
+	}
+}
+
+func TestRouterMethods(t *testing.T) {
+	router := NewRouter()
+	router.Read("/team/get", echo("read"))
+	router.Post("/team/add", echo("add"))
+	router.Get("/teams/{team_name}", echo("team"))
+
+	tests := []struct {
+		method, target string
+		status         int
+		allow          string
+	}{
+		{http.MethodPut, "/team/get", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST"},
+		{http.MethodGet, "/team/add", http.StatusMethodNotAllowed, "OPTIONS, POST"},
+		{http.MethodHead, "/team/add", http.StatusMethodNotAllowed, "OPTIONS, POST"},
+		{http.MethodDelete, "/teams/backend", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{http.MethodOptions, "/team/get", http.StatusNoContent, "GET, HEAD, OPTIONS, POST"},
+		{http.MethodOptions, "/teams/backend", http.StatusNoContent, "GET, HEAD, OPTIONS"},
+		{http.MethodHead, "/teams/backend", http.StatusOK, ""},
+		{http.MethodHead, "/team/get", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		w := serve(router, test.method, test.target)
+		if w.Code != test.status || w.Header().Get("Allow") != test.allow {
+			t.Errorf("%s %s: %d, Allow %q, want %d, %q", test.method, test.target, w.Code, w.Header().Get("Allow"), test.status, test.allow)
+		}
+		if w.Code != http.StatusMethodNotAllowed {
+			continue
+		}
+		var response ErrorRespone
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil || response.Error.Code != "METHOD_NOT_ALLOWED" {
+			t.Errorf("%s %s: error %+v, %v", test.method, test.target, response, err)
+		}
+	}
+
+	if w := serve(router, http.MethodGet, "/team/unknown"); w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Errorf("unknown path: %d, Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestRoutesKeepLegacyPaths(t *testing.T) {
+	router := NewRouter()
+	api := &API{Idempotency: NewIdempotency(nil)}
+	// Every route answers with its group and scope instead of calling the handler
+	api.Register(router, func(group string, scope auth.Scope) func(http.HandlerFunc) http.HandlerFunc {
+		return func(http.HandlerFunc) http.HandlerFunc {
+			return echo(group + " " + string(scope))
+		}
+	})
+
+	tests := []struct {
+		method, target, want string
+	}{
+		{http.MethodPost, "/team/add", "teams write:teams map[]"},
+		{http.MethodGet, "/team/get", "read read map[]"},
+		{http.MethodPost, "/team/get", "read read map[]"},
+		{http.MethodPost, "/users/setIsActive", "users write:users map[]"},
+		{http.MethodPost, "/pullRequest/create", "prs write:prs map[]"},
+		{http.MethodPost, "/pullRequest/merge", "prs write:prs map[]"},
+		{http.MethodPost, "/pullRequest/reassign", "prs write:prs map[]"},
+		{http.MethodGet, "/users/getReview", "read read map[]"},
+		{http.MethodPost, "/users/getReview", "read read map[]"},
+		{http.MethodPost, "/webhooks/add", "admin admin map[]"},
+		{http.MethodGet, "/teams/backend", "read read map[team_name:backend]"},
+		{http.MethodGet, "/users/u1/reviews", "read read map[user_id:u1]"},
+		{http.MethodPost, "/pull-requests/pr-1/merge", "prs write:prs map[pull_request_id:pr-1]"},
+	}
+	for _, test := range tests {
+		w := serve(router, test.method, test.target)
+		if w.Code != http.StatusOK || w.Body.String() != test.want {
+			t.Errorf("%s %s: %d %s, want %s", test.method, test.target, w.Code, w.Body, test.want)
+		}
+	}
+
+	// Changes aren't read endpoints
+	if w := serve(router, http.MethodGet, "/pullRequest/merge"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pullRequest/merge: %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"net/http"
+)
+
+// Guard of route group: rate limit of group, authentication and required scope
+type Guard func(group string, scope auth.Scope) func(http.HandlerFunc) http.HandlerFunc
+
+// Handlers of the service API
+type API struct {
+	Users       *UserHandler
+	Teams       *TeamHandler
+	PRs         *PRHandler
+	Webhooks    *WebhookHandler
+	APIKeys     *APIKeyHandler
+	Events      *EventHandler
+	Idempotency *Idempotency
+}
+
+// Register routes of the API, each guarded by its group and scope
+func (api *API) Register(router *Router, guard Guard) {
+	read := guard("read", auth.ScopeRead)
+	writeTeams := guard("teams", auth.ScopeWriteTeams)
+	writeUsers := guard("users", auth.ScopeWriteUsers)
+	writePRs := guard("prs", auth.ScopeWritePRs)
+	admin := guard("admin", auth.ScopeAdmin)
+
+	router.Post("/team/add", writeTeams(api.Idempotency.Wrap(api.Teams.AddTeam)))
+	router.Read("/team/get", read(api.Teams.GetTeam))
+	router.Post("/team/addMember", writeTeams(api.Teams.AddMember))
+	router.Post("/team/removeMember", writeTeams(api.Teams.RemoveMember))
+	router.Post("/users/setIsActive", writeUsers(api.Users.SetIsActive))
+	router.Post("/pullRequest/create", writePRs(api.Idempotency.Wrap(api.PRs.CreatePR)))
+	router.Post("/pullRequest/merge", writePRs(api.PRs.Merge))
+	router.Post("/pullRequest/reassign", writePRs(api.PRs.Reassign))
+	router.Read("/users/getReview", read(api.Users.GetReview))
+	// Additional functions
+	router.Read("/users/statistics", read(api.Users.GetUserStatistics))
+	router.Read("/users/get", read(api.Users.GetStatistics))
+	router.Read("/team/statistics", read(api.Teams.GetTeamStatistics))
+	router.Read("/team/count", read(api.Teams.GetStatistics))
+	router.Read("/pullRequest/statistics", read(api.PRs.GetStatistics))
+	// Paginated lists
+	router.Read("/team/list", read(api.Teams.ListTeams))
+	router.Read("/users/list", read(api.Users.ListUsers))
+	router.Read("/pullRequest/list", read(api.PRs.ListPullRequests))
+	router.Read("/pullRequest/search", read(api.PRs.SearchPullRequests))
+	// Review SLA
+	router.Read("/team/getSettings", read(api.Teams.GetSettings))
+	router.Post("/team/setSettings", writeTeams(api.Teams.SetSettings))
+	router.Read("/pullRequest/overdue", read(api.PRs.GetOverdue))
+	router.Read("/pullRequest/history", read(api.PRs.GetHistory))
+	// Webhooks
+	router.Post("/webhooks/add", admin(api.Webhooks.AddWebhook))
+	router.Read("/webhooks/list", admin(api.Webhooks.GetWebhooks))
+	router.Post("/webhooks/delete", admin(api.Webhooks.DeleteWebhook))
+	router.Read("/webhooks/deliveries/failed", admin(api.Webhooks.GetFailedDeliveries))
+	router.Post("/webhooks/deliveries/replay", admin(api.Webhooks.ReplayDelivery))
+	// API keys
+	router.Post("/apiKeys/add", admin(api.APIKeys.AddKey))
+	router.Read("/apiKeys/list", admin(api.APIKeys.GetKeys))
+	router.Post("/apiKeys/revoke", admin(api.APIKeys.RevokeKey))
+	// Event stream
+	router.Get("/events/stream", read(api.Events.Stream))
+	// REST-style resources
+	router.Get("/teams", read(api.Teams.ListTeams))
+	router.Get("/teams/{team_name}", read(api.Teams.GetTeam))
+	router.Get("/users", read(api.Users.ListUsers))
+	router.Get("/pull-requests", read(api.PRs.ListPullRequests))
+	router.Get("/pull-requests/search", read(api.PRs.SearchPullRequests))
+	router.Get("/users/{user_id}/reviews", read(api.Users.GetReview))
+	router.Post("/pull-requests/{pull_request_id}/merge", writePRs(api.PRs.Merge))
+}
@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"PR_reviewer_assign_service/internal/errors"
 	"PR_reviewer_assign_service/internal/models"
 	"PR_reviewer_assign_service/internal/service"
-	"encoding/json"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -25,29 +24,96 @@ func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var team models.Team
 
-	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &team); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateTeam(team); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidateTeam(team); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Create team
-	log.Printf("Creating team: %s", team.TeamName)
+	slog.InfoContext(r.Context(), "Creating team", "team_name", team.TeamName)
 	if code := h.service.CreateTeam(r.Context(), &team); code != "" {
 		writeError(w, code)
 		return
 	}
-	log.Printf("Team created: %s", team.TeamName)
+	slog.InfoContext(r.Context(), "Team created", "team_name", team.TeamName)
 
 	// Send response
 	writeJSON(w, http.StatusCreated, TeamResponse{Team: &team})
 }
 
+/*
+/team/addMember - TeamMemberAddQuery
+*/
+func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var member models.TeamMemberAddQuery
+
+	if err := decodeRequest(r, &member); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateTeamMemberAddQuery(member); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Add member
+	slog.InfoContext(r.Context(), "Adding team member", "team_name", member.TeamName, "user_id", member.UserID)
+	team, err := h.service.AddTeamMember(r.Context(), member.TeamName, models.TeamMember{
+		UserID:     member.UserID,
+		Username:   member.Username,
+		IsActive:   member.IsActive,
+		IsTeamLead: member.IsTeamLead,
+	})
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Team member added", "team_name", member.TeamName, "user_id", member.UserID)
+
+	// Send response
+	writeJSON(w, http.StatusOK, TeamResponse{Team: team})
+}
+
+/*
+/team/removeMember - TeamMemberRemoveQuery
+*/
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var member models.TeamMemberRemoveQuery
+
+	if err := decodeRequest(r, &member); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateTeamMemberRemoveQuery(member); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Remove member
+	slog.InfoContext(r.Context(), "Removing team member", "team_name", member.TeamName, "user_id", member.UserID)
+	user, err := h.service.RemoveTeamMember(r.Context(), member.TeamName, member.UserID)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Team member removed", "team_name", member.TeamName, "user_id", member.UserID)
+
+	// Send response
+	writeJSON(w, http.StatusOK, UserResponse{User: user})
+}
+
 /*
 	 /team/get - TeamNameQuery
 	}
@@ -56,40 +122,71 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var teamName models.TeamNameQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&teamName); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &teamName); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateTeamNameQuery(teamName); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidateTeamNameQuery(teamName); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Get team
-	log.Printf("Receiving team: %s", teamName.TeamName)
+	slog.DebugContext(r.Context(), "Receiving team", "team_name", teamName.TeamName)
 	team, err := h.service.GetTeam(r.Context(), teamName.TeamName)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("Team received: %s", team.TeamName)
+	slog.DebugContext(r.Context(), "Team received", "team_name", team.TeamName)
 
 	// Send response
 	writeJSON(w, http.StatusOK, team)
 }
 
+/*
+/team/list - TeamListQuery (optional)
+*/
+func (h *TeamHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty query means first page of all teams
+	var query models.TeamListQuery
+
+	if err := decodeRequest(r, &query); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	filter, violations := ValidateTeamListQuery(query)
+	if len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Get teams
+	slog.DebugContext(r.Context(), "Listing teams")
+	teams, cursor, err := h.service.ListTeams(r.Context(), filter)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+
+	// Send response
+	writeJSON(w, http.StatusOK, TeamsListResponse{Teams: teams, NextCursor: encodeCursor(cursor)})
+}
+
 // Additional functions
 func (h *TeamHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
 	// Get teams statistics
-	log.Printf("Receiving tems's statistics")
+	slog.DebugContext(r.Context(), "Receiving teams statistics")
 	statistics, err := h.service.GetTeamsStatistics(r.Context())
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("Team's statistics received")
+	slog.DebugContext(r.Context(), "Teams statistics received")
 
 	// Send response
 	writeJSON(w, http.StatusOK, statistics)
@@ -99,26 +196,87 @@ func (h *TeamHandler) GetTeamStatistics(w http.ResponseWriter, r *http.Request)
 	// Decode input
 	var teamName models.TeamNameQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&teamName); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &teamName); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateTeamNameQuery(teamName); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidateTeamNameQuery(teamName); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Get team statistics
-	log.Printf("Receiving team statistics: %s", teamName.TeamName)
+	slog.DebugContext(r.Context(), "Receiving team statistics", "team_name", teamName.TeamName)
 	team, err := h.service.GetTeamStatistics(r.Context(), teamName.TeamName)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("Team statistics received: %s", team.TeamName)
+	slog.DebugContext(r.Context(), "Team statistics received", "team_name", team.TeamName)
 
 	// Send response
 	writeJSON(w, http.StatusOK, team)
 }
+
+/*
+/team/getSettings - TeamNameQuery
+*/
+func (h *TeamHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var teamName models.TeamNameQuery
+
+	if err := decodeRequest(r, &teamName); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateTeamNameQuery(teamName); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Get settings
+	slog.DebugContext(r.Context(), "Receiving team settings", "team_name", teamName.TeamName)
+	settings, err := h.service.GetTeamSettings(r.Context(), teamName.TeamName)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Team settings received", "team_name", teamName.TeamName)
+
+	// Send response
+	writeJSON(w, http.StatusOK, settings)
+}
+
+/*
+/team/setSettings - TeamSettings
+*/
+func (h *TeamHandler) SetSettings(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var settings models.TeamSettings
+
+	if err := decodeRequest(r, &settings); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateTeamSettings(settings); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Update settings
+	slog.InfoContext(r.Context(), "Updating team settings", "team_name", settings.TeamName)
+	if err := h.service.SetTeamSettings(r.Context(), &settings); err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Team settings updated", "team_name", settings.TeamName)
+
+	// Send response
+	writeJSON(w, http.StatusOK, settings)
+}
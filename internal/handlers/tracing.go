@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("PR_reviewer_assign_service/internal/handlers")
+
+// Middleware that starts server span for every request, continuing W3C trace context of the caller
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if recorder.route != "" {
+			span.SetName(r.Method + " " + recorder.route)
+			span.SetAttributes(semconv.HTTPRoute(recorder.route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if recorder.errorCode != "" {
+			span.SetAttributes(semconv.ErrorTypeKey.String(string(recorder.errorCode)))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, string(recorder.errorCode))
+		}
+	})
+}
@@ -4,8 +4,8 @@ import (
 	"PR_reviewer_assign_service/internal/errors"
 	"PR_reviewer_assign_service/internal/models"
 	"PR_reviewer_assign_service/internal/service"
-	"encoding/json"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -25,77 +25,118 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var user models.UserActiveQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &user); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateUserActiveQuery(user); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidateUserActiveQuery(user); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
 	// Update user's isActive
-	log.Printf("Updating activness of user: %s", user.UserID)
+	slog.InfoContext(r.Context(), "Updating user activity", "user_id", user.UserID, "is_active", user.IsActive)
 	u, err := h.service.UserSetIsActive(r.Context(), user.UserID, user.IsActive)
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("User's activness is changed: %s", user.UserID)
+	slog.InfoContext(r.Context(), "User activity updated", "user_id", user.UserID)
 
 	// Send response
 	writeJSON(w, http.StatusOK, UserResponse{User: u})
 }
 
 /*
-/users/getReview - UserIDQuery
+/users/getReview - UserReviewQuery
 */
 func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 	// Decode input
-	var user models.UserIDQuery
+	var user models.UserReviewQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &user); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateUserIDQuery(user); err != "" {
-		writeErrorMessage(w, err, msg)
+	filter, violations := ValidateUserReviewQuery(user)
+	if len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
-	// Get users PR's
-	log.Printf("Receiving user's PRs: %s", user.UserID)
-	prs, err := h.service.GetPRs(r.Context(), user.UserID)
+	// Get users PR's, legacy path without page parameters gets all of them like before pagination
+	slog.DebugContext(r.Context(), "Receiving user PRs", "user_id", user.UserID)
+	var prs []models.PullRequestShort
+	var cursor *models.Cursor
+	var err errors.ErrorCode
+	if PathParams(r) == nil && user.Limit == 0 && user.Cursor == "" {
+		filter.Limit = MaxPageLimit
+		prs, err = h.service.GetAllPRs(r.Context(), user.UserID, filter)
+	} else {
+		prs, cursor, err = h.service.GetPRs(r.Context(), user.UserID, filter)
+	}
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("PRs of user: %s, received", user.UserID)
+	slog.DebugContext(r.Context(), "User PRs received", "user_id", user.UserID)
 
 	// Send response
 	writeJSON(w, http.StatusOK, GetReviewResponse{
 		UserID:       user.UserID,
 		PullRequests: prs,
+		NextCursor:   encodeCursor(cursor),
 	})
 }
 
+/*
+/users/list - UserListQuery (optional)
+*/
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty query means first page of all users
+	var query models.UserListQuery
+
+	if err := decodeRequest(r, &query); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	filter, violations := ValidateUserListQuery(query)
+	if len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Get users
+	slog.DebugContext(r.Context(), "Listing users", "team_name", filter.TeamName)
+	users, cursor, err := h.service.ListUsers(r.Context(), filter)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+
+	// Send response
+	writeJSON(w, http.StatusOK, UsersListResponse{Users: users, NextCursor: encodeCursor(cursor)})
+}
+
 // Additional funcitons
 /*
 /users/statistics - get method
 */
 func (h *UserHandler) GetUserStatistics(w http.ResponseWriter, r *http.Request) {
 	// Get users statistics
-	log.Printf("Receiving user's statistics")
+	slog.DebugContext(r.Context(), "Receiving user statistics")
 	statistics, err := h.service.GetUsersStatistics(r.Context())
 	if err != "" {
 		writeError(w, err)
 		return
 	}
-	log.Printf("User's statistics Received")
+	slog.DebugContext(r.Context(), "User statistics received")
 
 	// Send response
 	writeJSON(w, http.StatusOK, statistics)
@@ -108,14 +149,14 @@ func (h *UserHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
 	// Decode input
 	var user models.UserIDQuery
 
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		writeErrorMessage(w, errors.ErrorCodeInvalidInput, "Invalid JSON format")
+	if err := decodeRequest(r, &user); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	// Validate input
-	if err, msg := ValidateUserIDQuery(user); err != "" {
-		writeErrorMessage(w, err, msg)
+	if violations := ValidateUserIDQuery(user); len(violations) > 0 {
+		writeViolations(w, violations)
 		return
 	}
 
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Router of user and team reads, u2 and u3 review every of count prs of u1
+func newReviewRouter(t *testing.T, count int) *Router {
+	t.Helper()
+	ctx := context.Background()
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	svc := service.NewService(storagetest.NewMemory(clk), nil)
+	svc.SetClock(clk)
+	team := models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Carol", IsActive: true},
+	}}
+	if err := svc.CreateTeam(ctx, &team); err != "" {
+		t.Fatal(err)
+	}
+	for i := range count {
+		clk.Advance(time.Minute)
+		id := fmt.Sprintf("pr-%03d", i)
+		if _, err := svc.CreatePullRequest(ctx, models.PullRequestCreateQuery{PullRequestID: id, PullRequestName: id, AuthorID: "u1"}); err != "" {
+			t.Fatal(err)
+		}
+	}
+
+	router := NewRouter()
+	users, teams := NewUserHandler(svc), NewTeamHandler(svc)
+	router.Read("/users/getReview", users.GetReview)
+	router.Get("/users/{user_id}/reviews", users.GetReview)
+	router.Read("/team/statistics", teams.GetTeamStatistics)
+	return router
+}
+
+func getJSON(t *testing.T, handler http.Handler, target string, response any) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status %d %s", target, w.Code, w.Body)
+	}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetReviewLegacyPathReturnsAllPRs(t *testing.T) {
+	router := newReviewRouter(t, DefaultPageLimit+MaxPageLimit+1)
+
+	// Old clients don't know about pages and get everything at once
+	var all GetReviewResponse
+	getJSON(t, router, "/users/getReview?user_id=u2", &all)
+	if len(all.PullRequests) != DefaultPageLimit+MaxPageLimit+1 || all.NextCursor != "" {
+		t.Errorf("legacy path: %d prs, cursor %q", len(all.PullRequests), all.NextCursor)
+	}
+
+	// Page is returned if it's asked for
+	var page GetReviewResponse
+	getJSON(t, router, "/users/getReview?user_id=u2&limit=10", &page)
+	if len(page.PullRequests) != 10 || page.NextCursor == "" {
+		t.Errorf("legacy path with limit: %d prs, cursor %q", len(page.PullRequests), page.NextCursor)
+	}
+	getJSON(t, router, "/users/getReview?user_id=u2&cursor="+page.NextCursor, &page)
+	if len(page.PullRequests) != DefaultPageLimit || page.PullRequests[0].PullRequestID != all.PullRequests[10].PullRequestID {
+		t.Errorf("legacy path with cursor: %d prs from %+v", len(page.PullRequests), page.PullRequests[0])
+	}
+
+	// REST path always pages
+	getJSON(t, router, "/users/u2/reviews", &page)
+	if len(page.PullRequests) != DefaultPageLimit || page.NextCursor == "" {
+		t.Errorf("REST path: %d prs, cursor %q", len(page.PullRequests), page.NextCursor)
+	}
+}
+
+func TestTeamStatisticsKeepsLatestPRIDs(t *testing.T) {
+	router := newReviewRouter(t, models.TeamStatsPRLimit+5)
+
+	var statistics models.TeamStats
+	getJSON(t, router, "/team/statistics?team_name=backend", &statistics)
+	if statistics.PullRequestsTotal != models.TeamStatsPRLimit+5 || len(statistics.PullRequests) != models.TeamStatsPRLimit {
+		t.Fatalf("statistics: %d prs, %d ids", statistics.PullRequestsTotal, len(statistics.PullRequests))
+	}
+	if first := fmt.Sprintf("pr-%03d", models.TeamStatsPRLimit+4); statistics.PullRequests[0] != first {
+		t.Errorf("first id %s, want the latest %s", statistics.PullRequests[0], first)
+	}
+}
@@ -1,33 +1,161 @@
 package handlers
 
 import (
-	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/events"
 	"PR_reviewer_assign_service/internal/models"
+	"fmt"
+	"net/url"
 	"regexp"
+	"unicode/utf8"
 )
 
-// Regular Expressions to check input parameters
-var (
-	TeamNameRegExp *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9_\.\-\s]*$`)
-	UserIDRegExp   *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9\-]*$`)
-	UserNameRegExp *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z]*$`)
-	PRIDRegExp     *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9\-]*$`)
-	PRNameRegExp   *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9_\.\-\s]*$`)
+// Rule for string input field, lengths are counted in characters, not bytes
+type FieldRule struct {
+	Pattern   string
+	MinLength int
+	MaxLength int
+}
+
+// Rules for string input fields
+type ValidationRules struct {
+	TeamName FieldRule
+	UserID   FieldRule
+	Username FieldRule
+	PRID     FieldRule
+	PRName   FieldRule
+}
+
+// Letters of any alphabet are allowed in names, ids stay ASCII
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		TeamName: FieldRule{Pattern: `^[\p{L}\p{M}\p{N}_.\-\s]*$`, MinLength: 1, MaxLength: 100},
+		UserID:   FieldRule{Pattern: `^[a-zA-Z0-9\-]*$`, MinLength: 1, MaxLength: 50},
+		Username: FieldRule{Pattern: `^[\p{L}\p{M}]*$`, MinLength: 2, MaxLength: 50},
+		PRID:     FieldRule{Pattern: `^[a-zA-Z0-9\-]*$`, MinLength: 1, MaxLength: 50},
+		PRName:   FieldRule{Pattern: `^[\p{L}\p{M}\p{N}_.\-\s]*$`, MinLength: 1, MaxLength: 150},
+	}
+}
+
+// Rule with compiled pattern
+type fieldRule struct {
+	FieldRule
+	regexp *regexp.Regexp
+}
+
+func compileRule(rule FieldRule) (fieldRule, error) {
+	reg, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return fieldRule{}, err
+	}
+	return fieldRule{FieldRule: rule, regexp: reg}, nil
+}
+
+type compiledRules struct {
+	teamName fieldRule
+	userID   fieldRule
+	username fieldRule
+	prID     fieldRule
+	prName   fieldRule
+}
+
+func compileRules(r ValidationRules) (compiledRules, error) {
+	var result compiledRules
+	var err error
+	for _, rule := range []struct {
+		name string
+		src  FieldRule
+		dst  *fieldRule
+	}{
+		{"team_name", r.TeamName, &result.teamName},
+		{"user_id", r.UserID, &result.userID},
+		{"username", r.Username, &result.username},
+		{"pr_id", r.PRID, &result.prID},
+		{"pr_name", r.PRName, &result.prName},
+	} {
+		if *rule.dst, err = compileRule(rule.src); err != nil {
+			return compiledRules{}, fmt.Errorf("%s: %v", rule.name, err)
+		}
+	}
+	return result, nil
+}
+
+var rules = func() compiledRules {
+	r, err := compileRules(DefaultValidationRules())
+	if err != nil {
+		panic(err)
+	}
+	return r
+}()
+
+// API key names aren't configurable, limit is width of api_keys.name
+var apiKeyNameRule = func() fieldRule {
+	r, err := compileRule(FieldRule{Pattern: `^[\p{L}\p{M}\p{N}_.\-\s]*$`, MinLength: 1, MaxLength: 100})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}()
+
+// Change rules of input fields, called before serving requests
+func SetValidationRules(r ValidationRules) error {
+	compiled, err := compileRules(r)
+	if err != nil {
+		return err
+	}
+	rules = compiled
+	return nil
+}
+
+// Rules reported in violations
+const (
+	ruleRequired  = "required"
+	ruleMinLength = "min_length"
+	ruleMaxLength = "max_length"
+	rulePattern   = "pattern"
+	ruleFormat    = "format"
+	ruleRange     = "range"
+	ruleMinItems  = "min_items"
+	ruleUnique    = "unique"
+	ruleEnum      = "enum"
+	// Found while decoding
+	ruleType    = "type"
+	ruleUnknown = "unknown"
 )
 
-// validation for string field
-func validateStringField(field string, reg *regexp.Regexp, min_len, max_len int) (errors.ErrorCode, string) {
-	if len(field) < min_len {
-		return errors.ErrorCodeInvalidInput, " is too small"
+// Violations of input fields collected by validators, empty if input is valid
+type violations []models.Violation
+
+func (v *violations) add(field, rule, message string) {
+	*v = append(*v, models.Violation{Field: field, Rule: rule, Message: message})
+}
+
+// validation for string field, name is used in messages
+func (v *violations) checkString(field, name, value string, rule fieldRule) {
+	length := utf8.RuneCountInString(value)
+	if length == 0 && rule.MinLength > 0 {
+		// Nothing else to report about missing value
+		v.add(field, ruleRequired, name+" is required")
+		return
 	}
-	if len(field) > max_len {
-		return errors.ErrorCodeInvalidInput, " is too long"
+	if length < rule.MinLength {
+		v.add(field, ruleMinLength, fmt.Sprintf("%s %q is too short, minimum is %d characters", name, value, rule.MinLength))
 	}
+	if length > rule.MaxLength {
+		v.add(field, ruleMaxLength, fmt.Sprintf("%s %q is too long, maximum is %d characters", name, value, rule.MaxLength))
+	}
+	if !rule.regexp.MatchString(value) {
+		v.add(field, rulePattern, fmt.Sprintf("%s %q is invalid", name, value))
+	}
+}
 
-	if !reg.MatchString(field) {
-		return errors.ErrorCodeInvalidInput, " is invalid"
+// Path of field of list item: members[2].user_id
+func itemPath(list string, index int, field string) string {
+	path := fmt.Sprintf("%s[%d]", list, index)
+	if field != "" {
+		path += "." + field
 	}
-	return "", ""
+	return path
 }
 
 /*
@@ -40,31 +168,27 @@ func validateStringField(field string, reg *regexp.Regexp, min_len, max_len int)
 			}
 	}
 */
-func ValidateTeam(team models.Team) (errors.ErrorCode, string) {
+func ValidateTeam(team models.Team) []models.Violation {
+	var v violations
 	// Check teamName
-	if err, msg := validateStringField(team.TeamName, TeamNameRegExp, 1, 100); err != "" {
-		return err, "Team name " + team.TeamName + msg
-	}
+	v.checkString("team_name", "Team name", team.TeamName, rules.teamName)
 	// Check members
 	if len(team.Members) == 0 {
-		return errors.ErrorCodeInvalidInput, "team must have at least one member"
+		v.add("members", ruleMinItems, "team must have at least one member")
 	}
 	userIds := make(map[string]bool)
-	for _, member := range team.Members {
+	for i, member := range team.Members {
 		// Check member id
-		if err, msg := validateStringField(member.UserID, UserIDRegExp, 1, 50); err != "" {
-			return err, "Member ID " + member.UserID + msg
-		}
+		v.checkString(itemPath("members", i, "user_id"), "Member ID", member.UserID, rules.userID)
 		// Check member name
-		if err, msg := validateStringField(member.Username, UserNameRegExp, 2, 50); err != "" {
-			return err, "Member name " + member.Username + msg
-		}
-		// Check duplicates
-		if userIds[member.UserID] {
-			return errors.ErrorCodeInvalidInput, "Duplicate user ID: " + member.UserID
+		v.checkString(itemPath("members", i, "username"), "Member name", member.Username, rules.username)
+		// Check duplicates, missing ids are already reported
+		if member.UserID != "" && userIds[member.UserID] {
+			v.add(itemPath("members", i, "user_id"), ruleUnique, "Duplicate user ID: "+member.UserID)
 		}
+		userIds[member.UserID] = true
 	}
-	return "", ""
+	return v
 }
 
 /*
@@ -72,12 +196,41 @@ func ValidateTeam(team models.Team) (errors.ErrorCode, string) {
 		TeamName : string
 	}
 */
-func ValidateTeamNameQuery(name models.TeamNameQuery) (errors.ErrorCode, string) {
+func ValidateTeamNameQuery(name models.TeamNameQuery) []models.Violation {
+	var v violations
 	// Check teamName
-	if err, msg := validateStringField(name.TeamName, TeamNameRegExp, 1, 100); err != "" {
-		return err, "Team name " + name.TeamName + msg
+	v.checkString("team_name", "Team name", name.TeamName, rules.teamName)
+	return v
+}
+
+/*
+	TeamMemberAddQuery {
+		TeamName : string
+		UserID : string
+		Username : string
+		IsActive : boolean
+		IsTeamLead : boolean
+	}
+*/
+func ValidateTeamMemberAddQuery(member models.TeamMemberAddQuery) []models.Violation {
+	var v violations
+	v.checkString("team_name", "Team name", member.TeamName, rules.teamName)
+	v.checkString("user_id", "User ID", member.UserID, rules.userID)
+	v.checkString("username", "Member name", member.Username, rules.username)
+	return v
+}
+
+/*
+	TeamMemberRemoveQuery {
+		TeamName : string
+		UserID : string
 	}
-	return "", ""
+*/
+func ValidateTeamMemberRemoveQuery(member models.TeamMemberRemoveQuery) []models.Violation {
+	var v violations
+	v.checkString("team_name", "Team name", member.TeamName, rules.teamName)
+	v.checkString("user_id", "User ID", member.UserID, rules.userID)
+	return v
 }
 
 /*
@@ -86,12 +239,11 @@ func ValidateTeamNameQuery(name models.TeamNameQuery) (errors.ErrorCode, string)
 		IsActive : boolean
 	}
 */
-func ValidateUserActiveQuery(user models.UserActiveQuery) (errors.ErrorCode, string) {
+func ValidateUserActiveQuery(user models.UserActiveQuery) []models.Violation {
+	var v violations
 	// Check user id
-	if err, msg := validateStringField(user.UserID, UserIDRegExp, 1, 50); err != "" {
-		return err, "User ID " + user.UserID + msg
-	}
-	return "", ""
+	v.checkString("user_id", "User ID", user.UserID, rules.userID)
+	return v
 }
 
 /*
@@ -101,20 +253,15 @@ func ValidateUserActiveQuery(user models.UserActiveQuery) (errors.ErrorCode, str
 		AuthorID : string
 	}
 */
-func ValidatePullRequestCreateQuery(pr models.PullRequestCreateQuery) (errors.ErrorCode, string) {
+func ValidatePullRequestCreateQuery(pr models.PullRequestCreateQuery) []models.Violation {
+	var v violations
 	// Check pr id
-	if err, msg := validateStringField(pr.PullRequestID, PRIDRegExp, 1, 50); err != "" {
-		return err, "Pull Request ID " + pr.PullRequestID + msg
-	}
+	v.checkString("pull_request_id", "Pull Request ID", pr.PullRequestID, rules.prID)
 	// Check pr name
-	if err, msg := validateStringField(pr.PullRequestName, PRNameRegExp, 1, 150); err != "" {
-		return err, "Pull Request name " + pr.PullRequestName + msg
-	}
+	v.checkString("pull_request_name", "Pull Request name", pr.PullRequestName, rules.prName)
 	// Check author id
-	if err, msg := validateStringField(pr.AuthorID, UserIDRegExp, 1, 50); err != "" {
-		return err, "Author ID " + pr.AuthorID + msg
-	}
-	return "", ""
+	v.checkString("author_id", "Author ID", pr.AuthorID, rules.userID)
+	return v
 }
 
 /*
@@ -122,12 +269,11 @@ func ValidatePullRequestCreateQuery(pr models.PullRequestCreateQuery) (errors.Er
 		PullRequestID : string
 	}
 */
-func ValidatePullRequestMergeQuery(pr models.PullRequestMergeQuery) (errors.ErrorCode, string) {
+func ValidatePullRequestMergeQuery(pr models.PullRequestMergeQuery) []models.Violation {
+	var v violations
 	// Check pr id
-	if err, msg := validateStringField(pr.PullRequestID, PRIDRegExp, 1, 50); err != "" {
-		return err, "Pull Request ID" + pr.PullRequestID + msg
-	}
-	return "", ""
+	v.checkString("pull_request_id", "Pull Request ID", pr.PullRequestID, rules.prID)
+	return v
 }
 
 /*
@@ -136,29 +282,326 @@ func ValidatePullRequestMergeQuery(pr models.PullRequestMergeQuery) (errors.Erro
 		OldUserID : string
 	}
 */
-func ValidatePullRequestReassignQuery(pr models.PullRequestReassignQuery) (errors.ErrorCode, string) {
+func ValidatePullRequestReassignQuery(pr models.PullRequestReassignQuery) []models.Violation {
+	var v violations
 	// Check pr id
-	if err, msg := validateStringField(pr.PullRequestID, PRIDRegExp, 1, 50); err != "" {
-		return err, "Pull Request ID " + pr.PullRequestID + msg
+	v.checkString("pull_request_id", "Pull Request ID", pr.PullRequestID, rules.prID)
+	// Check user id
+	v.checkString("old_user_id", "Old user's ID", pr.OldUserID, rules.userID)
+	return v
+}
+
+/*
+	UserIDQuery {
+		UserID : string
 	}
+*/
+func ValidateUserIDQuery(user models.UserIDQuery) []models.Violation {
+	var v violations
 	// Check user id
-	if err, msg := validateStringField(pr.OldUserID, UserIDRegExp, 1, 50); err != "" {
-		return err, "Old user's ID" + pr.OldUserID + msg
+	v.checkString("user_id", "User ID", user.UserID, rules.userID)
+	return v
+}
+
+/*
+	WebhookCreateQuery {
+		URL : string
+		Events : []string
+		Secret : string
+	}
+*/
+func ValidateWebhookCreateQuery(webhook models.WebhookCreateQuery) []models.Violation {
+	var v violations
+	// Check url
+	if len(webhook.URL) == 0 {
+		v.add("url", ruleRequired, "Webhook URL is required")
+	} else if len(webhook.URL) > 2048 {
+		v.add("url", ruleMaxLength, "Webhook URL must be at most 2048 characters long")
+	} else if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("url", ruleFormat, "Webhook URL "+webhook.URL+" is invalid")
+	}
+	// Check events
+	if len(webhook.Events) == 0 {
+		v.add("events", ruleMinItems, "webhook must subscribe to at least one event")
+	}
+	for i, event := range webhook.Events {
+		if !events.IsKnown(events.Type(event)) {
+			v.add(itemPath("events", i, ""), ruleEnum, "Unknown event: "+event)
+		}
+	}
+	// Check secret
+	if len(webhook.Secret) > 128 {
+		v.add("secret", ruleMaxLength, "Webhook secret is too long")
 	}
+	return v
+}
 
-	return "", ""
+/*
+	WebhookIDQuery {
+		WebhookID : int
+	}
+*/
+func ValidateWebhookIDQuery(webhook models.WebhookIDQuery) []models.Violation {
+	var v violations
+	// Check webhook id
+	if webhook.WebhookID <= 0 {
+		v.add("webhook_id", ruleRange, "Webhook ID must be positive")
+	}
+	return v
 }
 
 /*
-	UserIDQuery {
-		UserID : string
+	DeliveryIDQuery {
+		DeliveryID : int
+	}
+*/
+func ValidateDeliveryIDQuery(delivery models.DeliveryIDQuery) []models.Violation {
+	var v violations
+	// Check delivery id
+	if delivery.DeliveryID <= 0 {
+		v.add("delivery_id", ruleRange, "Delivery ID must be positive")
+	}
+	return v
+}
+
+/*
+	EventStreamQuery {
+		TeamName : string (optional)
+		UserID : string (optional)
 	}
 */
-func ValidateUserIDQuery(user models.UserIDQuery) (errors.ErrorCode, string) {
+func ValidateEventStreamQuery(filter models.EventStreamQuery) []models.Violation {
+	var v violations
+	// Check team name
+	if filter.TeamName != "" {
+		v.checkString("team_name", "Team name", filter.TeamName, rules.teamName)
+	}
 	// Check user id
-	if err, msg := validateStringField(user.UserID, UserIDRegExp, 1, 50); err != "" {
-		return err, "User ID " + user.UserID + msg
+	if filter.UserID != "" {
+		v.checkString("user_id", "User ID", filter.UserID, rules.userID)
+	}
+	return v
+}
+
+/*
+	TeamSettings {
+		TeamName : string
+		ReviewSLAHours : int
+		AutoEscalate : boolean
+		EscalateAfterHours : int (optional)
+		MaxEscalations : int (optional)
+	}
+*/
+func ValidateTeamSettings(settings models.TeamSettings) []models.Violation {
+	var v violations
+	// Check teamName
+	v.checkString("team_name", "Team name", settings.TeamName, rules.teamName)
+	// Check SLA, at most a year
+	if settings.ReviewSLAHours < 1 || settings.ReviewSLAHours > 8760 {
+		v.add("review_sla_hours", ruleRange, "Review SLA must be from 1 to 8760 hours")
+	}
+	// Check escalation, 0 means default
+	if settings.EscalateAfterHours < 0 || settings.EscalateAfterHours > 8760 {
+		v.add("escalate_after_hours", ruleRange, "Escalation time must be from 1 to 8760 hours, or 0 for default")
+	}
+	if settings.MaxEscalations < 0 || settings.MaxEscalations > 100 {
+		v.add("max_escalations", ruleRange, "Max escalations must be from 1 to 100, or 0 for default")
+	}
+	return v
+}
+
+/*
+	APIKeyCreateQuery {
+		Name : string
+		Scopes : []string
+	}
+*/
+func ValidateAPIKeyCreateQuery(key models.APIKeyCreateQuery) []models.Violation {
+	var v violations
+	// Check name
+	v.checkString("name", "API key name", key.Name, apiKeyNameRule)
+	// Check scopes
+	if len(key.Scopes) == 0 {
+		v.add("scopes", ruleMinItems, "API key must have at least one scope")
+	}
+	for i, scope := range key.Scopes {
+		if !auth.IsKnownScope(auth.Scope(scope)) {
+			v.add(itemPath("scopes", i, ""), ruleEnum, "Unknown scope: "+scope)
+		}
+	}
+	return v
+}
+
+/*
+	APIKeyIDQuery {
+		KeyID : int
+	}
+*/
+func ValidateAPIKeyIDQuery(key models.APIKeyIDQuery) []models.Violation {
+	var v violations
+	// Check key id
+	if key.KeyID <= 0 {
+		v.add("key_id", ruleRange, "API key ID must be positive")
+	}
+	return v
+}
+
+/*
+	TeamListQuery {
+		Limit : int (optional)
+		Cursor : string (optional)
+		CreatedFrom : string (optional)
+		CreatedTo : string (optional)
+	}
+*/
+func ValidateTeamListQuery(query models.TeamListQuery) (models.ListFilter, []models.Violation) {
+	var v violations
+	// Check page and dates
+	filter := v.checkPage(query.Limit, query.Cursor, query.CreatedFrom, query.CreatedTo)
+	return filter, v
+}
+
+/*
+	UserListQuery {
+		Limit : int (optional)
+		Cursor : string (optional)
+		TeamName : string (optional)
+		CreatedFrom : string (optional)
+		CreatedTo : string (optional)
+	}
+*/
+func ValidateUserListQuery(query models.UserListQuery) (models.ListFilter, []models.Violation) {
+	var v violations
+	// Check page and dates
+	filter := v.checkPage(query.Limit, query.Cursor, query.CreatedFrom, query.CreatedTo)
+	// Check team name
+	if query.TeamName != "" {
+		v.checkString("team_name", "Team name", query.TeamName, rules.teamName)
+		filter.TeamName = query.TeamName
+	}
+	return filter, v
+}
+
+/*
+	PullRequestListQuery {
+		Limit : int (optional)
+		Cursor : string (optional)
+		Status : string (optional)
+		AuthorID : string (optional)
+		TeamName : string (optional)
+		CreatedFrom : string (optional)
+		CreatedTo : string (optional)
+	}
+*/
+func ValidatePullRequestListQuery(query models.PullRequestListQuery) (models.ListFilter, []models.Violation) {
+	var v violations
+	// Check page and dates
+	filter := v.checkPage(query.Limit, query.Cursor, query.CreatedFrom, query.CreatedTo)
+	// Check status
+	v.checkStatus(query.Status)
+	filter.Status = query.Status
+	// Check author id
+	if query.AuthorID != "" {
+		v.checkString("author_id", "Author ID", query.AuthorID, rules.userID)
+		filter.AuthorID = query.AuthorID
+	}
+	// Check team name
+	if query.TeamName != "" {
+		v.checkString("team_name", "Team name", query.TeamName, rules.teamName)
+		filter.TeamName = query.TeamName
+	}
+	return filter, v
+}
+
+/*
+	PullRequestSearchQuery {
+		Name : string (optional)
+		AuthorID : string (optional)
+		ReviewerID : string (optional)
+		TeamName : string (optional)
+		Status : string (optional)
+		CreatedFrom : string (optional)
+		CreatedTo : string (optional)
+		MergedFrom : string (optional)
+		MergedTo : string (optional)
+		Sort : string (optional)
+		Order : string (optional)
+		Limit : int (optional)
+		Cursor : string (optional)
+	}
+*/
+func ValidatePullRequestSearchQuery(query models.PullRequestSearchQuery) (models.PRSearchFilter, []models.Violation) {
+	var v violations
+	filter := models.PRSearchFilter{Limit: v.checkLimit(query.Limit)}
+	// Check name substring
+	if utf8.RuneCountInString(query.Name) > rules.prName.MaxLength {
+		v.add("name", ruleMaxLength, fmt.Sprintf("Name is too long, maximum is %d characters", rules.prName.MaxLength))
+	}
+	filter.Name = query.Name
+	// Check author, reviewer and team
+	if query.AuthorID != "" {
+		v.checkString("author_id", "Author ID", query.AuthorID, rules.userID)
+		filter.AuthorID = query.AuthorID
+	}
+	if query.ReviewerID != "" {
+		v.checkString("reviewer_id", "Reviewer ID", query.ReviewerID, rules.userID)
+		filter.ReviewerID = query.ReviewerID
+	}
+	if query.TeamName != "" {
+		v.checkString("team_name", "Team name", query.TeamName, rules.teamName)
+		filter.TeamName = query.TeamName
+	}
+	// Check status and dates
+	v.checkStatus(query.Status)
+	filter.Status = query.Status
+	filter.CreatedFrom, filter.CreatedTo = v.checkTimeRange("created_from", query.CreatedFrom, "created_to", query.CreatedTo)
+	filter.MergedFrom, filter.MergedTo = v.checkTimeRange("merged_from", query.MergedFrom, "merged_to", query.MergedTo)
+	// Check sort, times are newest first and names alphabetical by default
+	filter.Sort = query.Sort
+	switch query.Sort {
+	case "":
+		filter.Sort = "created_at"
+	case "created_at", "merged_at", "name":
+	default:
+		v.add("sort", ruleEnum, "Sort must be created_at, merged_at or name")
 	}
+	switch query.Order {
+	case "":
+		filter.Descending = filter.Sort != "name"
+	case "asc", "desc":
+		filter.Descending = query.Order == "desc"
+	default:
+		v.add("order", ruleEnum, "Order must be asc or desc")
+	}
+	// Check cursor, it must come from the search with the same order
+	if query.Cursor != "" {
+		after, err := decodeSearchCursor(query.Cursor)
+		if err != nil || after.Sort != filter.Sort || after.Descending != filter.Descending {
+			v.add("cursor", ruleFormat, "Cursor is invalid, use next_cursor of the previous page with the same sort and order")
+		}
+		filter.After = after
+	}
+	return filter, v
+}
 
-	return "", ""
+/*
+	UserReviewQuery {
+		UserID : string
+		Limit : int (optional)
+		Cursor : string (optional)
+		Status : string (optional)
+		CreatedFrom : string (optional)
+		CreatedTo : string (optional)
+	}
+*/
+func ValidateUserReviewQuery(query models.UserReviewQuery) (models.ListFilter, []models.Violation) {
+	var v violations
+	// Check user id
+	v.checkString("user_id", "User ID", query.UserID, rules.userID)
+	// Check page and dates
+	filter := v.checkPage(query.Limit, query.Cursor, query.CreatedFrom, query.CreatedTo)
+	// Check status
+	v.checkStatus(query.Status)
+	filter.Status = query.Status
+	return filter, v
 }
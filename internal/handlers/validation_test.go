@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Violations as "field:rule" in reported order
+func violationRules(violations []models.Violation) []string {
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Field+":"+v.Rule)
+	}
+	return got
+}
+
+func member(id, name string) models.TeamMember {
+	return models.TeamMember{UserID: id, Username: name, IsActive: true}
+}
+
+func TestValidateTeam(t *testing.T) {
+	tests := []struct {
+		name string
+		team models.Team
+		want []string
+	}{
+		{"valid", models.Team{TeamName: "backend", Members: []models.TeamMember{member("u1", "Alice")}}, nil},
+		{"no members", models.Team{TeamName: "backend"}, []string{"members:min_items"}},
+		{"every invalid field", models.Team{TeamName: "back/end", Members: []models.TeamMember{
+			member("", "Alice"),
+			member("u 2", "B"),
+			member("u3", "Carol1"),
+		}}, []string{
+			"team_name:pattern",
+			"members[0].user_id:required",
+			"members[1].user_id:pattern",
+			"members[1].username:min_length",
+			"members[2].username:pattern",
+		}},
+		{"duplicate user id", models.Team{TeamName: "backend", Members: []models.TeamMember{
+			member("u1", "Alice"),
+			member("u2", "Bob"),
+			member("u1", "Carol"),
+			member("u2", "Dave"),
+		}}, []string{"members[2].user_id:unique", "members[3].user_id:unique"}},
+		// Missing ids are reported once as required, not as duplicates
+		{"missing ids", models.Team{TeamName: "backend", Members: []models.TeamMember{
+			member("", "Alice"),
+			member("", "Bob"),
+		}}, []string{"members[0].user_id:required", "members[1].user_id:required"}},
+	}
+	for _, test := range tests {
+		if got := violationRules(ValidateTeam(test.team)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: violations %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidationCountsCharacters(t *testing.T) {
+	limits := DefaultValidationRules()
+	tests := []struct {
+		name string
+		team string
+		user string
+		want []string
+	}{
+		// Two bytes per letter, limits are still in characters
+		{"cyrillic at limits", strings.Repeat("к", limits.TeamName.MaxLength), strings.Repeat("я", limits.Username.MaxLength), nil},
+		{"cyrillic over limits", strings.Repeat("к", limits.TeamName.MaxLength+1), strings.Repeat("я", limits.Username.MaxLength+1),
+			[]string{"team_name:max_length", "members[0].username:max_length"}},
+		{"short name of two-byte letter", "Команда", "Я", []string{"members[0].username:min_length"}},
+		{"combining marks", "Équipe", "Zoë", nil},
+		{"other alphabets", "チーム", "李雷", nil},
+	}
+	for _, test := range tests {
+		team := models.Team{TeamName: test.team, Members: []models.TeamMember{member("u1", test.user)}}
+		if got := violationRules(ValidateTeam(team)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: violations %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidatePullRequestCreateQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query models.PullRequestCreateQuery
+		want  []string
+	}{
+		{"valid", models.PullRequestCreateQuery{PullRequestID: "pr-1", PullRequestName: "Исправить вход", AuthorID: "u1"}, nil},
+		{"empty", models.PullRequestCreateQuery{}, []string{"pull_request_id:required", "pull_request_name:required", "author_id:required"}},
+		{"several rules of one field", models.PullRequestCreateQuery{PullRequestID: strings.Repeat("п", 51), PullRequestName: "Fix", AuthorID: "u1"},
+			[]string{"pull_request_id:max_length", "pull_request_id:pattern"}},
+	}
+	for _, test := range tests {
+		if got := violationRules(ValidatePullRequestCreateQuery(test.query)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: violations %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWriteViolationsReportsAll(t *testing.T) {
+	violations := ValidatePullRequestCreateQuery(models.PullRequestCreateQuery{PullRequestName: "Fix"})
+	w := httptest.NewRecorder()
+	writeViolations(w, violations)
+
+	var response ErrorRespone
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || response.Error.Code != "INVALID_INPUT" {
+		t.Errorf("response %d %s, want 400 INVALID_INPUT", w.Code, response.Error.Code)
+	}
+	if got, want := violationRules(response.Error.Details), []string{"pull_request_id:required", "author_id:required"}; !slices.Equal(got, want) {
+		t.Errorf("details %v, want %v", got, want)
+	}
+	if want := "Pull Request ID is required; Author ID is required"; response.Error.Message != want {
+		t.Errorf("message %q, want %q", response.Error.Message, want)
+	}
+}
+
+func TestSetValidationRules(t *testing.T) {
+	defaults := DefaultValidationRules()
+	t.Cleanup(func() { SetValidationRules(defaults) })
+
+	custom := defaults
+	custom.TeamName = FieldRule{Pattern: `^[a-z]*$`, MinLength: 3, MaxLength: 5}
+	if err := SetValidationRules(custom); err != nil {
+		t.Fatal(err)
+	}
+	team := models.Team{TeamName: "Backend", Members: []models.TeamMember{member("u1", "Alice")}}
+	if got, want := violationRules(ValidateTeam(team)), []string{"team_name:max_length", "team_name:pattern"}; !slices.Equal(got, want) {
+		t.Errorf("violations %v, want %v", got, want)
+	}
+
+	// Invalid pattern keeps the rules in use
+	custom.UserID.Pattern = `^[a-z`
+	if err := SetValidationRules(custom); err == nil || !strings.HasPrefix(err.Error(), "user_id:") {
+		t.Errorf("invalid pattern: %v", err)
+	}
+	if got := violationRules(ValidateTeam(models.Team{TeamName: "back", Members: team.Members})); got != nil {
+		t.Errorf("violations %v after failed change", got)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Handler for webhook requests
+type WebhookHandler struct {
+	service *service.Service
+}
+
+func NewWebhookHandler(service *service.Service) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+/*
+/webhooks/add - WebhookCreateQuery
+*/
+func (h *WebhookHandler) AddWebhook(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var webhook models.WebhookCreateQuery
+
+	if err := decodeRequest(r, &webhook); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateWebhookCreateQuery(webhook); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Create webhook
+	slog.InfoContext(r.Context(), "Creating webhook", "url", webhook.URL)
+	created, err := h.service.CreateWebhook(r.Context(), webhook)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Webhook created", "webhook_id", created.WebhookID)
+
+	// Send response
+	writeJSON(w, http.StatusCreated, WebhookResponse{Webhook: created})
+}
+
+/*
+/webhooks/list - get method
+*/
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	// Get webhooks
+	slog.DebugContext(r.Context(), "Receiving webhooks")
+	webhooks, err := h.service.GetWebhooks(r.Context())
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Webhooks received")
+
+	// Send response
+	writeJSON(w, http.StatusOK, WebhooksResponse{Webhooks: webhooks})
+}
+
+/*
+/webhooks/delete - WebhookIDQuery
+*/
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var webhook models.WebhookIDQuery
+
+	if err := decodeRequest(r, &webhook); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateWebhookIDQuery(webhook); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Delete webhook
+	slog.InfoContext(r.Context(), "Deleting webhook", "webhook_id", webhook.WebhookID)
+	if err := h.service.DeleteWebhook(r.Context(), webhook.WebhookID); err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Webhook deleted", "webhook_id", webhook.WebhookID)
+
+	// Send response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+/webhooks/deliveries/failed - WebhookIDQuery (optional)
+*/
+func (h *WebhookHandler) GetFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+	// Decode input, empty body means all webhooks
+	var webhook models.WebhookIDQuery
+
+	if err := decodeRequest(r, &webhook); err != nil && err != io.EOF {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Get failed deliveries
+	slog.DebugContext(r.Context(), "Receiving failed deliveries")
+	deliveries, err := h.service.GetFailedDeliveries(r.Context(), webhook.WebhookID)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Failed deliveries received")
+
+	// Send response
+	writeJSON(w, http.StatusOK, DeliveriesResponse{Deliveries: deliveries})
+}
+
+/*
+/webhooks/deliveries/replay - DeliveryIDQuery
+*/
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	// Decode input
+	var delivery models.DeliveryIDQuery
+
+	if err := decodeRequest(r, &delivery); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Validate input
+	if violations := ValidateDeliveryIDQuery(delivery); len(violations) > 0 {
+		writeViolations(w, violations)
+		return
+	}
+
+	// Replay delivery
+	slog.InfoContext(r.Context(), "Replaying delivery", "delivery_id", delivery.DeliveryID)
+	replayed, err := h.service.ReplayDelivery(r.Context(), delivery.DeliveryID)
+	if err != "" {
+		writeError(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Delivery queued", "delivery_id", delivery.DeliveryID)
+
+	// Send response
+	writeJSON(w, http.StatusAccepted, DeliveryResponse{Delivery: replayed})
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// Register connection pool statistics of db
+func RegisterDBStats(registry *Registry, stats func() sql.DBStats) {
+	registry.Register(
+		NewGaugeFunc("db_max_open_connections", "Maximum number of open connections to the database.",
+			func() float64 { return float64(stats().MaxOpenConnections) }),
+		NewGaugeFunc("db_open_connections", "Number of established connections, in use and idle.",
+			func() float64 { return float64(stats().OpenConnections) }),
+		NewGaugeFunc("db_in_use_connections", "Number of connections currently in use.",
+			func() float64 { return float64(stats().InUse) }),
+		NewGaugeFunc("db_idle_connections", "Number of idle connections.",
+			func() float64 { return float64(stats().Idle) }),
+		NewCounterFunc("db_wait_count_total", "Total number of connections waited for.",
+			func() float64 { return float64(stats().WaitCount) }),
+		NewCounterFunc("db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+			func() float64 { return stats().WaitDuration.Seconds() }),
+		NewCounterFunc("db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.",
+			func() float64 { return float64(stats().MaxIdleClosed) }),
+		NewCounterFunc("db_max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime.",
+			func() float64 { return float64(stats().MaxIdleTimeClosed) }),
+		NewCounterFunc("db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.",
+			func() float64 { return float64(stats().MaxLifetimeClosed) }),
+	)
+}
@@ -0,0 +1,227 @@
+// Package metrics keeps counters and histograms and writes them in Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metric family that can be written in text format
+type Collector interface {
+	write(w io.Writer)
+}
+
+// Set of metrics exposed together
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Write all metrics in Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+/* /metrics - Query */
+func (r *Registry) Handler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// Counters split by label values
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+}
+
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *CounterVec) Add(delta float64, labels ...string) {
+	key := labelKey(labels)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: append([]string(nil), labels...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, v.labels), formatValue(v.value))
+	}
+}
+
+// Histograms split by label values
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogramValue)}
+}
+
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	key := labelKey(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: append([]string(nil), labels...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		for i, bound := range h.buckets {
+			values := append(append([]string(nil), v.labels...), formatValue(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), v.counts[i])
+		}
+		values := append(append([]string(nil), v.labels...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), v.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, v.labels), formatValue(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, v.labels), v.count)
+	}
+}
+
+// Gauge or counter whose value is read on every scrape
+type Func struct {
+	name  string
+	help  string
+	kind  string
+	value func() float64
+}
+
+func NewGaugeFunc(name, help string, value func() float64) *Func {
+	return &Func{name: name, help: help, kind: "gauge", value: value}
+}
+
+func NewCounterFunc(name, help string, value func() float64) *Func {
+	return &Func{name: name, help: help, kind: "counter", value: value}
+}
+
+func (f *Func) write(w io.Writer) {
+	writeHeader(w, f.name, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.name, formatValue(f.value()))
+}
+
+// Text format helpers
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func labelKey(labels []string) string {
+	return strings.Join(labels, "\xff")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,20 +2,34 @@ package models
 
 import "time"
 
-// Query objects
+// Query objects, fields tagged required:"true" must be sent even if false
 type TeamNameQuery struct {
 	TeamName string `json:"team_name"`
 }
 
 type TeamMember struct {
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	IsActive   bool   `json:"is_active" required:"true"`
+	IsTeamLead bool   `json:"is_team_lead"`
+}
+
+type TeamMemberAddQuery struct {
+	TeamName   string `json:"team_name"`
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	IsActive   bool   `json:"is_active" required:"true"`
+	IsTeamLead bool   `json:"is_team_lead"`
+}
+
+type TeamMemberRemoveQuery struct {
+	TeamName string `json:"team_name"`
 	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
 }
 
 type UserActiveQuery struct {
 	UserID   string `json:"user_id"`
-	IsActive bool   `json:"is_active"`
+	IsActive bool   `json:"is_active" required:"true"`
 }
 
 type PullRequestCreateQuery struct {
@@ -51,10 +65,11 @@ type Team struct {
 }
 
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	TeamName   string `json:"team_name"`
+	IsActive   bool   `json:"is_active"`
+	IsTeamLead bool   `json:"is_team_lead"`
 }
 
 type PullRequest struct {
@@ -81,13 +96,17 @@ type UsersStatistics struct {
 	TotalActiveUserNumber int `json:"total_active_user_number"`
 }
 
+// Number of pull request ids in team statistics
+const TeamStatsPRLimit = 20
+
 type TeamStats struct {
 	TeamName                string       `json:"team_name"`
 	MembersTotal            int          `json:"members_total"`
 	Members                 []TeamMember `json:"members"`
 	PullRequestsTotal       int          `json:"pull_requests_total"`
 	ActivePullRequestsTotal int          `json:"active_pull_requests_total"`
-	PullRequests            []string     `json:"pull_requests_id"`
+	// Deprecated: ids of the latest 20 team's prs only, all of them are listed by /pull-requests?team_name=
+	PullRequests []string `json:"pull_requests_id"`
 }
 
 type TeamsStatistics struct {
@@ -98,3 +117,259 @@ type PullRequestStatistics struct {
 	TotalPR       int `json:"total_pull_request_number"`
 	TotalActivePR int `json:"total_active_pull_request_number"`
 }
+
+// Pagination models
+// Lists are ordered by (created_at, id), newest first. Limit is 1..100 (0 means 50),
+// cursor is next_cursor of the previous page, dates are RFC 3339: from inclusive, to exclusive.
+type TeamListQuery struct {
+	Limit       int    `json:"limit"`
+	Cursor      string `json:"cursor"`
+	CreatedFrom string `json:"created_from"`
+	CreatedTo   string `json:"created_to"`
+}
+
+type UserListQuery struct {
+	Limit       int    `json:"limit"`
+	Cursor      string `json:"cursor"`
+	TeamName    string `json:"team_name"`
+	CreatedFrom string `json:"created_from"`
+	CreatedTo   string `json:"created_to"`
+}
+
+type PullRequestListQuery struct {
+	Limit       int    `json:"limit"`
+	Cursor      string `json:"cursor"`
+	Status      string `json:"status"`
+	AuthorID    string `json:"author_id"`
+	TeamName    string `json:"team_name"`
+	CreatedFrom string `json:"created_from"`
+	CreatedTo   string `json:"created_to"`
+}
+
+// Pull requests assigned to reviewer, open ones if status is empty
+type UserReviewQuery struct {
+	UserID      string `json:"user_id"`
+	Limit       int    `json:"limit"`
+	Cursor      string `json:"cursor"`
+	Status      string `json:"status"`
+	CreatedFrom string `json:"created_from"`
+	CreatedTo   string `json:"created_to"`
+}
+
+// Position in list after the last returned item
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Page and filters of list for storage, empty fields don't filter
+type ListFilter struct {
+	Limit       int
+	After       *Cursor
+	Status      string
+	AuthorID    string
+	TeamName    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// Search of pull requests, every filter is optional. Name is case-insensitive substring,
+// sort is created_at (default), merged_at or name, order is asc or desc (default desc, asc for name).
+type PullRequestSearchQuery struct {
+	Name        string `json:"name"`
+	AuthorID    string `json:"author_id"`
+	ReviewerID  string `json:"reviewer_id"`
+	TeamName    string `json:"team_name"`
+	Status      string `json:"status"`
+	CreatedFrom string `json:"created_from"`
+	CreatedTo   string `json:"created_to"`
+	MergedFrom  string `json:"merged_from"`
+	MergedTo    string `json:"merged_to"`
+	Sort        string `json:"sort"`
+	Order       string `json:"order"`
+	Limit       int    `json:"limit"`
+	Cursor      string `json:"cursor"`
+}
+
+// Position in search results: value of sort key and id of the last item
+type SearchCursor struct {
+	Sort       string
+	Descending bool
+	Key        string
+	ID         string
+}
+
+// Search filter for storage, empty fields don't filter
+type PRSearchFilter struct {
+	Limit       int
+	After       *SearchCursor
+	Sort        string
+	Descending  bool
+	Name        string
+	AuthorID    string
+	ReviewerID  string
+	TeamName    string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+}
+
+type TeamSummary struct {
+	TeamName     string    `json:"team_name"`
+	MembersTotal int       `json:"members_total"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Webhook models
+type WebhookCreateQuery struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type WebhookIDQuery struct {
+	WebhookID int64 `json:"webhook_id"`
+}
+
+type DeliveryIDQuery struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+type Webhook struct {
+	WebhookID int64     `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID int64     `json:"delivery_id"`
+	WebhookID  int64     `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Payload    []byte    `json:"-"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Attempts made before the current run, replay starts a new run
+	RunStartAttempts int `json:"-"`
+}
+
+type WebhookDeliveryAttempt struct {
+	DeliveryID int64     `json:"delivery_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Outbox models
+type OutboxEvent struct {
+	Seq       int64     `json:"seq"`
+	StreamSeq int64     `json:"stream_seq"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	TeamName  string    `json:"team_name"`
+	UserIDs   []string  `json:"user_ids"`
+	Payload   []byte    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EventStreamQuery struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+// Review SLA models
+type TeamSettings struct {
+	TeamName       string `json:"team_name"`
+	ReviewSLAHours int    `json:"review_sla_hours"`
+	// Replace reviewers who didn't finish review in EscalateAfterHours
+	AutoEscalate       bool `json:"auto_escalate" required:"true"`
+	EscalateAfterHours int  `json:"escalate_after_hours"`
+	MaxEscalations     int  `json:"max_escalations"`
+}
+
+type OverduePR struct {
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	TeamName          string     `json:"team_name"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ReviewSLAHours    int        `json:"review_sla_hours"`
+	RemindersSent     int        `json:"reminders_sent"`
+	LastRemindedAt    *time.Time `json:"last_reminded_at,omitempty"`
+}
+
+// Reviewer history models
+const (
+	ReassignReasonManual     = "MANUAL"
+	ReassignReasonEscalation = "ESCALATION"
+)
+
+type ReviewerChange struct {
+	PullRequestID string    `json:"pull_request_id"`
+	OldReviewerID string    `json:"old_reviewer_id"`
+	NewReviewerID string    `json:"new_reviewer_id"`
+	Reason        string    `json:"reason"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// API key models
+type APIKeyCreateQuery struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type APIKeyIDQuery struct {
+	KeyID int64 `json:"key_id"`
+}
+
+type APIKey struct {
+	KeyID  int64    `json:"key_id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Plain key, returned only on creation
+	Key       string     `json:"key,omitempty"`
+	KeyHash   string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Idempotency models
+type IdempotencyRecord struct {
+	// Credential of the caller, keys of different callers don't clash
+	Scope       string
+	Key         string
+	RequestHash string
+	// 0 while request is in progress
+	StatusCode int
+	Response   []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+type StaleReview struct {
+	PullRequestID  string
+	ReviewerID     string
+	TeamName       string
+	AssignedAt     time.Time
+	Escalations    int
+	MaxEscalations int
+}
+
+// Input field that failed validation
+type Violation struct {
+	// Path of the field in request: team_name, members[2].user_id
+	Field string `json:"field"`
+	// Failed rule: required, min_length, max_length, pattern, format, range, min_items, unique, enum, type, unknown
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
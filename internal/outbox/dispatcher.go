@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Destination of published events.
+// Send may be called more than once for the same event (at-least-once delivery),
+// so receivers should deduplicate by event id.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event models.OutboxEvent) error
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+// Claimed batch must be published in this time, then other instances may take its events
+const claimLease = 5 * time.Minute
+
+// Publishes events saved in outbox table to sinks
+type Dispatcher struct {
+	storage   storage.Storage
+	sinks     []Sink
+	config    Config
+	observers []func()
+	wg        sync.WaitGroup
+	running   atomic.Bool
+}
+
+func NewDispatcher(storage storage.Storage, config Config, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{storage: storage, sinks: sinks, config: config}
+}
+
+// Register function called after new events were published
+func (d *Dispatcher) OnPublish(observer func()) {
+	d.observers = append(d.observers, observer)
+}
+
+// Start polling outbox in background, stops when ctx is done
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.wg.Add(1)
+	d.running.Store(true)
+	go func() {
+		defer d.wg.Done()
+		defer d.running.Store(false)
+		ticker := time.NewTicker(d.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.dispatch(ctx)
+			}
+		}
+	}()
+}
+
+// Wait until background work stops after ctx is done
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Check that background work is running
+func (d *Dispatcher) Running() bool {
+	return d.running.Load()
+}
+
+// Publish one batch of due events
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, claimLease)
+	defer cancel()
+
+	outbox, err := d.storage.ClaimUnpublishedEvents(ctx, d.config.BatchSize, claimLease)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get outbox events", "error", err)
+		return
+	}
+
+	published := 0
+	for _, event := range outbox {
+		if err := d.publish(ctx, event); err != nil {
+			d.fail(ctx, event, err)
+			continue
+		}
+
+		// If this fails event is sent again on the next poll
+		if err := d.storage.MarkEventPublished(ctx, event.Seq); err != nil {
+			slog.ErrorContext(ctx, "Failed to update outbox event", "seq", event.Seq, "error", err)
+			continue
+		}
+		published++
+	}
+
+	if published > 0 {
+		for _, observer := range d.observers {
+			observer()
+		}
+	}
+}
+
+// Retry failed event later, after the last attempt it is dead and isn't published any more
+func (d *Dispatcher) fail(ctx context.Context, event models.OutboxEvent, err error) {
+	attempt := event.Attempts + 1
+	if attempt >= d.config.MaxAttempts {
+		slog.ErrorContext(ctx, "Event failed after all attempts", "seq", event.Seq, "event_type", event.EventType, "attempts", attempt, "error", err)
+		err = d.storage.MarkEventDead(ctx, event.Seq, err.Error())
+	} else {
+		retryAfter := d.backoff(attempt)
+		slog.WarnContext(ctx, "Failed to publish event", "seq", event.Seq, "event_type", event.EventType, "attempt", attempt, "retry_after", retryAfter.String(), "error", err)
+		err = d.storage.MarkEventFailed(ctx, event.Seq, err.Error(), retryAfter)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update outbox event", "seq", event.Seq, "error", err)
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := d.config.BaseBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > d.config.MaxBackoff {
+		return d.config.MaxBackoff
+	}
+	return backoff
+}
+
+// Send event to every sink, event is published only when all of them succeeded
+func (d *Dispatcher) publish(ctx context.Context, event models.OutboxEvent) error {
+	for _, sink := range d.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			return &SinkError{Sink: sink.Name(), Err: err}
+		}
+	}
+	return nil
+}
+
+type SinkError struct {
+	Sink string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return e.Sink + ": " + e.Err.Error()
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
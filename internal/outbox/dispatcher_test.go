@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Sink that keeps types of sent events, fails events of failing types
+type recordingSink struct {
+	name    string
+	sent    []string
+	failing map[string]bool
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Send(ctx context.Context, event models.OutboxEvent) error {
+	if s.failing[event.EventType] {
+		return errors.New("sink is down")
+	}
+	s.sent = append(s.sent, event.EventType)
+	return nil
+}
+
+type dispatcherTest struct {
+	t          *testing.T
+	ctx        context.Context
+	clock      *clock.Manual
+	storage    *storagetest.Memory
+	dispatcher *Dispatcher
+	published  int
+}
+
+// Events are retried after 1s, 2s and then 3s, 4th failed attempt is the last one
+func newDispatcherTest(t *testing.T, sinks ...Sink) *dispatcherTest {
+	t.Helper()
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	store := storagetest.NewMemory(clk)
+	config := Config{PollInterval: time.Second, BatchSize: 10, MaxAttempts: 4, BaseBackoff: time.Second, MaxBackoff: 3 * time.Second}
+	tt := &dispatcherTest{t: t, ctx: context.Background(), clock: clk, storage: store, dispatcher: NewDispatcher(store, config, sinks...)}
+	tt.dispatcher.OnPublish(func() { tt.published++ })
+
+	team := models.Team{TeamName: "backend", Members: []models.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}}}
+	if err := store.CreateTeam(tt.ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+	return tt
+}
+
+// Save events of types to outbox
+func (tt *dispatcherTest) save(types ...events.Type) {
+	tt.t.Helper()
+	var outbox []events.Event
+	for _, eventType := range types {
+		outbox = append(outbox, events.New(eventType, nil).About("backend", "u1"))
+	}
+	user := &models.User{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true}
+	if err := tt.storage.UpdateUser(tt.ctx, user, outbox); err != nil {
+		tt.t.Fatal(err)
+	}
+}
+
+// Dispatch after d
+func (tt *dispatcherTest) dispatchAfter(d time.Duration) {
+	tt.clock.Advance(d)
+	tt.dispatcher.dispatch(tt.ctx)
+}
+
+func (tt *dispatcherTest) event(eventType events.Type) models.OutboxEvent {
+	tt.t.Helper()
+	for _, event := range tt.storage.OutboxEvents() {
+		if event.EventType == string(eventType) {
+			return event
+		}
+	}
+	tt.t.Fatalf("no %s event", eventType)
+	return models.OutboxEvent{}
+}
+
+func TestDispatcherPublishesToAllSinks(t *testing.T) {
+	log := &recordingSink{name: "log"}
+	file := &recordingSink{name: "file", failing: map[string]bool{}}
+	tt := newDispatcherTest(t, log, file)
+	tt.save(events.TypeUserDeactivated, events.TypeUserActivated)
+
+	tt.dispatchAfter(0)
+	want := []string{string(events.TypeUserDeactivated), string(events.TypeUserActivated)}
+	if !slices.Equal(log.sent, want) || !slices.Equal(file.sent, want) {
+		t.Errorf("sent %v and %v, want %v", log.sent, file.sent, want)
+	}
+	if event := tt.event(events.TypeUserActivated); event.StreamSeq == 0 || event.Attempts != 1 {
+		t.Errorf("event %+v isn't published", event)
+	}
+	if tt.published != 1 {
+		t.Errorf("observers called %d times, want once per batch", tt.published)
+	}
+
+	// Published events aren't sent again
+	tt.dispatchAfter(time.Hour)
+	if len(log.sent) != 2 || tt.published != 1 {
+		t.Errorf("sent %v again", log.sent)
+	}
+
+	// Event is published only when every sink got it, so others get it again
+	file.failing[string(events.TypeUserDeactivated)] = true
+	tt.save(events.TypeUserDeactivated)
+	tt.dispatchAfter(0)
+	file.failing = nil
+	tt.dispatchAfter(time.Second)
+	if got := log.sent[2:]; len(got) != 2 || len(file.sent) != 3 {
+		t.Errorf("sent %v and %v after failure of file sink", log.sent, file.sent)
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	sink := &recordingSink{name: "log", failing: map[string]bool{string(events.TypeUserDeactivated): true}}
+	tt := newDispatcherTest(t, sink)
+	tt.save(events.TypeUserDeactivated, events.TypeUserActivated)
+
+	// Failed event doesn't hold the others
+	tt.dispatchAfter(0)
+	if !slices.Equal(sink.sent, []string{string(events.TypeUserActivated)}) {
+		t.Fatalf("sent %v", sink.sent)
+	}
+
+	for _, test := range []struct {
+		after    time.Duration
+		attempts int
+	}{
+		{time.Second - time.Millisecond, 1},
+		{time.Millisecond, 2},
+		{2*time.Second - time.Millisecond, 2},
+		{time.Millisecond, 3},
+		// Backoff is limited by MaxBackoff
+		{3*time.Second - time.Millisecond, 3},
+		{time.Millisecond, 4},
+	} {
+		tt.dispatchAfter(test.after)
+		if event := tt.event(events.TypeUserDeactivated); event.Attempts != test.attempts {
+			t.Fatalf("after %v: %d attempts, want %d", tt.clock.Now().Sub(event.CreatedAt), event.Attempts, test.attempts)
+		}
+	}
+
+	// Dead event isn't published any more, even when sink recovers
+	sink.failing = nil
+	tt.dispatchAfter(time.Hour)
+	if event := tt.event(events.TypeUserDeactivated); event.Attempts != 4 || event.StreamSeq != 0 || len(sink.sent) != 1 {
+		t.Errorf("dead event %+v is sent: %v", event, sink.sent)
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(nil, Config{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 6: 32 * time.Second, 7: time.Minute, 100: time.Minute} {
+		if got := d.backoff(attempt); got != want {
+			t.Errorf("backoff of attempt %d: %v, want %v", attempt, got, want)
+		}
+	}
+}
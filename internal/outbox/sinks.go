@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Writes events to the service log
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Send(ctx context.Context, event models.OutboxEvent) error {
+	slog.InfoContext(ctx, "Event", "seq", event.Seq, "event_type", event.EventType, "payload", string(event.Payload))
+	return nil
+}
+
+// Appends events to file, one JSON object per line
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Send(ctx context.Context, event models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := append(append([]byte{}, event.Payload...), '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
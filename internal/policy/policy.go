@@ -0,0 +1,77 @@
+// Package policy decides which callers may change teams, users and pull requests.
+package policy
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/models"
+)
+
+// Who makes the request
+type Caller struct {
+	Principal *auth.Principal
+	// User of token principal, nil for API keys and users unknown to the service
+	User *models.User
+}
+
+// API keys, admins, background jobs and requests without authentication aren't restricted
+func (c *Caller) Unrestricted() bool {
+	return c == nil || c.Principal == nil || c.Principal.UserID == "" || c.Principal.Role == auth.RoleAdmin
+}
+
+// Caller is user with id
+func (c *Caller) Is(userID string) bool {
+	return c.Principal.UserID == userID
+}
+
+// Caller leads team: by token role or by membership flag
+func (c *Caller) LeadsTeam(teamName string) bool {
+	team := c.Principal.TeamName
+	if c.User != nil {
+		team = c.User.TeamName
+	}
+	if team == "" || team != teamName {
+		return false
+	}
+	return c.Principal.Role == auth.RoleTeamLead || (c.User != nil && c.User.IsTeamLead)
+}
+
+// Authorization rules consulted by Service
+type Policy interface {
+	// Add members to team or move them out of it
+	CanManageMembers(caller *Caller, teamName string) bool
+	CanManageSettings(caller *Caller, teamName string) bool
+	CanSetIsActive(caller *Caller, user *models.User) bool
+	CanCreatePR(caller *Caller, author *models.User) bool
+	CanMergePR(caller *Caller, pr *models.PullRequest, author *models.User) bool
+	CanReassign(caller *Caller, pr *models.PullRequest, author *models.User, reviewerID string) bool
+}
+
+// Default rules:
+// team leads manage members and settings of their team and act on its users and pull requests,
+// users toggle only themselves, authors create and merge their pull requests,
+// reviewers may hand over their own review
+type TeamPolicy struct{}
+
+func (TeamPolicy) CanManageMembers(caller *Caller, teamName string) bool {
+	return caller.Unrestricted() || caller.LeadsTeam(teamName)
+}
+
+func (TeamPolicy) CanManageSettings(caller *Caller, teamName string) bool {
+	return caller.Unrestricted() || caller.LeadsTeam(teamName)
+}
+
+func (TeamPolicy) CanSetIsActive(caller *Caller, user *models.User) bool {
+	return caller.Unrestricted() || caller.Is(user.UserID) || caller.LeadsTeam(user.TeamName)
+}
+
+func (TeamPolicy) CanCreatePR(caller *Caller, author *models.User) bool {
+	return caller.Unrestricted() || caller.Is(author.UserID) || caller.LeadsTeam(author.TeamName)
+}
+
+func (TeamPolicy) CanMergePR(caller *Caller, pr *models.PullRequest, author *models.User) bool {
+	return caller.Unrestricted() || caller.Is(pr.AuthorID) || caller.LeadsTeam(author.TeamName)
+}
+
+func (TeamPolicy) CanReassign(caller *Caller, pr *models.PullRequest, author *models.User, reviewerID string) bool {
+	return caller.Unrestricted() || caller.Is(pr.AuthorID) || caller.Is(reviewerID) || caller.LeadsTeam(author.TeamName)
+}
@@ -0,0 +1,83 @@
+package policy
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/models"
+	"slices"
+	"testing"
+)
+
+// Users of two teams: u1 leads backend by flag, u2 and u3 are members, u4 is in frontend
+var (
+	lead     = &models.User{UserID: "u1", TeamName: "backend", IsTeamLead: true}
+	author   = &models.User{UserID: "u2", TeamName: "backend"}
+	reviewer = &models.User{UserID: "u3", TeamName: "backend"}
+	outsider = &models.User{UserID: "u4", TeamName: "frontend"}
+	pr       = &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u2", AssignedReviewers: []string{"u3"}}
+)
+
+func tokenCaller(user *models.User, role auth.Role) *Caller {
+	return &Caller{
+		Principal: &auth.Principal{Subject: user.UserID, UserID: user.UserID, TeamName: user.TeamName, Role: role},
+		User:      user,
+	}
+}
+
+// Callers and which actions each may do
+func TestTeamPolicyMatrix(t *testing.T) {
+	callers := map[string]*Caller{
+		"internal":       nil,
+		"api key":        {Principal: &auth.Principal{Subject: "ci", KeyID: 1}},
+		"admin":          tokenCaller(outsider, auth.RoleAdmin),
+		"lead by flag":   tokenCaller(lead, auth.RoleMember),
+		"lead by role":   tokenCaller(&models.User{UserID: "u5", TeamName: "backend"}, auth.RoleTeamLead),
+		"other lead":     tokenCaller(&models.User{UserID: "u6", TeamName: "frontend", IsTeamLead: true}, auth.RoleTeamLead),
+		"author":         tokenCaller(author, auth.RoleMember),
+		"reviewer":       tokenCaller(reviewer, auth.RoleMember),
+		"outsider":       tokenCaller(outsider, auth.RoleMember),
+		"unknown user":   {Principal: &auth.Principal{UserID: "u7", TeamName: "backend", Role: auth.RoleMember}},
+		"unknown leader": {Principal: &auth.Principal{UserID: "u8", TeamName: "backend", Role: auth.RoleTeamLead}},
+	}
+	everyone := []string{"internal", "api key", "admin"}
+	// Clipped, so appends below don't share items
+	leads := slices.Clip(append(everyone, "lead by flag", "lead by role", "unknown leader"))
+
+	var p TeamPolicy
+	actions := []struct {
+		name    string
+		allowed func(*Caller) bool
+		callers []string
+	}{
+		{"manage backend members", func(c *Caller) bool { return p.CanManageMembers(c, "backend") }, leads},
+		{"manage frontend members", func(c *Caller) bool { return p.CanManageMembers(c, "frontend") }, append(everyone, "other lead")},
+		{"manage settings", func(c *Caller) bool { return p.CanManageSettings(c, "backend") }, leads},
+		{"set author activity", func(c *Caller) bool { return p.CanSetIsActive(c, author) }, append(leads, "author")},
+		{"set outsider activity", func(c *Caller) bool { return p.CanSetIsActive(c, outsider) }, append(everyone, "other lead", "outsider")},
+		{"create pr", func(c *Caller) bool { return p.CanCreatePR(c, author) }, append(leads, "author")},
+		{"merge pr", func(c *Caller) bool { return p.CanMergePR(c, pr, author) }, append(leads, "author")},
+		{"reassign reviewer", func(c *Caller) bool { return p.CanReassign(c, pr, author, "u3") }, append(leads, "author", "reviewer")},
+	}
+
+	for _, action := range actions {
+		for name, caller := range callers {
+			want := slices.Contains(action.callers, name)
+			if got := action.allowed(caller); got != want {
+				t.Errorf("%s by %s: allowed %v, want %v", action.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestLeadsTeamUsesStoredTeam(t *testing.T) {
+	// Token says backend, but user was moved to frontend
+	moved := &Caller{
+		Principal: &auth.Principal{UserID: "u1", TeamName: "backend", Role: auth.RoleTeamLead},
+		User:      &models.User{UserID: "u1", TeamName: "frontend"},
+	}
+	if moved.LeadsTeam("backend") {
+		t.Error("caller leads team from token after moving out of it")
+	}
+	if !moved.LeadsTeam("frontend") {
+		t.Error("caller with team-lead role doesn't lead their current team")
+	}
+}
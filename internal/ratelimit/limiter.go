@@ -0,0 +1,222 @@
+// Package ratelimit limits request rates with in-memory token buckets.
+package ratelimit
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Requests per second and how many requests may come at once
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// Idle buckets are removed at most this often
+const sweepInterval = time.Minute
+
+// Token buckets with the same rule, one per key (credential or IP)
+type Limiter struct {
+	rule  Rule
+	clock clock.Clock
+
+	mu sync.Mutex
+	// Rules of keys that differ from the common one
+	keyRules  map[string]Rule
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewLimiter(rule Rule, clock clock.Clock) *Limiter {
+	return &Limiter{
+		rule:      rule,
+		clock:     clock,
+		keyRules:  make(map[string]Rule),
+		buckets:   make(map[string]*bucket),
+		lastSweep: clock.Now(),
+	}
+}
+
+func (l *Limiter) Rule() Rule {
+	return l.rule
+}
+
+// Use rule for key instead of the common one
+func (l *Limiter) SetKeyRule(key string, rule Rule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keyRules[key] = rule
+	delete(l.buckets, key)
+}
+
+// Take token for key, when there are none returns time until the next one
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.take(key, true)
+}
+
+// Check that key has a token without taking it
+func (l *Limiter) Ready(key string) (bool, time.Duration) {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) (bool, time.Duration) {
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	rule := l.ruleOf(key)
+	b, ok := l.buckets[key]
+	if !ok {
+		if !consume {
+			return true, 0
+		}
+		b = &bucket{tokens: float64(rule.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, rule)
+
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return false, wait
+}
+
+func (l *Limiter) ruleOf(key string) Rule {
+	if rule, ok := l.keyRules[key]; ok {
+		return rule
+	}
+	return l.rule
+}
+
+func (b *bucket) refill(now time.Time, rule Rule) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.Rate)
+		b.updated = now
+	}
+}
+
+// Remove buckets that are full again, they are the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		rule := l.ruleOf(key)
+		b.refill(now, rule)
+		if b.tokens >= float64(rule.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Rules of route groups: read endpoints, changes of teams, users and pull requests, admin endpoints
+func DefaultRules() map[string]Rule {
+	return map[string]Rule{
+		"read":  {Rate: 50, Burst: 100},
+		"teams": {Rate: 5, Burst: 10},
+		"users": {Rate: 10, Burst: 20},
+		"prs":   {Rate: 10, Burst: 20},
+		"admin": {Rate: 5, Burst: 10},
+	}
+}
+
+// Parse rules of groups: "read=50:100,prs=5:10" - group=rate:burst
+func ParseRules(s string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		group, rule, err := parseRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules[group] = rule
+	}
+	return rules, nil
+}
+
+// Parse rules of credentials: "key:3/read=100:200,user:u1/prs=20:40" - credential/group=rate:burst,
+// credential is key:<API key ID> or user:<token subject>. Returns rules of credentials per group
+func ParseCredentialRules(s string) (map[string]map[string]Rule, error) {
+	rules := make(map[string]map[string]Rule)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		credential, value, ok := strings.Cut(item, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, expected credential/group=rate:burst", item)
+		}
+		if err := checkCredential(credential); err != nil {
+			return nil, fmt.Errorf("invalid credential in %q: %v", item, err)
+		}
+		group, rule, err := parseRule(value)
+		if err != nil {
+			return nil, err
+		}
+		if rules[group] == nil {
+			rules[group] = make(map[string]Rule)
+		}
+		rules[group][credential] = rule
+	}
+	return rules, nil
+}
+
+func checkCredential(credential string) error {
+	kind, id, _ := strings.Cut(credential, ":")
+	switch {
+	case kind == "key":
+		if n, err := strconv.ParseInt(id, 10, 64); err != nil || n <= 0 {
+			return fmt.Errorf("API key ID must be a positive number")
+		}
+	case kind == "user":
+		if id == "" {
+			return fmt.Errorf("user ID is empty")
+		}
+	default:
+		return fmt.Errorf("expected key:<id> or user:<id>")
+	}
+	return nil
+}
+
+func parseRule(item string) (string, Rule, error) {
+	group, value, ok := strings.Cut(item, "=")
+	if !ok {
+		return "", Rule{}, fmt.Errorf("invalid rate limit %q, expected group=rate:burst", item)
+	}
+	rateValue, burstValue, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", Rule{}, fmt.Errorf("invalid rate limit %q, expected group=rate:burst", item)
+	}
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return "", Rule{}, fmt.Errorf("invalid rate in %q", item)
+	}
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil || burst < 1 {
+		return "", Rule{}, fmt.Errorf("invalid burst in %q", item)
+	}
+	return strings.TrimSpace(group), Rule{Rate: rate, Burst: burst}, nil
+}
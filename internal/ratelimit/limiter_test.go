@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	clk := clock.NewManual(testNow)
+	l := NewLimiter(Rule{Rate: 2, Burst: 3}, clk)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := l.Allow("ip:10.0.0.1"); !allowed {
+			t.Fatalf("request %d within burst is limited", i+1)
+		}
+	}
+	allowed, wait := l.Allow("ip:10.0.0.1")
+	if allowed {
+		t.Fatal("request over burst is allowed")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait %v, want 500ms", wait)
+	}
+
+	// Other keys have own buckets
+	if allowed, _ := l.Allow("ip:10.0.0.2"); !allowed {
+		t.Error("another key is limited")
+	}
+
+	// One token in half a second
+	clk.Advance(250 * time.Millisecond)
+	if allowed, wait := l.Allow("ip:10.0.0.1"); allowed || wait != 250*time.Millisecond {
+		t.Errorf("after 250ms: allowed %v, wait %v, want false, 250ms", allowed, wait)
+	}
+	clk.Advance(250 * time.Millisecond)
+	if allowed, _ := l.Allow("ip:10.0.0.1"); !allowed {
+		t.Error("request after refill is limited")
+	}
+
+	// Bucket doesn't grow over burst
+	clk.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Allow("ip:10.0.0.1")
+	}
+	if allowed, _ := l.Allow("ip:10.0.0.1"); allowed {
+		t.Error("idle bucket holds more than burst")
+	}
+}
+
+func TestLimiterReadyDoesNotTakeToken(t *testing.T) {
+	clk := clock.NewManual(testNow)
+	l := NewLimiter(Rule{Rate: 1, Burst: 1}, clk)
+
+	for i := 0; i < 3; i++ {
+		if ready, _ := l.Ready("ip:10.0.0.1"); !ready {
+			t.Fatal("new key isn't ready")
+		}
+	}
+	if len(l.buckets) != 0 {
+		t.Errorf("check created %d buckets", len(l.buckets))
+	}
+
+	l.Allow("ip:10.0.0.1")
+	if ready, wait := l.Ready("ip:10.0.0.1"); ready || wait != time.Second {
+		t.Errorf("empty bucket: ready %v, wait %v, want false, 1s", ready, wait)
+	}
+}
+
+func TestLimiterKeyRule(t *testing.T) {
+	clk := clock.NewManual(testNow)
+	l := NewLimiter(Rule{Rate: 1, Burst: 1}, clk)
+	l.SetKeyRule("key:3", Rule{Rate: 10, Burst: 5})
+
+	for i := 0; i < 5; i++ {
+		if allowed, _ := l.Allow("key:3"); !allowed {
+			t.Fatalf("request %d within own burst is limited", i+1)
+		}
+	}
+	if allowed, wait := l.Allow("key:3"); allowed || wait != 100*time.Millisecond {
+		t.Errorf("over own burst: allowed %v, wait %v, want false, 100ms", allowed, wait)
+	}
+
+	l.Allow("key:4")
+	if allowed, _ := l.Allow("key:4"); allowed {
+		t.Error("key without own rule uses another rule")
+	}
+}
+
+func TestLimiterSweepsFullBuckets(t *testing.T) {
+	clk := clock.NewManual(testNow)
+	l := NewLimiter(Rule{Rate: 1, Burst: 100}, clk)
+	l.SetKeyRule("key:3", Rule{Rate: 0.01, Burst: 1})
+
+	l.Allow("ip:10.0.0.1")
+	l.Allow("key:3")
+	for i := 0; i < 100; i++ {
+		l.Allow("ip:10.0.0.2")
+	}
+
+	// After a minute 10.0.0.1 is full, 10.0.0.2 and slow key:3 are not
+	clk.Advance(sweepInterval)
+	l.Allow("ip:10.0.0.3")
+	if _, ok := l.buckets["ip:10.0.0.1"]; ok {
+		t.Error("full bucket isn't removed")
+	}
+	if _, ok := l.buckets["ip:10.0.0.2"]; !ok {
+		t.Error("bucket with spent tokens is removed")
+	}
+	if _, ok := l.buckets["key:3"]; !ok {
+		t.Error("bucket is swept by common rule instead of its own")
+	}
+}
+
+func TestParseCredentialRules(t *testing.T) {
+	rules, err := ParseCredentialRules("key:3/read=100:200, user:u1/prs=20:40,key:3/prs=1:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules["read"]["key:3"] != (Rule{Rate: 100, Burst: 200}) ||
+		rules["prs"]["user:u1"] != (Rule{Rate: 20, Burst: 40}) ||
+		rules["prs"]["key:3"] != (Rule{Rate: 1, Burst: 2}) {
+		t.Errorf("rules %v", rules)
+	}
+
+	for _, value := range []string{
+		"read=100:200",
+		"key:x/read=1:1",
+		"key:0/read=1:1",
+		"user:/read=1:1",
+		"ip:10.0.0.1/read=1:1",
+		"key:3/read=0:1",
+		"key:3/read=1",
+	} {
+		if _, err := ParseCredentialRules(value); err == nil {
+			t.Errorf("%q is accepted", value)
+		}
+	}
+}
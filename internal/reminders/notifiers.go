@@ -0,0 +1,85 @@
+package reminders
+
+import (
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/webhooks"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Type of reminder events
+const EventType events.Type = "pr.reminder"
+
+// Sends reminders about overdue prs somewhere
+type Notifier interface {
+	Notify(ctx context.Context, pr models.OverduePR) error
+}
+
+// Writes reminders to the service log
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, pr models.OverduePR) error {
+	slog.InfoContext(ctx, "Review reminder",
+		"reminder", pr.RemindersSent,
+		"pull_request_id", pr.PullRequestID,
+		"team_name", pr.TeamName,
+		"created_at", pr.CreatedAt.Format(time.RFC3339),
+		"sla_hours", pr.ReviewSLAHours,
+		"reviewers", pr.AssignedReviewers)
+	return nil
+}
+
+// Posts reminder events to url, signed the same way as webhook deliveries
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, pr models.OverduePR) error {
+	event := events.New(EventType, pr).About(pr.TeamName, append([]string{pr.AuthorID}, pr.AssignedReviewers...)...)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderEvent, string(EventType))
+	req.Header.Set(webhooks.HeaderTimestamp, timestamp)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(n.secret, timestamp, payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package reminders
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	// How often to look for overdue prs
+	Interval time.Duration
+	// Minimal time between two reminders about the same pr
+	RepeatEvery time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:    5 * time.Minute,
+		RepeatEvery: 24 * time.Hour,
+	}
+}
+
+// Reminds about prs that are open longer than review SLA of their team
+type Scheduler struct {
+	service  *service.Service
+	notifier Notifier
+	clock    clock.Clock
+	config   Config
+	wg       sync.WaitGroup
+	running  atomic.Bool
+}
+
+func NewScheduler(service *service.Service, notifier Notifier, clock clock.Clock, config Config) *Scheduler {
+	return &Scheduler{service: service, notifier: notifier, clock: clock, config: config}
+}
+
+// Start checking in background, stops when ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.wg.Add(1)
+	s.running.Store(true)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Store(false)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Wait until background work stops after ctx is done
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Check that background work is running
+func (s *Scheduler) Running() bool {
+	return s.running.Load()
+}
+
+// Send reminders about overdue prs once, skipped while another instance sends them.
+// Instance that runs next doesn't repeat them, sent reminders are recorded.
+func (s *Scheduler) Run(ctx context.Context) {
+	ran, err := s.service.RunExclusive(ctx, storage.JobReminders, s.remind)
+	if err != "" {
+		slog.ErrorContext(ctx, "Failed to lock reminders", "error_code", err)
+		return
+	}
+	if !ran {
+		slog.DebugContext(ctx, "Reminders are sent by another instance")
+	}
+}
+
+func (s *Scheduler) remind(ctx context.Context) {
+	prs, err := s.service.GetOverduePRs(ctx, "")
+	if err != "" {
+		slog.ErrorContext(ctx, "Failed to get overdue PRs", "error_code", err)
+		return
+	}
+
+	now := s.clock.Now()
+	for _, pr := range prs {
+		// Don't remind too often
+		if pr.LastRemindedAt != nil && now.Sub(*pr.LastRemindedAt) < s.config.RepeatEvery {
+			continue
+		}
+
+		pr.RemindersSent++
+		pr.LastRemindedAt = &now
+		if err := s.notifier.Notify(ctx, pr); err != nil {
+			slog.ErrorContext(ctx, "Failed to send reminder", "pull_request_id", pr.PullRequestID, "error", err)
+			continue
+		}
+
+		if _, err := s.service.RecordReminder(ctx, pr.PullRequestID); err != "" {
+			slog.ErrorContext(ctx, "Failed to record reminder", "pull_request_id", pr.PullRequestID, "error_code", err)
+		}
+	}
+}
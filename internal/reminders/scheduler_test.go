@@ -0,0 +1,182 @@
+package reminders
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+// Notifier that keeps reminders, fails while err is set
+type recordingNotifier struct {
+	sent []models.OverduePR
+	err  error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, pr models.OverduePR) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, pr)
+	return nil
+}
+
+type schedulerTest struct {
+	t         *testing.T
+	ctx       context.Context
+	clock     *clock.Manual
+	storage   *storagetest.Memory
+	service   *service.Service
+	notifier  *recordingNotifier
+	scheduler *Scheduler
+}
+
+// Teams backend with review SLA of 4 hours and frontend with the default 48 hours,
+// reminders are repeated after a day
+func newSchedulerTest(t *testing.T) *schedulerTest {
+	t.Helper()
+	clk := clock.NewManual(testNow)
+	store := storagetest.NewMemory(clk)
+	s := service.NewService(store, nil)
+	s.SetClock(clk)
+	s.SetDefaultReviewSLA(48)
+	notifier := &recordingNotifier{}
+
+	tt := &schedulerTest{t: t, ctx: context.Background(), clock: clk, storage: store, service: s, notifier: notifier,
+		scheduler: NewScheduler(s, notifier, clk, Config{Interval: time.Minute, RepeatEvery: 24 * time.Hour})}
+	for _, team := range []string{"backend", "frontend"} {
+		members := models.Team{TeamName: team}
+		for _, id := range []string{"1", "2", "3"} {
+			members.Members = append(members.Members, models.TeamMember{UserID: team[:1] + id, Username: team + id, IsActive: true})
+		}
+		if err := s.CreateTeam(tt.ctx, &members); err != "" {
+			t.Fatalf("create team %s: %s", team, err)
+		}
+	}
+	if err := s.SetTeamSettings(tt.ctx, &models.TeamSettings{TeamName: "backend", ReviewSLAHours: 4}); err != "" {
+		t.Fatalf("set settings: %s", err)
+	}
+	return tt
+}
+
+func (tt *schedulerTest) createPR(id, author string) {
+	tt.t.Helper()
+	if _, err := tt.service.CreatePullRequest(tt.ctx, models.PullRequestCreateQuery{PullRequestID: id, PullRequestName: id, AuthorID: author}); err != "" {
+		tt.t.Fatalf("create %s: %s", id, err)
+	}
+}
+
+// Run scheduler after d and check ids of prs reminded by this run
+func (tt *schedulerTest) runAfter(d time.Duration, want ...string) []models.OverduePR {
+	tt.t.Helper()
+	tt.clock.Advance(d)
+	before := len(tt.notifier.sent)
+	tt.scheduler.Run(tt.ctx)
+	sent := tt.notifier.sent[before:]
+	var got []string
+	for _, pr := range sent {
+		got = append(got, pr.PullRequestID)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		tt.t.Fatalf("after %v: reminded %v, want %v", tt.clock.Now().Sub(testNow), got, want)
+	}
+	return sent
+}
+
+func TestSchedulerRemindsAfterTeamSLA(t *testing.T) {
+	tt := newSchedulerTest(t)
+	tt.createPR("pr-b", "b1")
+	tt.createPR("pr-f", "f1")
+
+	// SLA is exceeded only after its end
+	tt.runAfter(4 * time.Hour)
+	sent := tt.runAfter(time.Second, "pr-b")
+	if pr := sent[0]; pr.TeamName != "backend" || pr.ReviewSLAHours != 4 || pr.RemindersSent != 1 || len(pr.AssignedReviewers) != 2 {
+		t.Errorf("reminder %+v", pr)
+	}
+
+	// Team without settings has the default SLA
+	tt.runAfter(44*time.Hour-time.Second, "pr-b")
+	tt.runAfter(time.Second, "pr-f")
+}
+
+func TestSchedulerRepeatsReminders(t *testing.T) {
+	tt := newSchedulerTest(t)
+	tt.createPR("pr-1", "b1")
+
+	tt.runAfter(5*time.Hour, "pr-1")
+	tt.runAfter(time.Hour)
+	tt.runAfter(22 * time.Hour)
+	sent := tt.runAfter(time.Hour, "pr-1")
+	if pr := sent[0]; pr.RemindersSent != 2 || pr.LastRemindedAt == nil || !pr.LastRemindedAt.Equal(tt.clock.Now()) {
+		t.Errorf("second reminder %+v", pr)
+	}
+
+	// Merged prs aren't overdue
+	if _, err := tt.service.MergePullRequest(tt.ctx, models.PullRequestMergeQuery{PullRequestID: "pr-1"}); err != "" {
+		t.Fatal(err)
+	}
+	tt.runAfter(48 * time.Hour)
+}
+
+func TestSchedulerRetriesFailedReminder(t *testing.T) {
+	tt := newSchedulerTest(t)
+	tt.createPR("pr-1", "b1")
+
+	tt.notifier.err = errors.New("notifier is down")
+	tt.runAfter(5 * time.Hour)
+
+	// Failed reminder isn't recorded, so it's sent on the next run
+	tt.notifier.err = nil
+	sent := tt.runAfter(time.Minute, "pr-1")
+	if sent[0].RemindersSent != 1 {
+		t.Errorf("reminder %+v, want the first one", sent[0])
+	}
+}
+
+func TestSchedulerSkippedWhileAnotherInstanceRuns(t *testing.T) {
+	tt := newSchedulerTest(t)
+	tt.createPR("pr-1", "b1")
+
+	// Other instance holds the job lock
+	ran, err := tt.storage.RunExclusive(tt.ctx, storage.JobReminders, func(ctx context.Context) {
+		tt.runAfter(5 * time.Hour)
+	})
+	if !ran || err != nil {
+		t.Fatalf("lock: %v, %v", ran, err)
+	}
+
+	// Lock is released after the run
+	tt.runAfter(0, "pr-1")
+}
+
+func TestOverduePRsOfTeam(t *testing.T) {
+	tt := newSchedulerTest(t)
+	tt.createPR("pr-b", "b1")
+	tt.createPR("pr-f", "f1")
+	tt.clock.Advance(49 * time.Hour)
+
+	for team, want := range map[string][]string{"": {"pr-b", "pr-f"}, "backend": {"pr-b"}, "frontend": {"pr-f"}} {
+		prs, err := tt.service.GetOverduePRs(tt.ctx, team)
+		if err != "" {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, pr := range prs {
+			got = append(got, pr.PullRequestID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("overdue of %q: %v, want %v", team, got, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/policy"
+	"context"
+)
+
+// Change authorization rules
+func (s *Service) SetPolicy(policy policy.Policy) {
+	s.policy = policy
+}
+
+// Caller of the request with its user, nil for internal calls
+func (s *Service) caller(ctx context.Context) (*policy.Caller, errors.ErrorCode) {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return nil, ""
+	}
+
+	caller := &policy.Caller{Principal: principal}
+	if principal.UserID != "" {
+		user, err := s.storage.GetUser(ctx, principal.UserID)
+		if err != nil {
+			return nil, internalError(ctx, err)
+		}
+		caller.User = user
+	}
+	return caller, ""
+}
+
+// Check rule for caller of the request
+func (s *Service) authorize(ctx context.Context, allowed func(caller *policy.Caller) bool) errors.ErrorCode {
+	caller, er := s.caller(ctx)
+	if er != "" {
+		return er
+	}
+	if !allowed(caller) {
+		return errors.ErrorCodeForbidden
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+)
+
+// API key functions
+
+// Create new API key, plain key is returned only here
+func (s *Service) CreateAPIKey(ctx context.Context, query models.APIKeyCreateQuery) (*models.APIKey, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.CreateAPIKey")
+	defer span.End()
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	created, err := s.storage.CreateAPIKey(ctx, &models.APIKey{
+		Name:    query.Name,
+		Scopes:  query.Scopes,
+		KeyHash: auth.HashKey(key),
+	})
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	created.Key = key
+	return created, ""
+}
+
+// Get all API keys, including revoked ones
+func (s *Service) GetAPIKeys(ctx context.Context) ([]models.APIKey, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetAPIKeys")
+	defer span.End()
+
+	keys, err := s.storage.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return keys, ""
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.RevokeAPIKey")
+	defer span.End()
+
+	revoked, err := s.storage.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if !revoked {
+		return errors.ErrorCodeNotFound
+	}
+	return ""
+}
+
+// Save key given by operator if it doesn't exist, used for bootstrap admin key
+func (s *Service) EnsureAPIKey(ctx context.Context, name, key string, scopes []auth.Scope) error {
+	ctx, span := tracer.Start(ctx, "Service.EnsureAPIKey")
+	defer span.End()
+
+	hash := auth.HashKey(key)
+	existing, err := s.storage.GetAPIKeyByHash(ctx, hash)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	_, err = s.storage.CreateAPIKey(ctx, &models.APIKey{
+		Name:    name,
+		Scopes:  names,
+		KeyHash: hash,
+	})
+	return err
+}
+
+// Find caller by API key
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.AuthenticateAPIKey")
+	defer span.End()
+
+	stored, err := s.storage.GetAPIKeyByHash(ctx, auth.HashKey(key))
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if stored == nil || stored.RevokedAt != nil {
+		return nil, errors.ErrorCodeUnauthorized
+	}
+
+	principal := &auth.Principal{
+		Subject: stored.Name,
+		KeyID:   stored.KeyID,
+	}
+	for _, scope := range stored.Scopes {
+		principal.Scopes = append(principal.Scopes, auth.Scope(scope))
+	}
+	return principal, ""
+}
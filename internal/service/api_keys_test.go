@@ -0,0 +1,113 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCreateAPIKeyStoresHashOnly(t *testing.T) {
+	s, store, _ := newTestService(t)
+	ctx := context.Background()
+
+	first, err := s.CreateAPIKey(ctx, models.APIKeyCreateQuery{Name: "ci", Scopes: []string{"read"}})
+	if err != "" {
+		t.Fatal(err)
+	}
+	second, err := s.CreateAPIKey(ctx, models.APIKeyCreateQuery{Name: "bot", Scopes: []string{"read"}})
+	if err != "" {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first.Key, auth.KeyPrefix) || first.Key == second.Key {
+		t.Errorf("keys %s and %s", first.Key, second.Key)
+	}
+
+	keys, _ := store.GetAPIKeys(ctx)
+	if len(keys) != 2 {
+		t.Fatalf("%d keys stored, want 2", len(keys))
+	}
+	if stored := keys[0]; stored.Key != "" || stored.KeyHash != auth.HashKey(first.Key) || strings.Contains(stored.KeyHash, first.Key) {
+		t.Errorf("stored key %+v", stored)
+	}
+}
+
+func TestAuthenticateAPIKeyScopes(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	created, err := s.CreateAPIKey(ctx, models.APIKeyCreateQuery{Name: "ci", Scopes: []string{"read", "write:prs"}})
+	if err != "" {
+		t.Fatal(err)
+	}
+	principal, err := s.AuthenticateAPIKey(ctx, created.Key)
+	if err != "" {
+		t.Fatal(err)
+	}
+	if principal.Subject != "ci" || principal.KeyID != created.KeyID || !slices.Equal(principal.Scopes, []auth.Scope{auth.ScopeRead, auth.ScopeWritePRs}) {
+		t.Errorf("principal %+v", principal)
+	}
+	for scope, want := range map[auth.Scope]bool{auth.ScopeRead: true, auth.ScopeWritePRs: true, auth.ScopeWriteTeams: false, auth.ScopeAdmin: false} {
+		if principal.HasScope(scope) != want {
+			t.Errorf("has scope %s: %v, want %v", scope, !want, want)
+		}
+	}
+
+	// Hash of a key isn't a key
+	for _, key := range []string{"", auth.HashKey(created.Key), created.Key + "0", strings.ToUpper(created.Key)} {
+		if _, err := s.AuthenticateAPIKey(ctx, key); err != errors.ErrorCodeUnauthorized {
+			t.Errorf("key %q: %q, want unauthorized", key, err)
+		}
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	revoked, _ := s.CreateAPIKey(ctx, models.APIKeyCreateQuery{Name: "old", Scopes: []string{"read"}})
+	kept, _ := s.CreateAPIKey(ctx, models.APIKeyCreateQuery{Name: "new", Scopes: []string{"read"}})
+	if err := s.RevokeAPIKey(ctx, revoked.KeyID); err != "" {
+		t.Fatal(err)
+	}
+
+	if _, err := s.AuthenticateAPIKey(ctx, revoked.Key); err != errors.ErrorCodeUnauthorized {
+		t.Errorf("revoked key: %q, want unauthorized", err)
+	}
+	if _, err := s.AuthenticateAPIKey(ctx, kept.Key); err != "" {
+		t.Errorf("other key: %q", err)
+	}
+
+	// Revoked keys are listed, but can't be revoked again
+	keys, _ := s.GetAPIKeys(ctx)
+	if len(keys) != 2 || keys[0].RevokedAt == nil || keys[1].RevokedAt != nil {
+		t.Errorf("keys %+v", keys)
+	}
+	for _, id := range []int64{revoked.KeyID, 1000} {
+		if err := s.RevokeAPIKey(ctx, id); err != errors.ErrorCodeNotFound {
+			t.Errorf("revoke %d: %q, want not found", id, err)
+		}
+	}
+}
+
+func TestEnsureAPIKey(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	// Bootstrap key is saved once, on every start
+	for range 2 {
+		if err := s.EnsureAPIKey(ctx, "bootstrap", "admin-key", []auth.Scope{auth.ScopeAdmin}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if keys, _ := s.GetAPIKeys(ctx); len(keys) != 1 {
+		t.Errorf("%d keys, want 1", len(keys))
+	}
+	principal, err := s.AuthenticateAPIKey(ctx, "admin-key")
+	if err != "" || principal.Subject != "bootstrap" || !principal.HasScope(auth.ScopeWriteTeams) {
+		t.Errorf("bootstrap key: %+v, %q", principal, err)
+	}
+}
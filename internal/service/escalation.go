@@ -0,0 +1,80 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"log/slog"
+)
+
+// Escalation defaults of teams that didn't set them
+const (
+	DefaultEscalateAfterHours = 24
+	DefaultMaxEscalations     = 1
+)
+
+// Escalation functions
+
+// Replace reviewers who exceeded escalation time of their team, returns made changes
+func (s *Service) EscalateStaleReviews(ctx context.Context) ([]models.ReviewerChange, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.EscalateStaleReviews")
+	defer span.End()
+
+	reviews, err := s.storage.GetStaleReviews(ctx, s.clock.Now())
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	// Escalations made in this run count towards the limit too
+	escalations := make(map[string]int)
+	var changes []models.ReviewerChange
+	for _, review := range reviews {
+		count, seen := escalations[review.PullRequestID]
+		if !seen {
+			count = review.Escalations
+		}
+		if count >= review.MaxEscalations {
+			continue
+		}
+
+		_, newReviewer, code := s.reassign(ctx, models.PullRequestReassignQuery{
+			PullRequestID: review.PullRequestID,
+			OldUserID:     review.ReviewerID,
+		}, models.ReassignReasonEscalation)
+		if code != "" {
+			// Nobody to replace with, try again next time
+			slog.WarnContext(ctx, "Failed to escalate review", "pull_request_id", review.PullRequestID, "reviewer_id", review.ReviewerID, "error_code", code)
+			continue
+		}
+
+		escalations[review.PullRequestID] = count + 1
+		changes = append(changes, models.ReviewerChange{
+			PullRequestID: review.PullRequestID,
+			OldReviewerID: review.ReviewerID,
+			NewReviewerID: *newReviewer,
+			Reason:        models.ReassignReasonEscalation,
+		})
+	}
+	return changes, ""
+}
+
+// Get history of reviewer changes in pr
+func (s *Service) GetReviewerHistory(ctx context.Context, prId string) ([]models.ReviewerChange, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetReviewerHistory")
+	defer span.End()
+
+	// Check pr existance
+	pr, err := s.storage.GetPR(ctx, prId)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if pr == nil {
+		return nil, errors.ErrorCodeNotFound
+	}
+
+	history, err := s.storage.GetReviewerHistory(ctx, prId)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return history, ""
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+)
+
+// Event functions
+
+// Get published events after given stream position
+func (s *Service) GetEvents(ctx context.Context, after int64, filter models.EventStreamQuery, limit int) ([]models.OutboxEvent, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetEvents")
+	defer span.End()
+
+	outbox, err := s.storage.GetPublishedEvents(ctx, after, filter, limit)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return outbox, ""
+}
+
+// Get position of the last published event
+func (s *Service) GetLastEventSeq(ctx context.Context) (int64, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetLastEventSeq")
+	defer span.End()
+
+	seq, err := s.storage.GetLastStreamSeq(ctx)
+	if err != nil {
+		return 0, internalError(ctx, err)
+	}
+	return seq, ""
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"fmt"
+)
+
+// Health functions, errors describe the failed dependency
+
+// Check that storage answers
+func (s *Service) PingStorage(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Service.PingStorage")
+	defer span.End()
+
+	return s.storage.Ping(ctx)
+}
+
+// Check that storage schema is the one the code expects
+func (s *Service) CheckSchemaVersion(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Service.CheckSchemaVersion")
+	defer span.End()
+
+	version, err := s.storage.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != storage.SchemaVersion {
+		return fmt.Errorf("schema version is %d, expected %d", version, storage.SchemaVersion)
+	}
+	return nil
+}
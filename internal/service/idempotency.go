@@ -0,0 +1,117 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"time"
+)
+
+// Idempotency functions
+
+// How long responses are kept for retries by default
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Request with idempotency key is cancelled after this, so its key isn't unlocked while it runs
+const IdempotentRequestTimeout = time.Minute
+
+// Unfinished request older than this is considered lost, e.g. after restart
+const idempotencyLockTimeout = 2 * IdempotentRequestTimeout
+
+// Change how long responses are kept for retries
+func (s *Service) SetIdempotencyTTL(ttl time.Duration) {
+	s.idempotencyTTL = ttl
+}
+
+/*
+Reserve idempotency key for request.
+Returns nil if request should be handled, or saved record if its response should be replayed.
+*/
+func (s *Service) BeginIdempotentRequest(ctx context.Context, scope, key, requestHash string) (*models.IdempotencyRecord, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.BeginIdempotentRequest")
+	defer span.End()
+
+	now := s.clock.Now()
+	record := &models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.idempotencyTTL),
+	}
+
+	// Record may expire between attempts
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := s.storage.CreateIdempotencyRecord(ctx, record)
+		if err != nil {
+			return nil, internalError(ctx, err)
+		}
+		if created {
+			return nil, ""
+		}
+
+		existing, err := s.storage.GetIdempotencyRecord(ctx, scope, key)
+		if err != nil {
+			return nil, internalError(ctx, err)
+		}
+		if existing == nil {
+			continue
+		}
+
+		// Same key with another request
+		if existing.RequestHash != requestHash {
+			return nil, errors.ErrorCodeIdempotencyKeyReused
+		}
+		// First request is still handled
+		if existing.StatusCode == 0 {
+			if now.Sub(existing.CreatedAt) < idempotencyLockTimeout {
+				return nil, errors.ErrorCodeRequestInProgress
+			}
+			if err := s.storage.DeleteIdempotencyRecord(ctx, scope, key); err != nil {
+				return nil, internalError(ctx, err)
+			}
+			continue
+		}
+		return existing, ""
+	}
+	return nil, errors.ErrorCodeInternal
+}
+
+// Save response of request to replay it on retries
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, scope, key string, statusCode int, response []byte) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.CompleteIdempotentRequest")
+	defer span.End()
+
+	if err := s.storage.CompleteIdempotencyRecord(ctx, &models.IdempotencyRecord{
+		Scope:      scope,
+		Key:        key,
+		StatusCode: statusCode,
+		Response:   response,
+	}); err != nil {
+		return internalError(ctx, err)
+	}
+	return ""
+}
+
+// Release key of failed request, so it can be retried
+func (s *Service) AbortIdempotentRequest(ctx context.Context, scope, key string) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.AbortIdempotentRequest")
+	defer span.End()
+
+	if err := s.storage.DeleteIdempotencyRecord(ctx, scope, key); err != nil {
+		return internalError(ctx, err)
+	}
+	return ""
+}
+
+// Remove expired records, returns how many were removed
+func (s *Service) PurgeIdempotencyRecords(ctx context.Context) (int64, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.PurgeIdempotencyRecords")
+	defer span.End()
+
+	deleted, err := s.storage.DeleteExpiredIdempotencyRecords(ctx, s.clock.Now())
+	if err != nil {
+		return 0, internalError(ctx, err)
+	}
+	return deleted, ""
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"context"
+)
+
+// Background job functions
+
+// Run background job unless another instance of the service runs it now, false if it does
+func (s *Service) RunExclusive(ctx context.Context, job string, fn func(ctx context.Context)) (bool, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.RunExclusive")
+	defer span.End()
+
+	ran, err := s.storage.RunExclusive(ctx, job, fn)
+	if err != nil {
+		return false, internalError(ctx, err)
+	}
+	return ran, ""
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+)
+
+// List functions, pages are newest first, cursor is nil on the last page
+
+func (s *Service) ListTeams(ctx context.Context, filter models.ListFilter) ([]models.TeamSummary, *models.Cursor, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.ListTeams")
+	defer span.End()
+
+	teams, cursor, err := s.storage.ListTeams(ctx, filter)
+	if err != nil {
+		return nil, nil, internalError(ctx, err)
+	}
+	return teams, cursor, ""
+}
+
+func (s *Service) ListUsers(ctx context.Context, filter models.ListFilter) ([]models.User, *models.Cursor, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.ListUsers")
+	defer span.End()
+
+	users, cursor, err := s.storage.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, nil, internalError(ctx, err)
+	}
+	return users, cursor, ""
+}
+
+func (s *Service) ListPullRequests(ctx context.Context, filter models.ListFilter) ([]models.PullRequest, *models.Cursor, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.ListPullRequests")
+	defer span.End()
+
+	prs, cursor, err := s.storage.ListPRs(ctx, filter)
+	if err != nil {
+		return nil, nil, internalError(ctx, err)
+	}
+	return prs, cursor, ""
+}
+
+// Search of prs by name, people, team, status and dates
+func (s *Service) SearchPullRequests(ctx context.Context, filter models.PRSearchFilter) ([]models.PullRequest, *models.SearchCursor, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.SearchPullRequests")
+	defer span.End()
+
+	prs, cursor, err := s.storage.SearchPRs(ctx, filter)
+	if err != nil {
+		return nil, nil, internalError(ctx, err)
+	}
+	return prs, cursor, ""
+}
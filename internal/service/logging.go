@@ -0,0 +1,28 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log unexpected failure with request id of ctx and hide it behind INTERNAL code
+func internalError(ctx context.Context, err error) errors.ErrorCode {
+	operation := "unknown"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			operation = fn.Name()[strings.LastIndex(fn.Name(), "/")+1:]
+		}
+	}
+	slog.ErrorContext(ctx, "Internal error", "operation", operation, "error", err)
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, string(errors.ErrorCodeInternal))
+	return errors.ErrorCodeInternal
+}
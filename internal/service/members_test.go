@@ -0,0 +1,136 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+// Service with teams backend (u1 leads, u2, u3) and frontend (u4 leads, u5)
+func newTestService(t *testing.T) (*Service, *storagetest.Memory, *clock.Manual) {
+	t.Helper()
+	clk := clock.NewManual(testNow)
+	store := storagetest.NewMemory(clk)
+	s := NewService(store, nil)
+	s.SetClock(clk)
+
+	for _, team := range []models.Team{
+		{TeamName: "backend", Members: []models.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true, IsTeamLead: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+			{UserID: "u3", Username: "Carol", IsActive: true},
+		}},
+		{TeamName: "frontend", Members: []models.TeamMember{
+			{UserID: "u4", Username: "Dave", IsActive: true, IsTeamLead: true},
+			{UserID: "u5", Username: "Eve", IsActive: true},
+		}},
+	} {
+		if err := s.CreateTeam(context.Background(), &team); err != "" {
+			t.Fatalf("create team %s: %s", team.TeamName, err)
+		}
+	}
+	return s, store, clk
+}
+
+// Context of user with token
+func as(userID string, role auth.Role) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: userID, UserID: userID, Role: role, Scopes: auth.RoleScopes(role)})
+}
+
+func member(team *models.Team, userID string) *models.TeamMember {
+	for i := range team.Members {
+		if team.Members[i].UserID == userID {
+			return &team.Members[i]
+		}
+	}
+	return nil
+}
+
+func TestAddTeamMember(t *testing.T) {
+	s, store, _ := newTestService(t)
+	lead := as("u1", auth.RoleMember)
+
+	// Lead by flag adds new user to their team
+	team, err := s.AddTeamMember(lead, "backend", models.TeamMember{UserID: "u6", Username: "Frank", IsActive: true})
+	if err != "" {
+		t.Fatalf("add new member: %s", err)
+	}
+	if m := member(team, "u6"); m == nil || !m.IsActive {
+		t.Errorf("team %+v has no active u6", team)
+	}
+
+	// Team lead role can't add to another team
+	if _, err := s.AddTeamMember(as("u1", auth.RoleTeamLead), "frontend", models.TeamMember{UserID: "u7", Username: "Gina", IsActive: true}); err != errors.ErrorCodeForbidden {
+		t.Errorf("add to other team: %q, want FORBIDDEN", err)
+	}
+	// Nor take user from another team
+	if _, err := s.AddTeamMember(lead, "backend", models.TeamMember{UserID: "u5", Username: "Eve", IsActive: true}); err != errors.ErrorCodeForbidden {
+		t.Errorf("move from other team: %q, want FORBIDDEN", err)
+	}
+	// Members don't manage team
+	if _, err := s.AddTeamMember(as("u2", auth.RoleMember), "backend", models.TeamMember{UserID: "u7", Username: "Gina", IsActive: true}); err != errors.ErrorCodeForbidden {
+		t.Errorf("add by member: %q, want FORBIDDEN", err)
+	}
+	if _, err := s.AddTeamMember(lead, "mobile", models.TeamMember{UserID: "u7", Username: "Gina", IsActive: true}); err != errors.ErrorCodeNotFound {
+		t.Errorf("add to missing team: %q, want NOT_FOUND", err)
+	}
+
+	// Admin moves user, deactivation is published
+	team, err = s.AddTeamMember(as("u9", auth.RoleAdmin), "backend", models.TeamMember{UserID: "u5", Username: "Eve", IsActive: false})
+	if err != "" {
+		t.Fatalf("move by admin: %s", err)
+	}
+	if m := member(team, "u5"); m == nil || m.IsActive {
+		t.Errorf("team %+v has no inactive u5", team)
+	}
+	if user, _ := store.GetUser(context.Background(), "u5"); user.TeamName != "backend" {
+		t.Errorf("u5 is in %s, want backend", user.TeamName)
+	}
+	outbox := store.OutboxEvents()
+	if len(outbox) != 1 || outbox[0].EventType != string(events.TypeUserDeactivated) || outbox[0].TeamName != "backend" {
+		t.Errorf("outbox %+v, want one user.deactivated of backend", outbox)
+	}
+}
+
+func TestRemoveTeamMember(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	if _, err := s.RemoveTeamMember(as("u2", auth.RoleMember), "backend", "u3"); err != errors.ErrorCodeForbidden {
+		t.Errorf("remove by member: %q, want FORBIDDEN", err)
+	}
+	if _, err := s.RemoveTeamMember(as("u4", auth.RoleTeamLead), "backend", "u3"); err != errors.ErrorCodeForbidden {
+		t.Errorf("remove by other lead: %q, want FORBIDDEN", err)
+	}
+	if _, err := s.RemoveTeamMember(as("u1", auth.RoleMember), "backend", "u5"); err != errors.ErrorCodeNotFound {
+		t.Errorf("remove user of other team: %q, want NOT_FOUND", err)
+	}
+
+	// Lead removes another lead: both flags are dropped
+	if _, err := s.AddTeamMember(as("u1", auth.RoleMember), "backend", models.TeamMember{UserID: "u3", Username: "Carol", IsActive: true, IsTeamLead: true}); err != "" {
+		t.Fatalf("make u3 lead: %s", err)
+	}
+	user, err := s.RemoveTeamMember(as("u1", auth.RoleMember), "backend", "u3")
+	if err != "" {
+		t.Fatalf("remove by lead: %s", err)
+	}
+	if user.IsActive || user.IsTeamLead || user.TeamName != "backend" {
+		t.Errorf("removed user %+v, want inactive non-lead of backend", user)
+	}
+	outbox := store.OutboxEvents()
+	if len(outbox) != 1 || outbox[0].EventType != string(events.TypeUserDeactivated) {
+		t.Errorf("outbox %+v, want one user.deactivated", outbox)
+	}
+
+	// Removed lead no longer manages team
+	if _, err := s.RemoveTeamMember(as("u3", auth.RoleMember), "backend", "u2"); err != errors.ErrorCodeForbidden {
+		t.Errorf("remove by removed lead: %q, want FORBIDDEN", err)
+	}
+}
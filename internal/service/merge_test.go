@@ -0,0 +1,69 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"testing"
+	"time"
+)
+
+// Storage that returns pr as it was before concurrent merge
+type staleStorage struct {
+	*storagetest.Memory
+	stale *models.PullRequest
+}
+
+func (s *staleStorage) GetPR(ctx context.Context, prId string) (*models.PullRequest, error) {
+	if stale := s.stale; stale != nil {
+		s.stale = nil
+		return stale, nil
+	}
+	return s.Memory.GetPR(ctx, prId)
+}
+
+func mergeEvents(store *storagetest.Memory) int {
+	count := 0
+	for _, event := range store.OutboxEvents() {
+		if event.EventType == string(events.TypePRMerged) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMergePullRequestOnce(t *testing.T) {
+	s, store, clk := newTestService(t)
+	ctx := context.Background()
+	if _, err := s.CreatePullRequest(ctx, models.PullRequestCreateQuery{PullRequestID: "pr-1", PullRequestName: "Fix", AuthorID: "u1"}); err != "" {
+		t.Fatal(err)
+	}
+	open, _ := store.GetPR(ctx, "pr-1")
+
+	first, err := s.MergePullRequest(ctx, models.PullRequestMergeQuery{PullRequestID: "pr-1"})
+	if err != "" || first.Status != "MERGED" {
+		t.Fatalf("merge: %+v, %s", first, err)
+	}
+
+	// Retry gets the same merge
+	clk.Advance(time.Minute)
+	retry, err := s.MergePullRequest(ctx, models.PullRequestMergeQuery{PullRequestID: "pr-1"})
+	if err != "" || !retry.MergedAt.Equal(*first.MergedAt) {
+		t.Errorf("retry: %+v, %s", retry, err)
+	}
+
+	// Concurrent merge has read the pr while it was open and loses the race
+	racing := NewService(&staleStorage{Memory: store, stale: open}, nil)
+	racing.SetClock(clk)
+	lost, err := racing.MergePullRequest(ctx, models.PullRequestMergeQuery{PullRequestID: "pr-1"})
+	if err != "" || lost.Status != "MERGED" || !lost.MergedAt.Equal(*first.MergedAt) {
+		t.Errorf("concurrent merge: %+v, %s, want merge at %s", lost, err, first.MergedAt)
+	}
+	if stored, _ := store.GetPR(ctx, "pr-1"); !stored.MergedAt.Equal(*first.MergedAt) {
+		t.Errorf("merge time changed to %s", stored.MergedAt)
+	}
+	if count := mergeEvents(store); count != 1 {
+		t.Errorf("%d pr.merged events, want 1", count)
+	}
+}
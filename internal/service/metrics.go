@@ -0,0 +1,34 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/metrics"
+)
+
+// Counters of assignment outcomes
+type domainMetrics struct {
+	prsCreated        *metrics.CounterVec
+	reviewersAssigned *metrics.CounterVec
+	reassignments     *metrics.CounterVec
+	noCandidate       *metrics.CounterVec
+	reviewersPerPR    *metrics.HistogramVec
+}
+
+func newDomainMetrics() *domainMetrics {
+	return &domainMetrics{
+		prsCreated: metrics.NewCounterVec("prs_created_total", "Total number of created pull requests."),
+		reviewersAssigned: metrics.NewCounterVec("reviewers_assigned_total",
+			"Total number of reviewer assignments, on creation and on reassignment.", "source"),
+		reassignments: metrics.NewCounterVec("reviewer_reassignments_total",
+			"Total number of reviewer reassignments.", "reason"),
+		noCandidate: metrics.NewCounterVec("reassign_no_candidate_total",
+			"Total number of reassignments failed with NO_CANDIDATE.", "reason"),
+		reviewersPerPR: metrics.NewHistogramVec("reviewers_per_pr", "Number of reviewers assigned to created pull requests.",
+			[]float64{0, 1, 2, 3, 5, 10}),
+	}
+}
+
+// Expose domain counters in registry
+func (s *Service) RegisterMetrics(registry *metrics.Registry) {
+	m := s.metrics
+	registry.Register(m.prsCreated, m.reviewersAssigned, m.reassignments, m.noCandidate, m.reviewersPerPR)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/policy"
+	"context"
+)
+
+// Team settings functions
+
+// Get team settings, default ones if team has not set them
+func (s *Service) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetTeamSettings")
+	defer span.End()
+
+	// Check team existance
+	team, err := s.storage.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if team == nil {
+		return nil, errors.ErrorCodeNotFound
+	}
+
+	settings, err := s.storage.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if settings == nil {
+		settings = &models.TeamSettings{
+			TeamName:           teamName,
+			ReviewSLAHours:     s.reviewSLAHours,
+			AutoEscalate:       false,
+			EscalateAfterHours: DefaultEscalateAfterHours,
+			MaxEscalations:     DefaultMaxEscalations,
+		}
+	}
+	return settings, ""
+}
+
+// Set team settings
+func (s *Service) SetTeamSettings(ctx context.Context, settings *models.TeamSettings) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.SetTeamSettings")
+	defer span.End()
+
+	// Check team existance
+	team, err := s.storage.GetTeam(ctx, settings.TeamName)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if team == nil {
+		return errors.ErrorCodeNotFound
+	}
+
+	// Check access
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanManageSettings(caller, settings.TeamName)
+	}); er != "" {
+		return er
+	}
+
+	// Escalation fields are optional
+	if settings.EscalateAfterHours == 0 {
+		settings.EscalateAfterHours = DefaultEscalateAfterHours
+	}
+	if settings.MaxEscalations == 0 {
+		settings.MaxEscalations = DefaultMaxEscalations
+	}
+
+	if err := s.storage.SetTeamSettings(ctx, settings); err != nil {
+		return internalError(ctx, err)
+	}
+	return ""
+}
+
+// Reminder functions
+
+// Get open prs that exceeded review SLA, of all teams if teamName is empty
+func (s *Service) GetOverduePRs(ctx context.Context, teamName string) ([]models.OverduePR, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetOverduePRs")
+	defer span.End()
+
+	prs, err := s.storage.GetOverduePRs(ctx, s.reviewSLAHours, s.clock.Now(), teamName)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return prs, ""
+}
+
+// Count sent reminder about pr
+func (s *Service) RecordReminder(ctx context.Context, prId string) (int, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.RecordReminder")
+	defer span.End()
+
+	sent, err := s.storage.RecordReminder(ctx, prId, s.clock.Now())
+	if err != nil {
+		return 0, internalError(ctx, err)
+	}
+	return sent, ""
+}
@@ -1,9 +1,13 @@
 package service
 
 import (
+	"PR_reviewer_assign_service/internal/clock"
 	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/events"
 	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/policy"
 	"PR_reviewer_assign_service/internal/storage"
+	"PR_reviewer_assign_service/internal/webhooks"
 	"context"
 	"math/rand"
 	"time"
@@ -11,21 +15,79 @@ import (
 
 // Middle part between Storage and Handlers
 type Service struct {
-	storage storage.Storage
+	storage        storage.Storage
+	webhooks       *webhooks.Dispatcher
+	clock          clock.Clock
+	policy         policy.Policy
+	reviewSLAHours int
+	reviewersPerPR int
+	idempotencyTTL time.Duration
+	metrics        *domainMetrics
 }
 
-func NewService(storage storage.Storage) *Service {
-	return &Service{storage: storage}
+// Review SLA of teams without own settings
+const DefaultReviewSLAHours = 48
+
+// Reviewers assigned to new pr
+const DefaultReviewersPerPR = 2
+
+func NewService(storage storage.Storage, webhooks *webhooks.Dispatcher) *Service {
+	return &Service{
+		storage:        storage,
+		webhooks:       webhooks,
+		clock:          clock.System{},
+		policy:         policy.TeamPolicy{},
+		reviewSLAHours: DefaultReviewSLAHours,
+		reviewersPerPR: DefaultReviewersPerPR,
+		idempotencyTTL: DefaultIdempotencyTTL,
+		metrics:        newDomainMetrics(),
+	}
+}
+
+// Change source of time, used by tests
+func (s *Service) SetClock(clock clock.Clock) {
+	s.clock = clock
+}
+
+// Change review SLA of teams without own settings
+func (s *Service) SetDefaultReviewSLA(hours int) {
+	s.reviewSLAHours = hours
+}
+
+// Change number of reviewers assigned to new pr
+func (s *Service) SetReviewersPerPR(count int) {
+	s.reviewersPerPR = count
 }
 
 // Team functions
 
 // Create new team
 func (s *Service) CreateTeam(ctx context.Context, team *models.Team) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.CreateTeam")
+	defer span.End()
+
+	// Check access, existing users are moved out of their teams
+	caller, er := s.caller(ctx)
+	if er != "" {
+		return er
+	}
+	if !s.policy.CanManageMembers(caller, team.TeamName) {
+		return errors.ErrorCodeForbidden
+	}
+	for _, member := range team.Members {
+		user, err := s.storage.GetUser(ctx, member.UserID)
+		if err != nil {
+			return internalError(ctx, err)
+		}
+		if user != nil && !s.policy.CanManageMembers(caller, user.TeamName) {
+			return errors.ErrorCodeForbidden
+		}
+	}
+
 	// Check existance
 	t, err := s.storage.GetTeam(ctx, team.TeamName)
 	if err != nil {
-		return errors.ErrorCodeInternal
+		return internalError(ctx, err)
 	}
 	if t != nil {
 		return errors.ErrorCodeTeamExists
@@ -33,7 +95,7 @@ func (s *Service) CreateTeam(ctx context.Context, team *models.Team) errors.Erro
 
 	// Create team
 	if err := s.storage.CreateTeam(ctx, team); err != nil {
-		return errors.ErrorCodeInternal
+		return internalError(ctx, err)
 	}
 
 	return ""
@@ -41,9 +103,12 @@ func (s *Service) CreateTeam(ctx context.Context, team *models.Team) errors.Erro
 
 // Get existing team
 func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetTeam")
+	defer span.End()
+
 	team, err := s.storage.GetTeam(ctx, teamName)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if team == nil {
 		return nil, errors.ErrorCodeNotFound
@@ -51,50 +116,176 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, e
 	return team, ""
 }
 
+// Add user to team, existing user is moved out of their team
+func (s *Service) AddTeamMember(ctx context.Context, teamName string, member models.TeamMember) (*models.Team, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.AddTeamMember")
+	defer span.End()
+
+	// Check team existance
+	team, err := s.storage.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if team == nil {
+		return nil, errors.ErrorCodeNotFound
+	}
+	user, err := s.storage.GetUser(ctx, member.UserID)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	// Check access to both teams
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanManageMembers(caller, teamName) &&
+			(user == nil || s.policy.CanManageMembers(caller, user.TeamName))
+	}); er != "" {
+		return nil, er
+	}
+
+	// Create or move user
+	updated := &models.User{
+		UserID:     member.UserID,
+		Username:   member.Username,
+		TeamName:   teamName,
+		IsActive:   member.IsActive,
+		IsTeamLead: member.IsTeamLead,
+	}
+	if user == nil {
+		err = s.storage.CreateUser(ctx, updated)
+	} else {
+		err = s.storage.UpdateUser(ctx, updated, activityEvents(user, updated))
+	}
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	return s.GetTeam(ctx, teamName)
+}
+
+// Take user off team: user is deactivated and loses team lead flag,
+// but stays in the team, as users can't be without one and pull requests refer to them
+func (s *Service) RemoveTeamMember(ctx context.Context, teamName, userID string) (*models.User, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.RemoveTeamMember")
+	defer span.End()
+
+	// Get member
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if user == nil || user.TeamName != teamName {
+		return nil, errors.ErrorCodeNotFound
+	}
+	// Check access
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanManageMembers(caller, teamName)
+	}); er != "" {
+		return nil, er
+	}
+
+	// Update user
+	updated := *user
+	updated.IsActive = false
+	updated.IsTeamLead = false
+	if err := s.storage.UpdateUser(ctx, &updated, activityEvents(user, &updated)); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return &updated, ""
+}
+
+// Events about change of user activity
+func activityEvents(before, after *models.User) []events.Event {
+	switch {
+	case before.IsActive && !after.IsActive:
+		return []events.Event{events.New(events.TypeUserDeactivated, after).About(after.TeamName, after.UserID)}
+	case !before.IsActive && after.IsActive:
+		return []events.Event{events.New(events.TypeUserActivated, after).About(after.TeamName, after.UserID)}
+	}
+	return nil
+}
+
 // User functions
 // Set User isActive
 func (s *Service) UserSetIsActive(ctx context.Context, id string, active bool) (*models.User, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.UserSetIsActive")
+	defer span.End()
+
 	// Get user
 	user, err := s.storage.GetUser(ctx, id)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if user == nil {
 		return nil, errors.ErrorCodeNotFound
 	}
+	// Check access
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanSetIsActive(caller, user)
+	}); er != "" {
+		return nil, er
+	}
 	// Update user
-	user.IsActive = active
-	if err := s.storage.UpdateUser(ctx, user); err != nil {
-		return nil, errors.ErrorCodeInternal
+	updated := *user
+	updated.IsActive = active
+	if err := s.storage.UpdateUser(ctx, &updated, activityEvents(user, &updated)); err != nil {
+		return nil, internalError(ctx, err)
 	}
-	return user, ""
+	return &updated, ""
 }
 
-// Get User's prs
-func (s *Service) GetPRs(ctx context.Context, id string) ([]models.PullRequestShort, errors.ErrorCode) {
+// Get page of User's prs, open ones if filter has no status
+func (s *Service) GetPRs(ctx context.Context, id string, filter models.ListFilter) ([]models.PullRequestShort, *models.Cursor, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetPRs")
+	defer span.End()
+
 	// Check user existance
 	user, err := s.storage.GetUser(ctx, id)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, nil, internalError(ctx, err)
 	}
 	if user == nil {
-		return nil, errors.ErrorCodeNotFound
+		return nil, nil, errors.ErrorCodeNotFound
 	}
 	// Get user's PRs
-	prs, err := s.storage.GetPRsByRewiever(ctx, id)
+	if filter.Status == "" {
+		filter.Status = "OPEN"
+	}
+	prs, cursor, err := s.storage.GetPRsByRewiever(ctx, id, filter)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, nil, internalError(ctx, err)
+	}
+	return prs, cursor, ""
+}
+
+// Get all User's prs page by page, open ones if filter has no status
+func (s *Service) GetAllPRs(ctx context.Context, id string, filter models.ListFilter) ([]models.PullRequestShort, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetAllPRs")
+	defer span.End()
+
+	all := []models.PullRequestShort{}
+	for {
+		prs, cursor, err := s.GetPRs(ctx, id, filter)
+		if err != "" {
+			return nil, err
+		}
+		all = append(all, prs...)
+		if cursor == nil {
+			return all, ""
+		}
+		filter.After = cursor
 	}
-	return prs, ""
 }
 
 // PR functions
 // Create new Pull Request
 func (s *Service) CreatePullRequest(ctx context.Context, prQuery models.PullRequestCreateQuery) (*models.PullRequest, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.CreatePullRequest")
+	defer span.End()
+
 	// Check pr existance
 	pr, err := s.storage.GetPR(ctx, prQuery.PullRequestID)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if pr != nil {
 		return nil, errors.ErrorCodePRExists
@@ -103,31 +294,55 @@ func (s *Service) CreatePullRequest(ctx context.Context, prQuery models.PullRequ
 	// Check author existance
 	user, err := s.storage.GetUser(ctx, prQuery.AuthorID)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if user == nil {
 		return nil, errors.ErrorCodeNotFound
 	}
 
+	// Check access
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanCreatePR(caller, user)
+	}); er != "" {
+		return nil, er
+	}
+
 	// Get reviewers
-	reviewers, er := s.assignReviewers(ctx, *user, 2, nil)
+	reviewers, er := s.assignReviewers(ctx, *user, s.reviewersPerPR, nil)
 	if er != "" {
 		return nil, er
 	}
 
-	// Create pr
-	pr, err = s.storage.CreatePR(ctx, &models.PullRequest{
+	pr = &models.PullRequest{
 		PullRequestID:     prQuery.PullRequestID,
 		PullRequestName:   prQuery.PullRequestName,
 		AuthorID:          prQuery.AuthorID,
 		Status:            "OPEN",
 		AssignedReviewers: reviewers,
-		CreatedAt:         time.Now(),
+		CreatedAt:         s.clock.Now(),
 		MergedAt:          nil,
-	})
+	}
+
+	// Events about pr
+	outbox := []events.Event{
+		events.New(events.TypePRCreated, pr).About(user.TeamName, append([]string{user.UserID}, reviewers...)...),
+	}
+	for _, reviewer := range reviewers {
+		outbox = append(outbox, events.New(events.TypeReviewerAssigned, events.ReviewerAssignedData{
+			PullRequestID: pr.PullRequestID,
+			ReviewerID:    reviewer,
+		}).About(user.TeamName, user.UserID, reviewer))
+	}
+
+	// Create pr
+	pr, err = s.storage.CreatePR(ctx, pr, outbox)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
+
+	s.metrics.prsCreated.Inc()
+	s.metrics.reviewersAssigned.Add(float64(len(reviewers)), "create")
+	s.metrics.reviewersPerPR.Observe(float64(len(reviewers)))
 	return pr, ""
 }
 
@@ -136,7 +351,7 @@ func (s *Service) assignReviewers(ctx context.Context, author models.User, limit
 	// Get active users
 	users, err := s.storage.GetActiveUsersInTeam(ctx, author.TeamName)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 
 	// Exclude not acceptable ones
@@ -183,22 +398,58 @@ func (s *Service) assignReviewers(ctx context.Context, author models.User, limit
 
 // Merge pr
 func (s *Service) MergePullRequest(ctx context.Context, prQuery models.PullRequestMergeQuery) (*models.PullRequest, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.MergePullRequest")
+	defer span.End()
+
 	// Check pr existance
 	pr, err := s.storage.GetPR(ctx, prQuery.PullRequestID)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if pr == nil {
 		return nil, errors.ErrorCodeNotFound
 	}
 
+	// Get author for event
+	author, err := s.storage.GetUser(ctx, pr.AuthorID)
+	if err != nil || author == nil {
+		return nil, errors.ErrorCodeInternal
+	}
+
+	// Check access
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanMergePR(caller, pr, author)
+	}); er != "" {
+		return nil, er
+	}
+
+	// Merge is idempotent, retries get the merged pr without new event
+	if pr.Status == "MERGED" {
+		return pr, ""
+	}
+
 	// Merge pr
 	pr.Status = "MERGED"
-	mergedAt := time.Now()
+	mergedAt := s.clock.Now()
 	pr.MergedAt = &mergedAt
 
-	if err := s.storage.UpdatePR(ctx, pr); err != nil {
-		return nil, errors.ErrorCodeInternal
+	outbox := []events.Event{
+		events.New(events.TypePRMerged, pr).About(author.TeamName, append([]string{author.UserID}, pr.AssignedReviewers...)...),
+	}
+	merged, err := s.storage.MergePR(ctx, pr, outbox)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	// Concurrent request has merged it first, its merge time and event are kept
+	if !merged {
+		pr, err = s.storage.GetPR(ctx, prQuery.PullRequestID)
+		if err != nil {
+			return nil, internalError(ctx, err)
+		}
+		if pr == nil {
+			return nil, errors.ErrorCodeNotFound
+		}
 	}
 
 	return pr, ""
@@ -206,10 +457,18 @@ func (s *Service) MergePullRequest(ctx context.Context, prQuery models.PullReque
 
 // Reassign user in pr
 func (s *Service) Reassign(ctx context.Context, query models.PullRequestReassignQuery) (*models.PullRequest, *string, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.Reassign")
+	defer span.End()
+
+	return s.reassign(ctx, query, models.ReassignReasonManual)
+}
+
+// Reassign user in pr, reason is saved in reviewers history
+func (s *Service) reassign(ctx context.Context, query models.PullRequestReassignQuery, reason string) (*models.PullRequest, *string, errors.ErrorCode) {
 	// Check pr existance
 	pr, err := s.storage.GetPR(ctx, query.PullRequestID)
 	if err != nil {
-		return nil, nil, errors.ErrorCodeInternal
+		return nil, nil, internalError(ctx, err)
 	}
 	if pr == nil {
 		return nil, nil, errors.ErrorCodeNotFound
@@ -218,12 +477,23 @@ func (s *Service) Reassign(ctx context.Context, query models.PullRequestReassign
 	// Check user existance
 	oldUser, err := s.storage.GetUser(ctx, query.OldUserID)
 	if err != nil {
-		return nil, nil, errors.ErrorCodeInternal
+		return nil, nil, internalError(ctx, err)
 	}
 	if oldUser == nil {
 		return nil, nil, errors.ErrorCodeNotFound
 	}
 
+	// Check access
+	author, err := s.storage.GetUser(ctx, pr.AuthorID)
+	if err != nil || author == nil {
+		return nil, nil, errors.ErrorCodeInternal
+	}
+	if er := s.authorize(ctx, func(caller *policy.Caller) bool {
+		return s.policy.CanReassign(caller, pr, author, oldUser.UserID)
+	}); er != "" {
+		return nil, nil, er
+	}
+
 	// Check Merged
 	if pr.Status == "MERGED" {
 		return nil, nil, errors.ErrorCodePRMerged
@@ -250,6 +520,7 @@ func (s *Service) Reassign(ctx context.Context, query models.PullRequestReassign
 		return nil, nil, er
 	}
 	if len(candidates) == 0 {
+		s.metrics.noCandidate.Inc(reason)
 		return nil, nil, errors.ErrorCodeNoCandidate
 	}
 
@@ -262,30 +533,55 @@ func (s *Service) Reassign(ctx context.Context, query models.PullRequestReassign
 	}
 
 	// Update pr
-	if err := s.storage.UpdatePR(ctx, pr); err != nil {
-		return nil, nil, errors.ErrorCodeInternal
-	}
+	change := &models.ReviewerChange{
+		PullRequestID: pr.PullRequestID,
+		OldReviewerID: oldUser.UserID,
+		NewReviewerID: candidates[0],
+		Reason:        reason,
+		ChangedAt:     s.clock.Now(),
+	}
+	outbox := []events.Event{events.New(events.TypeReviewerReassigned, events.ReviewerReassignedData{
+		PullRequestID: pr.PullRequestID,
+		OldReviewerID: oldUser.UserID,
+		NewReviewerID: candidates[0],
+		Reason:        reason,
+	}).About(oldUser.TeamName, pr.AuthorID, oldUser.UserID, candidates[0])}
+	if err := s.storage.ReplaceReviewer(ctx, change, outbox); err != nil {
+		return nil, nil, internalError(ctx, err)
+	}
+
+	s.metrics.reassignments.Inc(reason)
+	s.metrics.reviewersAssigned.Inc("reassign")
 	return pr, &candidates[0], ""
 }
 
 // Additional functions
 func (s *Service) GetUsersStatistics(ctx context.Context) (*models.UsersStatistics, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetUsersStatistics")
+	defer span.End()
+
 	statistics, err := s.storage.GetUsersStatistics(ctx)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	return statistics, ""
 }
 
 func (s *Service) GetUserStatistics(ctx context.Context, id string) (*models.UserStats, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetUserStatistics")
+	defer span.End()
+
 	user, err := s.storage.GetUser(ctx, id)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
+	}
+	if user == nil {
+		return nil, errors.ErrorCodeNotFound
 	}
 
 	assignments_count, err := s.storage.GetUserStatistics(ctx, id)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	return &models.UserStats{
 		UserID:           user.UserID,
@@ -297,17 +593,23 @@ func (s *Service) GetUserStatistics(ctx context.Context, id string) (*models.Use
 }
 
 func (s *Service) GetTeamsStatistics(ctx context.Context) (*models.TeamsStatistics, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetTeamsStatistics")
+	defer span.End()
+
 	statistics, err := s.storage.GetTeamsStatistics(ctx)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	return statistics, ""
 }
 
 func (s *Service) GetTeamStatistics(ctx context.Context, name string) (*models.TeamStats, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetTeamStatistics")
+	defer span.End()
+
 	statistics, err := s.storage.GetTeamStatistics(ctx, name)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	if statistics == nil {
 		return nil, errors.ErrorCodeNotFound
@@ -316,9 +618,12 @@ func (s *Service) GetTeamStatistics(ctx context.Context, name string) (*models.T
 }
 
 func (s *Service) GetPRStatistics(ctx context.Context) (*models.PullRequestStatistics, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetPRStatistics")
+	defer span.End()
+
 	statistics, err := s.storage.GetPRStatistics(ctx)
 	if err != nil {
-		return nil, errors.ErrorCodeInternal
+		return nil, internalError(ctx, err)
 	}
 	return statistics, ""
 }
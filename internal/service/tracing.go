@@ -0,0 +1,7 @@
+package service
+
+import (
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("PR_reviewer_assign_service/internal/service")
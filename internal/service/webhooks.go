@@ -0,0 +1,115 @@
+package service
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/webhooks"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Webhook functions
+
+// Register new webhook
+func (s *Service) CreateWebhook(ctx context.Context, query models.WebhookCreateQuery) (*models.Webhook, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.CreateWebhook")
+	defer span.End()
+
+	// Generate secret if client didn't provide one
+	secret := query.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, internalError(ctx, err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	webhook, err := s.storage.CreateWebhook(ctx, &models.Webhook{
+		URL:    query.URL,
+		Events: query.Events,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	// Secret is shown only once, on creation
+	return webhook, ""
+}
+
+// Get all webhooks without secrets
+func (s *Service) GetWebhooks(ctx context.Context) ([]models.Webhook, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetWebhooks")
+	defer span.End()
+
+	hooks, err := s.storage.GetWebhooks(ctx)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, ""
+}
+
+// Remove webhook with its deliveries
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) errors.ErrorCode {
+	ctx, span := tracer.Start(ctx, "Service.DeleteWebhook")
+	defer span.End()
+
+	deleted, err := s.storage.DeleteWebhook(ctx, id)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if !deleted {
+		return errors.ErrorCodeNotFound
+	}
+	return ""
+}
+
+// Get deliveries that ran out of attempts
+func (s *Service) GetFailedDeliveries(ctx context.Context, webhookId int64) ([]models.WebhookDelivery, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.GetFailedDeliveries")
+	defer span.End()
+
+	deliveries, err := s.storage.GetFailedDeliveries(ctx, webhookId)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return deliveries, ""
+}
+
+// Send failed delivery again
+func (s *Service) ReplayDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, errors.ErrorCode) {
+	ctx, span := tracer.Start(ctx, "Service.ReplayDelivery")
+	defer span.End()
+
+	// Check delivery existance
+	delivery, err := s.storage.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if delivery == nil {
+		return nil, errors.ErrorCodeNotFound
+	}
+
+	// Check status
+	if delivery.Status != webhooks.StatusFailed {
+		return nil, errors.ErrorCodeDeliveryNotFailed
+	}
+
+	// Replay
+	if s.webhooks == nil {
+		return nil, errors.ErrorCodeInternal
+	}
+	replayed, err := s.webhooks.Replay(ctx, delivery)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	// Replayed concurrently
+	if !replayed {
+		return nil, errors.ErrorCodeDeliveryNotFailed
+	}
+	return delivery, ""
+}
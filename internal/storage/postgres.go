@@ -1,12 +1,14 @@
 package storage
 
 import (
+	"PR_reviewer_assign_service/internal/events"
 	"PR_reviewer_assign_service/internal/models"
 	"context"
 	"database/sql"
 	_ "embed"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -15,6 +17,15 @@ type PostgresStorage struct {
 	db *sql.DB
 }
 
+// Keys of advisory locks shared by all instances of the service
+const (
+	// Assignment of event stream positions
+	lockStreamSeq int64 = 730001
+	// Runs of background jobs
+	lockReminders  int64 = 730002
+	lockEscalation int64 = 730003
+)
+
 func NewPostgresStorage(connection string) (*PostgresStorage, error) {
 	// Open connection
 	db, err := sql.Open("postgres", connection)
@@ -32,7 +43,7 @@ func NewPostgresStorage(connection string) (*PostgresStorage, error) {
 		return nil, fmt.Errorf("failed to initialize tables: %v", err)
 	}
 
-	log.Println("Connected to PostgreSQL successfully")
+	slog.Info("Connected to PostgreSQL successfully")
 	return &PostgresStorage{db: db}, nil
 }
 
@@ -43,7 +54,7 @@ func initTables(db *sql.DB) error {
 	if _, err := db.Exec(schemaSQL); err != nil {
 		return err
 	}
-	log.Println("Database schema initialized successfully")
+	slog.Info("Database schema initialized successfully")
 	return nil
 }
 
@@ -51,8 +62,31 @@ func (p *PostgresStorage) Close() error {
 	return p.db.Close()
 }
 
+// Limits of connection pool
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func (p *PostgresStorage) SetPool(config PoolConfig) {
+	p.db.SetMaxOpenConns(config.MaxOpenConns)
+	p.db.SetMaxIdleConns(config.MaxIdleConns)
+	p.db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	p.db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
+
+// Connection pool statistics
+func (p *PostgresStorage) Stats() sql.DBStats {
+	return p.db.Stats()
+}
+
 // Team functions
 func (p *PostgresStorage) CreateTeam(ctx context.Context, team *models.Team) error {
+	ctx, span := startSpan(ctx, "CreateTeam")
+	defer span.End()
+
 	// Start a transaction
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -71,11 +105,11 @@ func (p *PostgresStorage) CreateTeam(ctx context.Context, team *models.Team) err
 	// Insert / update users
 	for _, member := range team.Members {
 		_, err := tx.ExecContext(ctx, `
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO users (user_id, username, team_name, is_active, is_team_lead)
+			VALUES ($1, $2, $3, $4, $5)
 			ON CONFLICT (user_id)
-			DO UPDATE SET username = $2, team_name = $3, is_active = $4
-		`, member.UserID, member.Username, team.TeamName, member.IsActive)
+			DO UPDATE SET username = $2, team_name = $3, is_active = $4, is_team_lead = $5
+		`, member.UserID, member.Username, team.TeamName, member.IsActive, member.IsTeamLead)
 		if err != nil {
 			return err
 		}
@@ -85,6 +119,9 @@ func (p *PostgresStorage) CreateTeam(ctx context.Context, team *models.Team) err
 }
 
 func (p *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	ctx, span := startSpan(ctx, "GetTeam")
+	defer span.End()
+
 	// Check existance
 	var teamExists bool
 	err := p.db.QueryRowContext(ctx,
@@ -104,7 +141,7 @@ func (p *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 	team.TeamName = teamName
 
 	rows, err := p.db.QueryContext(ctx, `
-		SELECT user_id, username, is_active
+		SELECT user_id, username, is_active, is_team_lead
 		FROM users
 		WHERE team_name = $1
 	`, teamName)
@@ -116,7 +153,7 @@ func (p *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 	// Make TeamMembers
 	for rows.Next() {
 		var member models.TeamMember
-		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive); err != nil {
+		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive, &member.IsTeamLead); err != nil {
 			return nil, err
 		}
 		team.Members = append(team.Members, member)
@@ -130,9 +167,12 @@ func (p *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 }
 
 func (p *PostgresStorage) GetActiveUsersInTeam(ctx context.Context, teamName string) ([]*models.User, error) {
+	ctx, span := startSpan(ctx, "GetActiveUsersInTeam")
+	defer span.End()
+
 	// Get active users in team
 	rows, err := p.db.QueryContext(ctx, `
-		SELECT user_id, username, team_name, is_active
+		SELECT user_id, username, team_name, is_active, is_team_lead
 		FROM users
 		WHERE team_name = $1 AND is_active = true	
 	`, teamName)
@@ -145,7 +185,7 @@ func (p *PostgresStorage) GetActiveUsersInTeam(ctx context.Context, teamName str
 	var users []*models.User
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.IsTeamLead); err != nil {
 			return nil, err
 		}
 		users = append(users, &user)
@@ -160,23 +200,29 @@ func (p *PostgresStorage) GetActiveUsersInTeam(ctx context.Context, teamName str
 
 // User functions
 func (p *PostgresStorage) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, span := startSpan(ctx, "CreateUser")
+	defer span.End()
+
 	// Create user
 	_, err := p.db.ExecContext(ctx, `
-		INSERT INTO users  (user_id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
-	`, user.UserID, user.Username, user.TeamName, user.IsActive)
+		INSERT INTO users  (user_id, username, team_name, is_active, is_team_lead)
+		VALUES ($1, $2, $3, $4, $5)
+	`, user.UserID, user.Username, user.TeamName, user.IsActive, user.IsTeamLead)
 
 	return err
 }
 
 func (p *PostgresStorage) GetUser(ctx context.Context, userId string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUser")
+	defer span.End()
+
 	// Get user
 	var user models.User
 	err := p.db.QueryRowContext(ctx, `
-		SELECT user_id, username, team_name, is_active
+		SELECT user_id, username, team_name, is_active, is_team_lead
 		FROM users
 		WHERE user_id = $1
-	`, userId).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+	`, userId).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.IsTeamLead)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -187,18 +233,40 @@ func (p *PostgresStorage) GetUser(ctx context.Context, userId string) (*models.U
 	return &user, nil
 }
 
-func (p *PostgresStorage) UpdateUser(ctx context.Context, user *models.User) error {
+func (p *PostgresStorage) UpdateUser(ctx context.Context, user *models.User, outbox []events.Event) error {
+	ctx, span := startSpan(ctx, "UpdateUser")
+	defer span.End()
+
+	// Create transaction
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Update user
-	_, err := p.db.ExecContext(ctx, `
+	_, err = tx.ExecContext(ctx, `
 		UPDATE users
-		SET username = $1, team_name = $2, is_active = $3
-		WHERE user_id = $4
-	`, user.Username, user.TeamName, user.IsActive, user.UserID)
-	return err
+		SET username = $1, team_name = $2, is_active = $3, is_team_lead = $4
+		WHERE user_id = $5
+	`, user.Username, user.TeamName, user.IsActive, user.IsTeamLead, user.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Save events
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // PR functions
-func (p *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest) (*models.PullRequest, error) {
+func (p *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (*models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "CreatePR")
+	defer span.End()
+
 	// Create transaction
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -218,14 +286,19 @@ func (p *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest)
 	// Insert reviewers
 	for _, reviewer := range pr.AssignedReviewers {
 		_, err = tx.ExecContext(ctx, `
-			INSERT INTO pr_reviewers (pr_id, user_id)
-			VALUES ($1, $2)
-			`, pr.PullRequestID, reviewer)
+			INSERT INTO pr_reviewers (pr_id, user_id, assigned_at)
+			VALUES ($1, $2, $3)
+			`, pr.PullRequestID, reviewer, pr.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Save events
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -234,6 +307,9 @@ func (p *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest)
 }
 
 func (p *PostgresStorage) GetPR(ctx context.Context, prId string) (*models.PullRequest, error) {
+	ctx, span := startSpan(ctx, "GetPR")
+	defer span.End()
+
 	var pr models.PullRequest
 	var mergedAt sql.NullTime
 
@@ -280,75 +356,91 @@ func (p *PostgresStorage) GetPR(ctx context.Context, prId string) (*models.PullR
 	return &pr, nil
 }
 
-func (p *PostgresStorage) UpdatePR(ctx context.Context, pr *models.PullRequest) error {
+func (p *PostgresStorage) MergePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (bool, error) {
+	ctx, span := startSpan(ctx, "MergePR")
+	defer span.End()
+
 	// Create transaction
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer tx.Rollback()
 
-	// Update pr
-	_, err = tx.ExecContext(ctx, `
+	// Merge pr, concurrent merge of the same pr waits for this one and changes nothing
+	result, err := tx.ExecContext(ctx, `
 		UPDATE pull_requests
-		SET status = $1, merged_at = $2
-		WHERE pull_request_id = $3
-	`, pr.Status, pr.MergedAt, pr.PullRequestID)
+		SET status = 'MERGED', merged_at = $1
+		WHERE pull_request_id = $2 AND status = 'OPEN'
+	`, pr.MergedAt, pr.PullRequestID)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	// Update reviewers
-	_, err = tx.ExecContext(ctx, `
-		DELETE FROM pr_reviewers WHERE pr_id = $1
-	`, pr.PullRequestID)
+	merged, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return false, err
+	}
+	if merged == 0 {
+		return false, nil
 	}
 
-	for _, reviewer := range pr.AssignedReviewers {
-		_, err := tx.ExecContext(ctx, `
-			INSERT INTO pr_reviewers (pr_id, user_id)
-			VALUES ($1, $2)
-		`, pr.PullRequestID, reviewer)
-		if err != nil {
-			return err
-		}
+	// Save events
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return false, err
 	}
 
-	return tx.Commit()
+	return true, tx.Commit()
 }
 
-func (p *PostgresStorage) GetPRsByRewiever(ctx context.Context, userId string) ([]models.PullRequestShort, error) {
+func (p *PostgresStorage) GetPRsByRewiever(ctx context.Context, userId string, filter models.ListFilter) ([]models.PullRequestShort, *models.Cursor, error) {
+	ctx, span := startSpan(ctx, "GetPRsByRewiever")
+	defer span.End()
+
+	var q listQuery
+	q.where("prr.user_id = ?", userId)
+	if filter.Status != "" {
+		q.where("pr.status = ?", filter.Status)
+	}
+	q.page(filter, "pr.created_at", "pr.pull_request_id")
+
 	rows, err := p.db.QueryContext(ctx, `
-		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at
 		FROM pull_requests pr
 		JOIN pr_reviewers prr ON pr.pull_request_id = prr.pr_id
-		WHERE prr.user_id = $1 AND pr.status != 'MERGED'
-	`, userId)
+		`+q.clauses(filter, "pr.created_at", "pr.pull_request_id"), q.args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var prs []models.PullRequestShort
+	prs := make([]models.PullRequestShort, 0, filter.Limit+1)
+	var created []models.Cursor
 	for rows.Next() {
 		var pr models.PullRequestShort
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
-			return nil, err
+		var cursor models.Cursor
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &cursor.CreatedAt); err != nil {
+			return nil, nil, err
 		}
+		cursor.ID = pr.PullRequestID
 		prs = append(prs, pr)
+		created = append(created, cursor)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return prs, nil
+	count, cursor := nextCursor(len(prs), filter.Limit, func(i int) models.Cursor {
+		return created[i]
+	})
+	return prs[:count], cursor, nil
 }
 
 // Additional functions
 func (p *PostgresStorage) GetUsersStatistics(ctx context.Context) (*models.UsersStatistics, error) {
+	ctx, span := startSpan(ctx, "GetUsersStatistics")
+	defer span.End()
+
 	var statistics models.UsersStatistics
 
 	err := p.db.QueryRowContext(ctx, `
@@ -365,6 +457,9 @@ func (p *PostgresStorage) GetUsersStatistics(ctx context.Context) (*models.Users
 }
 
 func (p *PostgresStorage) GetUserStatistics(ctx context.Context, id string) (int, error) {
+	ctx, span := startSpan(ctx, "GetUserStatistics")
+	defer span.End()
+
 	var assignments_count int
 
 	err := p.db.QueryRowContext(ctx, `
@@ -380,6 +475,9 @@ func (p *PostgresStorage) GetUserStatistics(ctx context.Context, id string) (int
 }
 
 func (p *PostgresStorage) GetTeamsStatistics(ctx context.Context) (*models.TeamsStatistics, error) {
+	ctx, span := startSpan(ctx, "GetTeamsStatistics")
+	defer span.End()
+
 	var statistics models.TeamsStatistics
 
 	err := p.db.QueryRowContext(ctx, `
@@ -394,6 +492,9 @@ func (p *PostgresStorage) GetTeamsStatistics(ctx context.Context) (*models.Teams
 }
 
 func (p *PostgresStorage) GetTeamStatistics(ctx context.Context, name string) (*models.TeamStats, error) {
+	ctx, span := startSpan(ctx, "GetTeamStatistics")
+	defer span.End()
+
 	// Get team
 	team, err := p.GetTeam(ctx, name)
 	if err != nil {
@@ -412,35 +513,42 @@ func (p *PostgresStorage) GetTeamStatistics(ctx context.Context, name string) (*
 	}
 
 	// Count all prs
-	err = p.db.QueryRowContext(ctx, `
+	queryCtx, querySpan := startSpan(ctx, "GetTeamStatistics.CountPRs")
+	err = p.db.QueryRowContext(queryCtx, `
 		SELECT Count(*)
 		FROM pull_requests pr
 		JOIN users u ON pr.author_id = u.user_id
 		WHERE u.team_name = $1
 	`, name).Scan(&statistics.PullRequestsTotal)
+	querySpan.End()
 	if err != nil {
 		return nil, err
 	}
 
 	// Count active prs
-	err = p.db.QueryRowContext(ctx, `
+	queryCtx, querySpan = startSpan(ctx, "GetTeamStatistics.CountOpenPRs")
+	err = p.db.QueryRowContext(queryCtx, `
 		SELECT Count(*)
 		FROM pull_requests pr
 		JOIN users u ON pr.author_id = u.user_id
 		WHERE u.team_name = $1 AND pr.status = 'OPEN'
 	`, name).Scan(&statistics.ActivePullRequestsTotal)
+	querySpan.End()
 	if err != nil {
 		return nil, err
 	}
 
-	// Get snippet of teams's prs ids
-	rows, err := p.db.QueryContext(ctx, `
+	// Get ids of the latest team's prs, all of them are listed by /pull-requests
+	queryCtx, querySpan = startSpan(ctx, "GetTeamStatistics.ListPRs")
+	defer querySpan.End()
+	rows, err := p.db.QueryContext(queryCtx, `
 		SELECT pr.pull_request_id
 		FROM pull_requests pr
 		JOIN users u ON pr.author_id = u.user_id
 		WHERE u.team_name = $1
-		LIMIT 20
-	`, name)
+		ORDER BY pr.created_at DESC, pr.pull_request_id DESC
+		LIMIT $2
+	`, name, models.TeamStatsPRLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -462,6 +570,9 @@ func (p *PostgresStorage) GetTeamStatistics(ctx context.Context, name string) (*
 }
 
 func (p *PostgresStorage) GetPRStatistics(ctx context.Context) (*models.PullRequestStatistics, error) {
+	ctx, span := startSpan(ctx, "GetPRStatistics")
+	defer span.End()
+
 	var statistics models.PullRequestStatistics
 
 	err := p.db.QueryRowContext(ctx, `
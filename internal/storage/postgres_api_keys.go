@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// API key functions
+func (p *PostgresStorage) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	ctx, span := startSpan(ctx, "CreateAPIKey")
+	defer span.End()
+
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, key_hash, scopes)
+		VALUES ($1, $2, $3)
+		RETURNING key_id, created_at
+	`, key.Name, key.KeyHash, pq.Array(key.Scopes)).Scan(&key.KeyID, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (p *PostgresStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ctx, span := startSpan(ctx, "GetAPIKeyByHash")
+	defer span.End()
+
+	var key models.APIKey
+	err := p.db.QueryRowContext(ctx, `
+		SELECT key_id, name, key_hash, scopes, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1
+	`, hash).Scan(&key.KeyID, &key.Name, &key.KeyHash, pq.Array(&key.Scopes), &key.CreatedAt, &key.RevokedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (p *PostgresStorage) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, span := startSpan(ctx, "GetAPIKeys")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT key_id, name, key_hash, scopes, created_at, revoked_at
+		FROM api_keys
+		ORDER BY key_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.KeyID, &key.Name, &key.KeyHash, pq.Array(&key.Scopes), &key.CreatedAt, &key.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Revoke key, returns false if there is no active key with id
+func (p *PostgresStorage) RevokeAPIKey(ctx context.Context, id int64) (bool, error) {
+	ctx, span := startSpan(ctx, "RevokeAPIKey")
+	defer span.End()
+
+	result, err := p.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE key_id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return revoked > 0, nil
+}
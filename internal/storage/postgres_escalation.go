@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Reviewer history functions
+func (p *PostgresStorage) ReplaceReviewer(ctx context.Context, change *models.ReviewerChange, outbox []events.Event) error {
+	ctx, span := startSpan(ctx, "ReplaceReviewer")
+	defer span.End()
+
+	// Create transaction
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Replace reviewer, new one starts review now
+	result, err := tx.ExecContext(ctx, `
+		UPDATE pr_reviewers
+		SET user_id = $1, assigned_at = $2
+		WHERE pr_id = $3 AND user_id = $4
+	`, change.NewReviewerID, change.ChangedAt, change.PullRequestID, change.OldReviewerID)
+	if err != nil {
+		return err
+	}
+	replaced, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if replaced == 0 {
+		return fmt.Errorf("reviewer %s is not assigned to pr %s", change.OldReviewerID, change.PullRequestID)
+	}
+
+	// Save history
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewer_history (pr_id, old_user_id, new_user_id, reason, changed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, change.PullRequestID, change.OldReviewerID, change.NewReviewerID, change.Reason, change.ChangedAt)
+	if err != nil {
+		return err
+	}
+
+	// Save events
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgresStorage) GetReviewerHistory(ctx context.Context, prId string) ([]models.ReviewerChange, error) {
+	ctx, span := startSpan(ctx, "GetReviewerHistory")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT pr_id, old_user_id, new_user_id, reason, changed_at
+		FROM pr_reviewer_history
+		WHERE pr_id = $1
+		ORDER BY id
+	`, prId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.ReviewerChange
+	for rows.Next() {
+		var change models.ReviewerChange
+		if err := rows.Scan(&change.PullRequestID, &change.OldReviewerID, &change.NewReviewerID, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Escalation functions
+func (p *PostgresStorage) GetStaleReviews(ctx context.Context, now time.Time) ([]models.StaleReview, error) {
+	ctx, span := startSpan(ctx, "GetStaleReviews")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT prr.pr_id, prr.user_id, ts.team_name, prr.assigned_at, ts.max_escalations,
+			(SELECT COUNT(*) FROM pr_reviewer_history h WHERE h.pr_id = prr.pr_id AND h.reason = $1) AS escalations
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.pull_request_id = prr.pr_id
+		JOIN users u ON pr.author_id = u.user_id
+		JOIN team_settings ts ON ts.team_name = u.team_name
+		WHERE pr.status = 'OPEN'
+			AND ts.auto_escalate = true
+			AND prr.assigned_at + make_interval(hours => ts.escalate_after_hours) < $2
+		ORDER BY prr.assigned_at, prr.pr_id, prr.user_id
+	`, models.ReassignReasonEscalation, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []models.StaleReview
+	for rows.Next() {
+		var review models.StaleReview
+		if err := rows.Scan(&review.PullRequestID, &review.ReviewerID, &review.TeamName, &review.AssignedAt,
+			&review.MaxEscalations, &review.Escalations); err != nil {
+			return nil, err
+		}
+		// PRs that used all escalations are left as is
+		if review.Escalations >= review.MaxEscalations {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
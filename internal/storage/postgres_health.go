@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Health functions
+func (p *PostgresStorage) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "Ping")
+	defer span.End()
+
+	return p.db.PingContext(ctx)
+}
+
+// Latest applied schema version, 0 if none
+func (p *PostgresStorage) GetSchemaVersion(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "GetSchemaVersion")
+	defer span.End()
+
+	var version sql.NullInt64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT MAX(version) FROM schema_migrations
+	`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
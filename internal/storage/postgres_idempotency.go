@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Idempotency functions
+
+// Reserve key for request, returns false if there is unexpired record with the key
+func (p *PostgresStorage) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	ctx, span := startSpan(ctx, "CreateIdempotencyRecord")
+	defer span.End()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Expired key may be used again
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2 AND expires_at <= $3
+	`, record.Scope, record.Key, record.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, idempotency_key) DO NOTHING
+	`, record.Scope, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return created > 0, tx.Commit()
+}
+
+func (p *PostgresStorage) GetIdempotencyRecord(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	ctx, span := startSpan(ctx, "GetIdempotencyRecord")
+	defer span.End()
+
+	var record models.IdempotencyRecord
+	err := p.db.QueryRowContext(ctx, `
+		SELECT scope, idempotency_key, request_hash, status_code, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2
+	`, scope, key).Scan(&record.Scope, &record.Key, &record.RequestHash, &record.StatusCode,
+		&record.Response, &record.CreatedAt, &record.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Save response of finished request
+func (p *PostgresStorage) CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	ctx, span := startSpan(ctx, "CompleteIdempotencyRecord")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $1, response = $2
+		WHERE scope = $3 AND idempotency_key = $4
+	`, record.StatusCode, record.Response, record.Scope, record.Key)
+	return err
+}
+
+func (p *PostgresStorage) DeleteIdempotencyRecord(ctx context.Context, scope, key string) error {
+	ctx, span := startSpan(ctx, "DeleteIdempotencyRecord")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2
+	`, scope, key)
+	return err
+}
+
+func (p *PostgresStorage) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "DeleteExpiredIdempotencyRecords")
+	defer span.End()
+
+	result, err := p.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE expires_at <= $1
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
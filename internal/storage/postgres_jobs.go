@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// Background job functions
+
+// Session advisory lock is held by one connection while fn runs, it's released
+// by PostgreSQL if the instance dies in the middle of the job
+func (p *PostgresStorage) RunExclusive(ctx context.Context, job string, fn func(ctx context.Context)) (bool, error) {
+	ctx, span := startSpan(ctx, "RunExclusive")
+	defer span.End()
+
+	var key int64
+	switch job {
+	case JobReminders:
+		key = lockReminders
+	case JobEscalation:
+		key = lockEscalation
+	default:
+		return false, fmt.Errorf("unknown job %q", job)
+	}
+
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		// Unlock even if job is cancelled, connection that keeps the lock is closed instead of going back to pool
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	fn(ctx)
+	return true, nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Conditions of list query, "?" in condition is replaced by positional argument
+type listQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (q *listQuery) where(condition string, args ...interface{}) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(q.args)), 1)
+	}
+	q.conditions = append(q.conditions, condition)
+}
+
+// Cursor and date range of filter, columns are the ordering key of list
+func (q *listQuery) page(filter models.ListFilter, createdAt, id string) {
+	if filter.After != nil {
+		q.where(fmt.Sprintf("(%s, %s) < (?, ?)", createdAt, id), filter.After.CreatedAt, filter.After.ID)
+	}
+	if filter.CreatedFrom != nil {
+		q.where(createdAt+" >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.where(createdAt+" < ?", *filter.CreatedTo)
+	}
+}
+
+// WHERE, ORDER BY and LIMIT clauses, one row more than limit shows there is next page
+func (q *listQuery) clauses(filter models.ListFilter, createdAt, id string) string {
+	var clauses strings.Builder
+	if len(q.conditions) > 0 {
+		clauses.WriteString("WHERE " + strings.Join(q.conditions, " AND ") + "\n")
+	}
+	fmt.Fprintf(&clauses, "ORDER BY %s DESC, %s DESC\nLIMIT %d", createdAt, id, filter.Limit+1)
+	return clauses.String()
+}
+
+// Cut extra row of page, cursor of the last item is returned if there are more
+func nextCursor(count, limit int, last func(i int) models.Cursor) (int, *models.Cursor) {
+	if count <= limit {
+		return count, nil
+	}
+	cursor := last(limit - 1)
+	return limit, &cursor
+}
+
+// List functions
+func (p *PostgresStorage) ListTeams(ctx context.Context, filter models.ListFilter) ([]models.TeamSummary, *models.Cursor, error) {
+	ctx, span := startSpan(ctx, "ListTeams")
+	defer span.End()
+
+	var q listQuery
+	q.page(filter, "t.created_at", "t.team_name")
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT t.team_name, t.created_at,
+			(SELECT COUNT(*) FROM users u WHERE u.team_name = t.team_name)
+		FROM teams t
+		`+q.clauses(filter, "t.created_at", "t.team_name"), q.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]models.TeamSummary, 0, filter.Limit+1)
+	for rows.Next() {
+		var team models.TeamSummary
+		if err := rows.Scan(&team.TeamName, &team.CreatedAt, &team.MembersTotal); err != nil {
+			return nil, nil, err
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	count, cursor := nextCursor(len(teams), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: teams[i].CreatedAt, ID: teams[i].TeamName}
+	})
+	return teams[:count], cursor, nil
+}
+
+func (p *PostgresStorage) ListUsers(ctx context.Context, filter models.ListFilter) ([]models.User, *models.Cursor, error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer span.End()
+
+	var q listQuery
+	if filter.TeamName != "" {
+		q.where("team_name = ?", filter.TeamName)
+	}
+	q.page(filter, "created_at", "user_id")
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT user_id, username, team_name, is_active, is_team_lead, created_at
+		FROM users
+		`+q.clauses(filter, "created_at", "user_id"), q.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, filter.Limit+1)
+	var created []models.Cursor
+	for rows.Next() {
+		var user models.User
+		var cursor models.Cursor
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.IsTeamLead, &cursor.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		cursor.ID = user.UserID
+		users = append(users, user)
+		created = append(created, cursor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	count, cursor := nextCursor(len(users), filter.Limit, func(i int) models.Cursor {
+		return created[i]
+	})
+	return users[:count], cursor, nil
+}
+
+func (p *PostgresStorage) ListPRs(ctx context.Context, filter models.ListFilter) ([]models.PullRequest, *models.Cursor, error) {
+	ctx, span := startSpan(ctx, "ListPRs")
+	defer span.End()
+
+	var q listQuery
+	if filter.Status != "" {
+		q.where("pr.status = ?", filter.Status)
+	}
+	if filter.AuthorID != "" {
+		q.where("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.TeamName != "" {
+		q.where("u.team_name = ?", filter.TeamName)
+	}
+	q.page(filter, "pr.created_at", "pr.pull_request_id")
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at,
+			ARRAY(SELECT prr.user_id FROM pr_reviewers prr WHERE prr.pr_id = pr.pull_request_id ORDER BY prr.user_id)
+		FROM pull_requests pr
+		JOIN users u ON pr.author_id = u.user_id
+		`+q.clauses(filter, "pr.created_at", "pr.pull_request_id"), q.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	prs := make([]models.PullRequest, 0, filter.Limit+1)
+	for rows.Next() {
+		var pr models.PullRequest
+		var mergedAt sql.NullTime
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt, pq.Array(&pr.AssignedReviewers)); err != nil {
+			return nil, nil, err
+		}
+		if mergedAt.Valid {
+			pr.MergedAt = &mergedAt.Time
+		}
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	count, cursor := nextCursor(len(prs), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: prs[i].CreatedAt, ID: prs[i].PullRequestID}
+	})
+	return prs[:count], cursor, nil
+}
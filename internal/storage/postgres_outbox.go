@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Save events in the transaction of the changes they describe
+func insertOutboxEvents(ctx context.Context, tx *sql.Tx, outbox []events.Event) error {
+	for _, event := range outbox {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		userIds := event.UserIDs
+		if userIds == nil {
+			userIds = []string{}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO outbox_events (event_id, event_type, team_name, user_ids, payload)
+			VALUES ($1, $2, $3, $4, $5)
+		`, event.ID, string(event.Type), event.TeamName, pq.Array(userIds), payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Outbox functions
+
+// Claimed events are moved to the end of lease, rows locked by other instances are skipped
+func (p *PostgresStorage) ClaimUnpublishedEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	ctx, span := startSpan(ctx, "ClaimUnpublishedEvents")
+	defer span.End()
+
+	outbox, err := p.queryOutboxEvents(ctx, `
+		UPDATE outbox_events
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE seq IN (
+			SELECT seq
+			FROM outbox_events
+			WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY seq
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING seq, COALESCE(stream_seq, 0), event_id, event_type, team_name, user_ids, payload, attempts, created_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING has no order
+	sort.Slice(outbox, func(i, j int) bool { return outbox[i].Seq < outbox[j].Seq })
+	return outbox, nil
+}
+
+// Published events after given stream position, filtered by team and user if they are set
+func (p *PostgresStorage) GetPublishedEvents(ctx context.Context, afterStreamSeq int64, filter models.EventStreamQuery, limit int) ([]models.OutboxEvent, error) {
+	ctx, span := startSpan(ctx, "GetPublishedEvents")
+	defer span.End()
+
+	return p.queryOutboxEvents(ctx, `
+		SELECT seq, stream_seq, event_id, event_type, team_name, user_ids, payload, attempts, created_at
+		FROM outbox_events
+		WHERE stream_seq > $1
+			AND ($2::VARCHAR = '' OR team_name = $2::VARCHAR)
+			AND ($3::TEXT = '' OR $3::TEXT = ANY(user_ids))
+		ORDER BY stream_seq
+		LIMIT $4
+	`, afterStreamSeq, filter.TeamName, filter.UserID, limit)
+}
+
+func (p *PostgresStorage) GetLastStreamSeq(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "GetLastStreamSeq")
+	defer span.End()
+
+	var seq int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(stream_seq), 0) FROM outbox_events
+	`).Scan(&seq)
+	if err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+func (p *PostgresStorage) queryOutboxEvents(ctx context.Context, query string, args ...interface{}) ([]models.OutboxEvent, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outbox []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(&event.Seq, &event.StreamSeq, &event.EventID, &event.EventType, &event.TeamName,
+			pq.Array(&event.UserIDs), &event.Payload, &event.Attempts, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		outbox = append(outbox, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return outbox, nil
+}
+
+// Stream position is taken under a lock held until commit, so positions become visible in order:
+// readers of stream_seq > N never miss a smaller position committed later by another dispatcher
+func (p *PostgresStorage) MarkEventPublished(ctx context.Context, seq int64) error {
+	ctx, span := startSpan(ctx, "MarkEventPublished")
+	defer span.End()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockStreamSeq); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET published_at = CURRENT_TIMESTAMP, stream_seq = nextval('outbox_stream_seq'), attempts = attempts + 1, last_error = ''
+		WHERE seq = $1 AND published_at IS NULL
+	`, seq)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *PostgresStorage) MarkEventFailed(ctx context.Context, seq int64, reason string, retryAfter time.Duration) error {
+	ctx, span := startSpan(ctx, "MarkEventFailed")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE seq = $3
+	`, reason, retryAfter.Seconds(), seq)
+	return err
+}
+
+func (p *PostgresStorage) MarkEventDead(ctx context.Context, seq int64, reason string) error {
+	ctx, span := startSpan(ctx, "MarkEventDead")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1, dead_at = CURRENT_TIMESTAMP
+		WHERE seq = $2
+	`, reason, seq)
+	return err
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Team settings functions
+func (p *PostgresStorage) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	ctx, span := startSpan(ctx, "GetTeamSettings")
+	defer span.End()
+
+	var settings models.TeamSettings
+	err := p.db.QueryRowContext(ctx, `
+		SELECT team_name, review_sla_hours, auto_escalate, escalate_after_hours, max_escalations
+		FROM team_settings
+		WHERE team_name = $1
+	`, teamName).Scan(&settings.TeamName, &settings.ReviewSLAHours, &settings.AutoEscalate,
+		&settings.EscalateAfterHours, &settings.MaxEscalations)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (p *PostgresStorage) SetTeamSettings(ctx context.Context, settings *models.TeamSettings) error {
+	ctx, span := startSpan(ctx, "SetTeamSettings")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO team_settings (team_name, review_sla_hours, auto_escalate, escalate_after_hours, max_escalations)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (team_name)
+		DO UPDATE SET review_sla_hours = $2, auto_escalate = $3, escalate_after_hours = $4, max_escalations = $5,
+			updated_at = CURRENT_TIMESTAMP
+	`, settings.TeamName, settings.ReviewSLAHours, settings.AutoEscalate, settings.EscalateAfterHours, settings.MaxEscalations)
+	return err
+}
+
+// Reminder functions
+func (p *PostgresStorage) GetOverduePRs(ctx context.Context, defaultSLAHours int, now time.Time, teamName string) ([]models.OverduePR, error) {
+	ctx, span := startSpan(ctx, "GetOverduePRs")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, u.team_name, pr.created_at,
+			COALESCE(ts.review_sla_hours, $1) AS review_sla_hours,
+			COALESCE(r.reminders_sent, 0), r.last_reminded_at,
+			ARRAY(SELECT prr.user_id FROM pr_reviewers prr WHERE prr.pr_id = pr.pull_request_id ORDER BY prr.user_id)
+		FROM pull_requests pr
+		JOIN users u ON pr.author_id = u.user_id
+		LEFT JOIN team_settings ts ON ts.team_name = u.team_name
+		LEFT JOIN pr_reminders r ON r.pr_id = pr.pull_request_id
+		WHERE pr.status = 'OPEN'
+			AND pr.created_at + make_interval(hours => COALESCE(ts.review_sla_hours, $1)) < $2
+			AND ($3::VARCHAR = '' OR u.team_name = $3::VARCHAR)
+		ORDER BY pr.created_at, pr.pull_request_id
+	`, defaultSLAHours, now, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.OverduePR
+	for rows.Next() {
+		var pr models.OverduePR
+		var lastRemindedAt sql.NullTime
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName, &pr.CreatedAt,
+			&pr.ReviewSLAHours, &pr.RemindersSent, &lastRemindedAt, pq.Array(&pr.AssignedReviewers)); err != nil {
+			return nil, err
+		}
+		if lastRemindedAt.Valid {
+			pr.LastRemindedAt = &lastRemindedAt.Time
+		}
+		prs = append(prs, pr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prs, nil
+}
+
+func (p *PostgresStorage) RecordReminder(ctx context.Context, prId string, at time.Time) (int, error) {
+	ctx, span := startSpan(ctx, "RecordReminder")
+	defer span.End()
+
+	var sent int
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO pr_reminders (pr_id, reminders_sent, last_reminded_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (pr_id)
+		DO UPDATE SET reminders_sent = pr_reminders.reminders_sent + 1, last_reminded_at = $2
+		RETURNING reminders_sent
+	`, prId, at).Scan(&sent)
+	if err != nil {
+		return 0, err
+	}
+	return sent, nil
+}
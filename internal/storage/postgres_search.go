@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Sort keys of search, keys of time sorts are kept in cursor as RFC 3339.
+// Unmerged prs have the smallest merged_at, so they are last in descending order.
+var searchSorts = map[string]struct {
+	expr   string
+	isTime bool
+}{
+	"created_at": {expr: "pr.created_at", isTime: true},
+	"merged_at":  {expr: "COALESCE(pr.merged_at, 'epoch'::timestamp)", isTime: true},
+	"name":       {expr: "pr.pull_request_name"},
+}
+
+// Escape LIKE wildcards, so name is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (p *PostgresStorage) SearchPRs(ctx context.Context, filter models.PRSearchFilter) ([]models.PullRequest, *models.SearchCursor, error) {
+	ctx, span := startSpan(ctx, "SearchPRs")
+	defer span.End()
+
+	sort, ok := searchSorts[filter.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+
+	var q listQuery
+	// Trigram index is used for substring search
+	if filter.Name != "" {
+		q.where("pr.pull_request_name ILIKE ?", "%"+likeEscaper.Replace(filter.Name)+"%")
+	}
+	if filter.AuthorID != "" {
+		q.where("pr.author_id = ?", filter.AuthorID)
+	}
+	if filter.ReviewerID != "" {
+		q.where("EXISTS (SELECT 1 FROM pr_reviewers prr WHERE prr.pr_id = pr.pull_request_id AND prr.user_id = ?)", filter.ReviewerID)
+	}
+	if filter.TeamName != "" {
+		q.where("u.team_name = ?", filter.TeamName)
+	}
+	if filter.Status != "" {
+		q.where("pr.status = ?", filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		q.where("pr.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.where("pr.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.MergedFrom != nil {
+		q.where("pr.merged_at >= ?", *filter.MergedFrom)
+	}
+	if filter.MergedTo != nil {
+		q.where("pr.merged_at < ?", *filter.MergedTo)
+	}
+
+	order, compare := "ASC", ">"
+	if filter.Descending {
+		order, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		var key interface{} = filter.After.Key
+		if sort.isTime {
+			t, err := time.Parse(time.RFC3339Nano, filter.After.Key)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cursor key: %w", err)
+			}
+			key = t
+		}
+		q.where(fmt.Sprintf("(%s, pr.pull_request_id) %s (?, ?)", sort.expr, compare), key, filter.After.ID)
+	}
+
+	where := ""
+	if len(q.conditions) > 0 {
+		where = "WHERE " + strings.Join(q.conditions, " AND ")
+	}
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at,
+			ARRAY(SELECT prr.user_id FROM pr_reviewers prr WHERE prr.pr_id = pr.pull_request_id ORDER BY prr.user_id)
+		FROM pull_requests pr
+		JOIN users u ON pr.author_id = u.user_id
+		%[2]s
+		ORDER BY %[1]s %[3]s, pr.pull_request_id %[3]s
+		LIMIT %[4]d
+	`, sort.expr, where, order, filter.Limit+1), q.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	prs := make([]models.PullRequest, 0, filter.Limit+1)
+	var keys []string
+	for rows.Next() {
+		var pr models.PullRequest
+		var mergedAt sql.NullTime
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt, pq.Array(&pr.AssignedReviewers)); err != nil {
+			return nil, nil, err
+		}
+		if mergedAt.Valid {
+			pr.MergedAt = &mergedAt.Time
+		}
+		prs = append(prs, pr)
+		switch filter.Sort {
+		case "created_at":
+			keys = append(keys, pr.CreatedAt.UTC().Format(time.RFC3339Nano))
+		case "merged_at":
+			// Same value as COALESCE in sort expression
+			mergedKey := time.Unix(0, 0)
+			if mergedAt.Valid {
+				mergedKey = mergedAt.Time
+			}
+			keys = append(keys, mergedKey.UTC().Format(time.RFC3339Nano))
+		default:
+			keys = append(keys, pr.PullRequestName)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(prs) <= filter.Limit {
+		return prs, nil, nil
+	}
+	last := filter.Limit - 1
+	return prs[:filter.Limit], &models.SearchCursor{
+		Sort:       filter.Sort,
+		Descending: filter.Descending,
+		Key:        keys[last],
+		ID:         prs[last].PullRequestID,
+	}, nil
+}
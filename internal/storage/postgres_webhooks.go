@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook functions
+func (p *PostgresStorage) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "CreateWebhook")
+	defer span.End()
+
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO webhooks (url, events, secret)
+		VALUES ($1, $2, $3)
+		RETURNING webhook_id, created_at
+	`, webhook.URL, pq.Array(webhook.Events), webhook.Secret).Scan(&webhook.WebhookID, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (p *PostgresStorage) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "GetWebhook")
+	defer span.End()
+
+	var webhook models.Webhook
+	err := p.db.QueryRowContext(ctx, `
+		SELECT webhook_id, url, events, secret, created_at
+		FROM webhooks
+		WHERE webhook_id = $1
+	`, id).Scan(&webhook.WebhookID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Secret, &webhook.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (p *PostgresStorage) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	ctx, span := startSpan(ctx, "GetWebhooks")
+	defer span.End()
+
+	return p.queryWebhooks(ctx, `
+		SELECT webhook_id, url, events, secret, created_at
+		FROM webhooks
+		ORDER BY webhook_id
+	`)
+}
+
+func (p *PostgresStorage) GetWebhooksForEvent(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	ctx, span := startSpan(ctx, "GetWebhooksForEvent")
+	defer span.End()
+
+	return p.queryWebhooks(ctx, `
+		SELECT webhook_id, url, events, secret, created_at
+		FROM webhooks
+		WHERE $1 = ANY(events)
+		ORDER BY webhook_id
+	`, eventType)
+}
+
+func (p *PostgresStorage) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var webhook models.Webhook
+		if err := rows.Scan(&webhook.WebhookID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Secret, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (p *PostgresStorage) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteWebhook")
+	defer span.End()
+
+	result, err := p.db.ExecContext(ctx, `
+		DELETE FROM webhooks WHERE webhook_id = $1
+	`, id)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+// Delivery functions
+// Returns nil if delivery of the event to the webhook already exists
+func (p *PostgresStorage) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "CreateDelivery")
+	defer span.End()
+
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+		RETURNING delivery_id, created_at, updated_at
+	`, delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status,
+	).Scan(&delivery.DeliveryID, &delivery.CreatedAt, &delivery.UpdatedAt)
+
+	// Event was already delivered to this webhook
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (p *PostgresStorage) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "GetDelivery")
+	defer span.End()
+
+	var delivery models.WebhookDelivery
+	err := p.db.QueryRowContext(ctx, `
+		SELECT delivery_id, webhook_id, event_id, event_type, payload, status, attempts, last_error, created_at, updated_at, run_start_attempts
+		FROM webhook_deliveries
+		WHERE delivery_id = $1
+	`, id).Scan(&delivery.DeliveryID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.RunStartAttempts)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Get failed deliveries, of all webhooks if webhookId is 0
+func (p *PostgresStorage) GetFailedDeliveries(ctx context.Context, webhookId int64) ([]models.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "GetFailedDeliveries")
+	defer span.End()
+
+	return p.queryDeliveries(ctx, `
+		SELECT delivery_id, webhook_id, event_id, event_type, payload, status, attempts, last_error, created_at, updated_at, run_start_attempts
+		FROM webhook_deliveries
+		WHERE status = 'FAILED' AND ($1::BIGINT = 0 OR webhook_id = $1::BIGINT)
+		ORDER BY delivery_id
+	`, webhookId)
+}
+
+// Claimed deliveries are moved to the end of lease, rows locked by other workers are skipped
+func (p *PostgresStorage) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "ClaimDueDeliveries")
+	defer span.End()
+
+	return p.queryDeliveries(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE delivery_id IN (
+			SELECT delivery_id
+			FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at, delivery_id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING delivery_id, webhook_id, event_id, event_type, payload, status, attempts, last_error, created_at, updated_at, run_start_attempts
+	`, limit, lease.Seconds())
+}
+
+func (p *PostgresStorage) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(&delivery.DeliveryID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+			&delivery.Status, &delivery.Attempts, &delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.RunStartAttempts); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (p *PostgresStorage) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, span := startSpan(ctx, "UpdateDelivery")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_error = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $4
+	`, delivery.Status, delivery.Attempts, delivery.LastError, delivery.DeliveryID)
+	return err
+}
+
+// Keep failed delivery pending until retryAfter passes
+func (p *PostgresStorage) RetryDelivery(ctx context.Context, delivery *models.WebhookDelivery, retryAfter time.Duration) error {
+	ctx, span := startSpan(ctx, "RetryDelivery")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = $1, last_error = $2, updated_at = CURRENT_TIMESTAMP,
+			next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE delivery_id = $4
+	`, delivery.Attempts, delivery.LastError, retryAfter.Seconds(), delivery.DeliveryID)
+	return err
+}
+
+// Start new run of failed delivery, it is due at once. Returns false if delivery isn't failed
+func (p *PostgresStorage) ReplayDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	ctx, span := startSpan(ctx, "ReplayDelivery")
+	defer span.End()
+
+	err := p.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', run_start_attempts = attempts, updated_at = CURRENT_TIMESTAMP, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $1 AND status = 'FAILED'
+		RETURNING status, run_start_attempts, updated_at
+	`, delivery.DeliveryID).Scan(&delivery.Status, &delivery.RunStartAttempts, &delivery.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *PostgresStorage) AddDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	ctx, span := startSpan(ctx, "AddDeliveryAttempt")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error)
+		VALUES ($1, $2, $3, $4)
+	`, attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error)
+	return err
+}
@@ -29,4 +29,142 @@ CREATE TABLE IF NOT EXISTS pr_reviewers (
 
 CREATE INDEX IF NOT EXISTS idx_users_team_active ON users(team_name, is_active);
 CREATE INDEX IF NOT EXISTS idx_prs_status ON pull_requests(status);
-CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user ON pr_reviewers(user_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user ON pr_reviewers(user_id);
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(webhook_id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(delivery_id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (delivery_id, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    seq BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events(seq) WHERE published_at IS NULL;
+
+-- Order in which events were published, used as SSE event id
+CREATE SEQUENCE IF NOT EXISTS outbox_stream_seq;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS stream_seq BIGINT UNIQUE;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS team_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS user_ids TEXT[] NOT NULL DEFAULT '{}';
+
+-- Failed events are retried with backoff, dead ones stopped after max attempts
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE published_at IS NULL AND dead_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name VARCHAR(100) PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    review_sla_hours INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pr_reminders (
+    pr_id VARCHAR(50) PRIMARY KEY REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reminders_sent INT NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_prs_status_created ON pull_requests(status, created_at);
+
+-- Automatic escalation of stale reviews
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS auto_escalate BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS escalate_after_hours INT NOT NULL DEFAULT 24;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS max_escalations INT NOT NULL DEFAULT 1;
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS pr_reviewer_history (
+    id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(50) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    old_user_id VARCHAR(50) NOT NULL REFERENCES users(user_id),
+    new_user_id VARCHAR(50) NOT NULL REFERENCES users(user_id),
+    reason VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewer_history_pr ON pr_reviewer_history(pr_id, reason);
+
+-- API keys, only SHA-256 hashes of keys are stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Team leads manage members and pull requests of their team
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_team_lead BOOLEAN NOT NULL DEFAULT false;
+
+-- Responses of requests with Idempotency-Key, status_code 0 while request is in progress
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    response BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+-- Applied schema versions, readiness checks that the latest one is present
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keyset pagination of lists, newest first
+CREATE INDEX IF NOT EXISTS idx_prs_created_id ON pull_requests(created_at DESC, pull_request_id DESC);
+CREATE INDEX IF NOT EXISTS idx_users_created_id ON users(created_at DESC, user_id DESC);
+CREATE INDEX IF NOT EXISTS idx_teams_created_name ON teams(created_at DESC, team_name DESC);
+
+-- Search of pull requests, trigram index serves ILIKE on name substrings
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_prs_name_trgm ON pull_requests USING gin (pull_request_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_prs_author_created ON pull_requests(author_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_prs_merged ON pull_requests(merged_at DESC, pull_request_id DESC) WHERE merged_at IS NOT NULL;
+
+-- Pending deliveries wait for their next attempt, replay starts a new run of attempts
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS run_start_attempts INT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+
+INSERT INTO schema_migrations (version) VALUES (5) ON CONFLICT DO NOTHING;
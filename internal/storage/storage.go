@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"PR_reviewer_assign_service/internal/events"
 	"PR_reviewer_assign_service/internal/models"
 	"context"
+	"time"
 )
 
 // Interface for different types of storage (possibly not just PostgreSQL)
@@ -11,24 +13,104 @@ type Storage interface {
 	CreateTeam(ctx context.Context, team *models.Team) error
 	GetTeam(ctx context.Context, teamName string) (*models.Team, error)
 	GetActiveUsersInTeam(ctx context.Context, team_name string) ([]*models.User, error)
+	GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	SetTeamSettings(ctx context.Context, settings *models.TeamSettings) error
 
 	// User functions
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUser(ctx context.Context, userId string) (*models.User, error)
-	UpdateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User, outbox []events.Event) error
 
 	// Pull Request functions
-	CreatePR(ctx context.Context, pr *models.PullRequest) (*models.PullRequest, error)
+	// Events from outbox are saved in the same transaction as the changes
+	CreatePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (*models.PullRequest, error)
 	GetPR(ctx context.Context, prId string) (*models.PullRequest, error)
-	UpdatePR(ctx context.Context, pr *models.PullRequest) error
-	GetPRsByRewiever(ctx context.Context, userId string) ([]models.PullRequestShort, error)
+	// Merge pr if it's still open, events are saved only by the merge that changed it
+	MergePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (bool, error)
+	// Replace one reviewer and save the change in history
+	ReplaceReviewer(ctx context.Context, change *models.ReviewerChange, outbox []events.Event) error
+	GetReviewerHistory(ctx context.Context, prId string) ([]models.ReviewerChange, error)
+	// Reviewers of open prs in teams with auto escalation, assigned longer ago than team allows
+	GetStaleReviews(ctx context.Context, now time.Time) ([]models.StaleReview, error)
+	// Page of prs assigned to user, cursor of next page is nil on the last one
+	GetPRsByRewiever(ctx context.Context, userId string, filter models.ListFilter) ([]models.PullRequestShort, *models.Cursor, error)
+	// Open PRs older than SLA of their team (defaultSLAHours if team has no settings)
+	GetOverduePRs(ctx context.Context, defaultSLAHours int, now time.Time, teamName string) ([]models.OverduePR, error)
+	// Count reminder about pr, returns number of sent reminders
+	RecordReminder(ctx context.Context, prId string, at time.Time) (int, error)
 
 	Close() error
 
+	// List functions, newest first by (created_at, id), cursor of next page is nil on the last one
+	ListTeams(ctx context.Context, filter models.ListFilter) ([]models.TeamSummary, *models.Cursor, error)
+	ListUsers(ctx context.Context, filter models.ListFilter) ([]models.User, *models.Cursor, error)
+	ListPRs(ctx context.Context, filter models.ListFilter) ([]models.PullRequest, *models.Cursor, error)
+	// Prs matching every set field of filter, sorted by filter.Sort and id, cursor of next page is nil on the last one
+	SearchPRs(ctx context.Context, filter models.PRSearchFilter) ([]models.PullRequest, *models.SearchCursor, error)
+
 	// Additional functions
 	GetUsersStatistics(ctx context.Context) (*models.UsersStatistics, error)
 	GetUserStatistics(ctx context.Context, id string) (int, error)
 	GetTeamsStatistics(ctx context.Context) (*models.TeamsStatistics, error)
 	GetTeamStatistics(ctx context.Context, name string) (*models.TeamStats, error)
 	GetPRStatistics(ctx context.Context) (*models.PullRequestStatistics, error)
+
+	// Webhook functions
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhooksForEvent(ctx context.Context, eventType string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) (bool, error)
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	GetFailedDeliveries(ctx context.Context, webhookId int64) ([]models.WebhookDelivery, error)
+	// Due pending deliveries, they aren't returned again for lease, so workers don't send the same delivery
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Count failed attempt, delivery is due again after retryAfter
+	RetryDelivery(ctx context.Context, delivery *models.WebhookDelivery, retryAfter time.Duration) error
+	// Make failed delivery pending again with new run of attempts, returns false if it isn't failed
+	ReplayDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+	AddDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error
+
+	// Outbox functions
+	// Due unpublished events, they aren't returned again for lease, so instances don't publish the same event
+	ClaimUnpublishedEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkEventPublished(ctx context.Context, seq int64) error
+	// Count failed attempt, event is due again after retryAfter
+	MarkEventFailed(ctx context.Context, seq int64, reason string, retryAfter time.Duration) error
+	// Stop publishing event after its last attempt
+	MarkEventDead(ctx context.Context, seq int64, reason string) error
+	GetPublishedEvents(ctx context.Context, afterStreamSeq int64, filter models.EventStreamQuery, limit int) ([]models.OutboxEvent, error)
+	GetLastStreamSeq(ctx context.Context) (int64, error)
+
+	// API key functions
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (bool, error)
+
+	// Idempotency functions
+	CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error)
+	GetIdempotencyRecord(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error)
+
+	// Background job functions
+	// Run fn while holding lock of job shared by all instances, false without running fn if another one holds it
+	RunExclusive(ctx context.Context, job string, fn func(ctx context.Context)) (bool, error)
+
+	// Health functions
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (int, error)
 }
+
+// Background jobs run by one instance at a time
+const (
+	JobReminders  = "reminders"
+	JobEscalation = "escalation"
+)
+
+// Version of schema.sql the code expects, bumped together with the INSERT into schema_migrations
+const SchemaVersion = 5
@@ -0,0 +1,1197 @@
+// Package storagetest provides in-memory storage for tests of packages that use storage.Storage.
+package storagetest
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/events"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage kept in maps, it follows the semantics of PostgreSQL storage.
+// Default timestamps come from clock, so tests control them.
+type Memory struct {
+	clock clock.Clock
+
+	mu            sync.Mutex
+	teams         map[string]*team
+	users         map[string]*user
+	prs           map[string]*pullRequest
+	history       []models.ReviewerChange
+	settings      map[string]models.TeamSettings
+	reminders     map[string]*reminder
+	webhooks      map[int64]models.Webhook
+	deliveries    map[int64]*delivery
+	attempts      []models.WebhookDeliveryAttempt
+	outbox        []*outboxEvent
+	apiKeys       map[int64]models.APIKey
+	idempotency   map[[2]string]models.IdempotencyRecord
+	runningJobs   map[string]bool
+	seq           int64
+	streamSeq     int64
+	schemaVersion int
+}
+
+type team struct {
+	name      string
+	createdAt time.Time
+}
+
+type user struct {
+	models.User
+	createdAt time.Time
+}
+
+type pullRequest struct {
+	models.PullRequest
+	// Reviewers in order of assignment
+	reviewers []reviewer
+}
+
+type reviewer struct {
+	userID     string
+	assignedAt time.Time
+}
+
+type reminder struct {
+	sent           int
+	lastRemindedAt time.Time
+}
+
+type delivery struct {
+	models.WebhookDelivery
+	nextAttemptAt time.Time
+}
+
+type outboxEvent struct {
+	models.OutboxEvent
+	nextAttemptAt time.Time
+	published     bool
+	dead          bool
+	lastError     string
+}
+
+var _ storage.Storage = (*Memory)(nil)
+
+func NewMemory(clock clock.Clock) *Memory {
+	return &Memory{
+		clock:         clock,
+		teams:         make(map[string]*team),
+		users:         make(map[string]*user),
+		prs:           make(map[string]*pullRequest),
+		settings:      make(map[string]models.TeamSettings),
+		reminders:     make(map[string]*reminder),
+		webhooks:      make(map[int64]models.Webhook),
+		deliveries:    make(map[int64]*delivery),
+		apiKeys:       make(map[int64]models.APIKey),
+		idempotency:   make(map[[2]string]models.IdempotencyRecord),
+		runningJobs:   make(map[string]bool),
+		schemaVersion: storage.SchemaVersion,
+	}
+}
+
+func (m *Memory) nextSeq() int64 {
+	m.seq++
+	return m.seq
+}
+
+// Team functions
+
+func (m *Memory) CreateTeam(ctx context.Context, t *models.Team) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teams[t.TeamName]; ok {
+		return fmt.Errorf("team %s already exists", t.TeamName)
+	}
+	now := m.clock.Now()
+	m.teams[t.TeamName] = &team{name: t.TeamName, createdAt: now}
+	for _, member := range t.Members {
+		u, ok := m.users[member.UserID]
+		if !ok {
+			u = &user{createdAt: now}
+			m.users[member.UserID] = u
+		}
+		u.User = models.User{UserID: member.UserID, Username: member.Username, TeamName: t.TeamName,
+			IsActive: member.IsActive, IsTeamLead: member.IsTeamLead}
+	}
+	return nil
+}
+
+func (m *Memory) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.team(teamName), nil
+}
+
+func (m *Memory) team(teamName string) *models.Team {
+	if _, ok := m.teams[teamName]; !ok {
+		return nil
+	}
+	result := &models.Team{TeamName: teamName}
+	for _, u := range m.sortedUsers() {
+		if u.TeamName == teamName {
+			result.Members = append(result.Members, models.TeamMember{UserID: u.UserID, Username: u.Username,
+				IsActive: u.IsActive, IsTeamLead: u.IsTeamLead})
+		}
+	}
+	return result
+}
+
+// Users in order of creation
+func (m *Memory) sortedUsers() []*user {
+	users := make([]*user, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].createdAt.Equal(users[j].createdAt) {
+			return users[i].createdAt.Before(users[j].createdAt)
+		}
+		return users[i].UserID < users[j].UserID
+	})
+	return users
+}
+
+func (m *Memory) GetActiveUsersInTeam(ctx context.Context, teamName string) ([]*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var users []*models.User
+	for _, u := range m.sortedUsers() {
+		if u.TeamName == teamName && u.IsActive {
+			copied := u.User
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+func (m *Memory) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings, ok := m.settings[teamName]
+	if !ok {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
+func (m *Memory) SetTeamSettings(ctx context.Context, settings *models.TeamSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teams[settings.TeamName]; !ok {
+		return fmt.Errorf("team %s doesn't exist", settings.TeamName)
+	}
+	m.settings[settings.TeamName] = *settings
+	return nil
+}
+
+// User functions
+
+func (m *Memory) CreateUser(ctx context.Context, u *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[u.UserID]; ok {
+		return fmt.Errorf("user %s already exists", u.UserID)
+	}
+	if _, ok := m.teams[u.TeamName]; !ok {
+		return fmt.Errorf("team %s doesn't exist", u.TeamName)
+	}
+	m.users[u.UserID] = &user{User: *u, createdAt: m.clock.Now()}
+	return nil
+}
+
+func (m *Memory) GetUser(ctx context.Context, userId string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userId]
+	if !ok {
+		return nil, nil
+	}
+	copied := u.User
+	return &copied, nil
+}
+
+func (m *Memory) UpdateUser(ctx context.Context, u *models.User, outbox []events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.users[u.UserID]
+	if !ok {
+		return nil
+	}
+	if _, ok := m.teams[u.TeamName]; !ok {
+		return fmt.Errorf("team %s doesn't exist", u.TeamName)
+	}
+	if err := m.insertOutboxEvents(outbox); err != nil {
+		return err
+	}
+	stored.User = *u
+	return nil
+}
+
+// Pull Request functions
+
+func (m *Memory) CreatePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (*models.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.prs[pr.PullRequestID]; ok {
+		return nil, fmt.Errorf("pr %s already exists", pr.PullRequestID)
+	}
+	if _, ok := m.users[pr.AuthorID]; !ok {
+		return nil, fmt.Errorf("author %s doesn't exist", pr.AuthorID)
+	}
+	if err := m.insertOutboxEvents(outbox); err != nil {
+		return nil, err
+	}
+	stored := &pullRequest{PullRequest: *pr}
+	for _, id := range pr.AssignedReviewers {
+		stored.reviewers = append(stored.reviewers, reviewer{userID: id, assignedAt: pr.CreatedAt})
+	}
+	m.prs[pr.PullRequestID] = stored
+	return pr, nil
+}
+
+func (m *Memory) GetPR(ctx context.Context, prId string) (*models.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pr, ok := m.prs[prId]
+	if !ok {
+		return nil, nil
+	}
+	return pr.model(false), nil
+}
+
+// Copy of pr with its reviewers, sorted like ARRAY(... ORDER BY user_id) of lists
+func (pr *pullRequest) model(sorted bool) *models.PullRequest {
+	copied := pr.PullRequest
+	copied.AssignedReviewers = nil
+	for _, r := range pr.reviewers {
+		copied.AssignedReviewers = append(copied.AssignedReviewers, r.userID)
+	}
+	if sorted {
+		if copied.AssignedReviewers == nil {
+			copied.AssignedReviewers = []string{}
+		}
+		slices.Sort(copied.AssignedReviewers)
+	}
+	if pr.MergedAt != nil {
+		mergedAt := *pr.MergedAt
+		copied.MergedAt = &mergedAt
+	}
+	return &copied
+}
+
+func (m *Memory) MergePR(ctx context.Context, pr *models.PullRequest, outbox []events.Event) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.prs[pr.PullRequestID]
+	if !ok || stored.Status != "OPEN" {
+		return false, nil
+	}
+	if err := m.insertOutboxEvents(outbox); err != nil {
+		return false, err
+	}
+	stored.Status = "MERGED"
+	mergedAt := *pr.MergedAt
+	stored.MergedAt = &mergedAt
+	return true, nil
+}
+
+func (m *Memory) ReplaceReviewer(ctx context.Context, change *models.ReviewerChange, outbox []events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pr, ok := m.prs[change.PullRequestID]
+	if ok {
+		ok = false
+		for i, r := range pr.reviewers {
+			if r.userID == change.OldReviewerID {
+				pr.reviewers[i] = reviewer{userID: change.NewReviewerID, assignedAt: change.ChangedAt}
+				ok = true
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("reviewer %s is not assigned to pr %s", change.OldReviewerID, change.PullRequestID)
+	}
+	if err := m.insertOutboxEvents(outbox); err != nil {
+		return err
+	}
+	m.history = append(m.history, *change)
+	return nil
+}
+
+func (m *Memory) GetReviewerHistory(ctx context.Context, prId string) ([]models.ReviewerChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var history []models.ReviewerChange
+	for _, change := range m.history {
+		if change.PullRequestID == prId {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+func (m *Memory) GetStaleReviews(ctx context.Context, now time.Time) ([]models.StaleReview, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reviews []models.StaleReview
+	for _, pr := range m.prs {
+		if pr.Status != "OPEN" {
+			continue
+		}
+		settings, ok := m.settings[m.users[pr.AuthorID].TeamName]
+		if !ok || !settings.AutoEscalate {
+			continue
+		}
+		escalations := 0
+		for _, change := range m.history {
+			if change.PullRequestID == pr.PullRequestID && change.Reason == models.ReassignReasonEscalation {
+				escalations++
+			}
+		}
+		// PRs that used all escalations are left as is
+		if escalations >= settings.MaxEscalations {
+			continue
+		}
+		for _, r := range pr.reviewers {
+			if r.assignedAt.Add(time.Duration(settings.EscalateAfterHours) * time.Hour).Before(now) {
+				reviews = append(reviews, models.StaleReview{PullRequestID: pr.PullRequestID, ReviewerID: r.userID,
+					TeamName: settings.TeamName, AssignedAt: r.assignedAt, Escalations: escalations,
+					MaxEscalations: settings.MaxEscalations})
+			}
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		a, b := reviews[i], reviews[j]
+		if !a.AssignedAt.Equal(b.AssignedAt) {
+			return a.AssignedAt.Before(b.AssignedAt)
+		}
+		if a.PullRequestID != b.PullRequestID {
+			return a.PullRequestID < b.PullRequestID
+		}
+		return a.ReviewerID < b.ReviewerID
+	})
+	return reviews, nil
+}
+
+func (m *Memory) GetPRsByRewiever(ctx context.Context, userId string, filter models.ListFilter) ([]models.PullRequestShort, *models.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	filter.AuthorID, filter.TeamName = "", ""
+	prs := m.listPRs(filter, func(pr *pullRequest) bool {
+		return slices.ContainsFunc(pr.reviewers, func(r reviewer) bool { return r.userID == userId })
+	})
+	count, cursor := page(len(prs), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: prs[i].CreatedAt, ID: prs[i].PullRequestID}
+	})
+	short := make([]models.PullRequestShort, count)
+	for i, pr := range prs[:count] {
+		short[i] = models.PullRequestShort{PullRequestID: pr.PullRequestID, PullRequestName: pr.PullRequestName,
+			AuthorID: pr.AuthorID, Status: pr.Status}
+	}
+	return short, cursor, nil
+}
+
+func (m *Memory) GetOverduePRs(ctx context.Context, defaultSLAHours int, now time.Time, teamName string) ([]models.OverduePR, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var overdue []models.OverduePR
+	for _, pr := range m.prs {
+		author := m.users[pr.AuthorID]
+		if pr.Status != "OPEN" || (teamName != "" && author.TeamName != teamName) {
+			continue
+		}
+		sla := defaultSLAHours
+		if settings, ok := m.settings[author.TeamName]; ok {
+			sla = settings.ReviewSLAHours
+		}
+		if !pr.CreatedAt.Add(time.Duration(sla) * time.Hour).Before(now) {
+			continue
+		}
+		item := models.OverduePR{PullRequestID: pr.PullRequestID, PullRequestName: pr.PullRequestName,
+			AuthorID: pr.AuthorID, TeamName: author.TeamName, AssignedReviewers: pr.model(true).AssignedReviewers,
+			CreatedAt: pr.CreatedAt, ReviewSLAHours: sla}
+		if r, ok := m.reminders[pr.PullRequestID]; ok {
+			lastRemindedAt := r.lastRemindedAt
+			item.RemindersSent, item.LastRemindedAt = r.sent, &lastRemindedAt
+		}
+		overdue = append(overdue, item)
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		if !overdue[i].CreatedAt.Equal(overdue[j].CreatedAt) {
+			return overdue[i].CreatedAt.Before(overdue[j].CreatedAt)
+		}
+		return overdue[i].PullRequestID < overdue[j].PullRequestID
+	})
+	return overdue, nil
+}
+
+func (m *Memory) RecordReminder(ctx context.Context, prId string, at time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.reminders[prId]
+	if !ok {
+		r = &reminder{}
+		m.reminders[prId] = r
+	}
+	r.sent++
+	r.lastRemindedAt = at
+	return r.sent, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// List functions
+
+// Cut extra item of page, cursor of the last item is returned if there are more
+func page(count, limit int, last func(i int) models.Cursor) (int, *models.Cursor) {
+	if count <= limit {
+		return count, nil
+	}
+	cursor := last(limit - 1)
+	return limit, &cursor
+}
+
+// Newest first by (created_at, id)
+func newer(aCreated time.Time, aID string, bCreated time.Time, bID string) bool {
+	if !aCreated.Equal(bCreated) {
+		return aCreated.After(bCreated)
+	}
+	return aID > bID
+}
+
+// Item is on the page of filter
+func onPage(filter models.ListFilter, createdAt time.Time, id string) bool {
+	if filter.After != nil && !newer(filter.After.CreatedAt, filter.After.ID, createdAt, id) {
+		return false
+	}
+	if filter.CreatedFrom != nil && createdAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !createdAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+func (m *Memory) ListTeams(ctx context.Context, filter models.ListFilter) ([]models.TeamSummary, *models.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var teams []models.TeamSummary
+	for _, t := range m.teams {
+		if !onPage(filter, t.createdAt, t.name) {
+			continue
+		}
+		summary := models.TeamSummary{TeamName: t.name, CreatedAt: t.createdAt}
+		for _, u := range m.users {
+			if u.TeamName == t.name {
+				summary.MembersTotal++
+			}
+		}
+		teams = append(teams, summary)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return newer(teams[i].CreatedAt, teams[i].TeamName, teams[j].CreatedAt, teams[j].TeamName)
+	})
+	count, cursor := page(len(teams), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: teams[i].CreatedAt, ID: teams[i].TeamName}
+	})
+	return teams[:count], cursor, nil
+}
+
+func (m *Memory) ListUsers(ctx context.Context, filter models.ListFilter) ([]models.User, *models.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var users []*user
+	for _, u := range m.users {
+		if (filter.TeamName == "" || u.TeamName == filter.TeamName) && onPage(filter, u.createdAt, u.UserID) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return newer(users[i].createdAt, users[i].UserID, users[j].createdAt, users[j].UserID)
+	})
+	count, cursor := page(len(users), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: users[i].createdAt, ID: users[i].UserID}
+	})
+	result := make([]models.User, count)
+	for i, u := range users[:count] {
+		result[i] = u.User
+	}
+	return result, cursor, nil
+}
+
+// Prs matching filter and match, newest first, with one more than limit
+func (m *Memory) listPRs(filter models.ListFilter, match func(pr *pullRequest) bool) []models.PullRequest {
+	var prs []models.PullRequest
+	for _, pr := range m.prs {
+		if filter.Status != "" && pr.Status != filter.Status ||
+			filter.AuthorID != "" && pr.AuthorID != filter.AuthorID ||
+			filter.TeamName != "" && m.users[pr.AuthorID].TeamName != filter.TeamName ||
+			!onPage(filter, pr.CreatedAt, pr.PullRequestID) || !match(pr) {
+			continue
+		}
+		prs = append(prs, *pr.model(true))
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		return newer(prs[i].CreatedAt, prs[i].PullRequestID, prs[j].CreatedAt, prs[j].PullRequestID)
+	})
+	return prs
+}
+
+func (m *Memory) ListPRs(ctx context.Context, filter models.ListFilter) ([]models.PullRequest, *models.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prs := m.listPRs(filter, func(*pullRequest) bool { return true })
+	count, cursor := page(len(prs), filter.Limit, func(i int) models.Cursor {
+		return models.Cursor{CreatedAt: prs[i].CreatedAt, ID: prs[i].PullRequestID}
+	})
+	return prs[:count], cursor, nil
+}
+
+// Sort key of pr in search, same as the key kept in cursor by PostgreSQL storage
+func searchKey(pr models.PullRequest, sort string) string {
+	switch sort {
+	case "created_at":
+		return pr.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "merged_at":
+		mergedAt := time.Unix(0, 0)
+		if pr.MergedAt != nil {
+			mergedAt = *pr.MergedAt
+		}
+		return mergedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return pr.PullRequestName
+	}
+}
+
+// Order of prs in search, time keys in RFC 3339 of the same width compare as strings
+func searchLess(a, b models.PullRequest, sortKey string, descending bool) bool {
+	keyA, keyB := searchKey(a, sortKey), searchKey(b, sortKey)
+	var less bool
+	switch {
+	case sortKey != "name" && keyA != keyB:
+		less = searchTime(keyA).Before(searchTime(keyB))
+	case keyA != keyB:
+		less = keyA < keyB
+	default:
+		less = a.PullRequestID < b.PullRequestID
+	}
+	if descending {
+		return !less && (keyA != keyB || a.PullRequestID != b.PullRequestID)
+	}
+	return less
+}
+
+func searchTime(key string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, key)
+	return t
+}
+
+func (m *Memory) SearchPRs(ctx context.Context, filter models.PRSearchFilter) ([]models.PullRequest, *models.SearchCursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch filter.Sort {
+	case "created_at", "merged_at", "name":
+	default:
+		return nil, nil, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+	var after *models.PullRequest
+	if filter.After != nil {
+		after = &models.PullRequest{PullRequestID: filter.After.ID, PullRequestName: filter.After.Key}
+		if filter.Sort != "name" {
+			t, err := time.Parse(time.RFC3339Nano, filter.After.Key)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cursor key: %w", err)
+			}
+			after.CreatedAt, after.MergedAt = t, &t
+		}
+	}
+
+	in := func(t *time.Time, from, to *time.Time) bool {
+		if from == nil && to == nil {
+			return true
+		}
+		return t != nil && (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+	}
+	var prs []models.PullRequest
+	for _, stored := range m.prs {
+		pr := *stored.model(true)
+		if filter.Name != "" && !strings.Contains(strings.ToLower(pr.PullRequestName), strings.ToLower(filter.Name)) ||
+			filter.AuthorID != "" && pr.AuthorID != filter.AuthorID ||
+			filter.ReviewerID != "" && !slices.Contains(pr.AssignedReviewers, filter.ReviewerID) ||
+			filter.TeamName != "" && m.users[pr.AuthorID].TeamName != filter.TeamName ||
+			filter.Status != "" && pr.Status != filter.Status ||
+			!in(&pr.CreatedAt, filter.CreatedFrom, filter.CreatedTo) ||
+			!in(pr.MergedAt, filter.MergedFrom, filter.MergedTo) ||
+			after != nil && !searchLess(*after, pr, filter.Sort, filter.Descending) {
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	sort.Slice(prs, func(i, j int) bool { return searchLess(prs[i], prs[j], filter.Sort, filter.Descending) })
+
+	if len(prs) <= filter.Limit {
+		return prs, nil, nil
+	}
+	last := prs[filter.Limit-1]
+	return prs[:filter.Limit], &models.SearchCursor{
+		Sort:       filter.Sort,
+		Descending: filter.Descending,
+		Key:        searchKey(last, filter.Sort),
+		ID:         last.PullRequestID,
+	}, nil
+}
+
+// Additional functions
+
+func (m *Memory) GetUsersStatistics(ctx context.Context) (*models.UsersStatistics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statistics := &models.UsersStatistics{TotalUserNumber: len(m.users)}
+	for _, u := range m.users {
+		if u.IsActive {
+			statistics.TotalActiveUserNumber++
+		}
+	}
+	return statistics, nil
+}
+
+func (m *Memory) GetUserStatistics(ctx context.Context, id string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, pr := range m.prs {
+		for _, r := range pr.reviewers {
+			if r.userID == id {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) GetTeamsStatistics(ctx context.Context) (*models.TeamsStatistics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &models.TeamsStatistics{TotalTeamNumber: len(m.teams)}, nil
+}
+
+func (m *Memory) GetTeamStatistics(ctx context.Context, name string) (*models.TeamStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.team(name)
+	if t == nil {
+		return nil, nil
+	}
+	statistics := &models.TeamStats{TeamName: t.TeamName, Members: t.Members, MembersTotal: len(t.Members)}
+	for _, pr := range m.prs {
+		if m.users[pr.AuthorID].TeamName != name {
+			continue
+		}
+		statistics.PullRequestsTotal++
+		if pr.Status == "OPEN" {
+			statistics.ActivePullRequestsTotal++
+		}
+	}
+	latest := m.listPRs(models.ListFilter{TeamName: name}, func(*pullRequest) bool { return true })
+	for _, pr := range latest[:min(len(latest), models.TeamStatsPRLimit)] {
+		statistics.PullRequests = append(statistics.PullRequests, pr.PullRequestID)
+	}
+	return statistics, nil
+}
+
+func (m *Memory) GetPRStatistics(ctx context.Context) (*models.PullRequestStatistics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statistics := &models.PullRequestStatistics{TotalPR: len(m.prs)}
+	for _, pr := range m.prs {
+		if pr.Status == "OPEN" {
+			statistics.TotalActivePR++
+		}
+	}
+	return statistics, nil
+}
+
+// Webhook functions
+
+func (m *Memory) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhook.WebhookID = m.nextSeq()
+	webhook.CreatedAt = m.clock.Now()
+	m.webhooks[webhook.WebhookID] = *webhook
+	return webhook, nil
+}
+
+func (m *Memory) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhook, ok := m.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	return &webhook, nil
+}
+
+func (m *Memory) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return m.webhooksWhere(func(models.Webhook) bool { return true }), nil
+}
+
+func (m *Memory) GetWebhooksForEvent(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	return m.webhooksWhere(func(webhook models.Webhook) bool { return slices.Contains(webhook.Events, eventType) }), nil
+}
+
+func (m *Memory) webhooksWhere(match func(models.Webhook) bool) []models.Webhook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var webhooks []models.Webhook
+	for _, webhook := range m.webhooks {
+		if match(webhook) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].WebhookID < webhooks[j].WebhookID })
+	return webhooks
+}
+
+func (m *Memory) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[id]; !ok {
+		return false, nil
+	}
+	delete(m.webhooks, id)
+	for deliveryID, d := range m.deliveries {
+		if d.WebhookID == id {
+			delete(m.deliveries, deliveryID)
+		}
+	}
+	return true, nil
+}
+
+// Returns nil if delivery of the event to the webhook already exists
+func (m *Memory) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.deliveries {
+		if existing.WebhookID == d.WebhookID && existing.EventID == d.EventID {
+			return nil, nil
+		}
+	}
+	now := m.clock.Now()
+	d.DeliveryID = m.nextSeq()
+	d.CreatedAt, d.UpdatedAt = now, now
+	m.deliveries[d.DeliveryID] = &delivery{WebhookDelivery: *d, nextAttemptAt: now}
+	return d, nil
+}
+
+func (m *Memory) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := d.WebhookDelivery
+	return &copied, nil
+}
+
+func (m *Memory) GetFailedDeliveries(ctx context.Context, webhookId int64) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status == "FAILED" && (webhookId == 0 || d.WebhookID == webhookId) {
+			deliveries = append(deliveries, d.WebhookDelivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].DeliveryID < deliveries[j].DeliveryID })
+	return deliveries, nil
+}
+
+func (m *Memory) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	var due []*delivery
+	for _, d := range m.deliveries {
+		if d.Status == "PENDING" && !d.nextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttemptAt.Equal(due[j].nextAttemptAt) {
+			return due[i].nextAttemptAt.Before(due[j].nextAttemptAt)
+		}
+		return due[i].DeliveryID < due[j].DeliveryID
+	})
+	var claimed []models.WebhookDelivery
+	for _, d := range due[:min(limit, len(due))] {
+		d.nextAttemptAt = now.Add(lease)
+		claimed = append(claimed, d.WebhookDelivery)
+	}
+	return claimed, nil
+}
+
+func (m *Memory) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.deliveries[d.DeliveryID]; ok {
+		stored.Status, stored.Attempts, stored.LastError = d.Status, d.Attempts, d.LastError
+		stored.UpdatedAt = m.clock.Now()
+	}
+	return nil
+}
+
+func (m *Memory) RetryDelivery(ctx context.Context, d *models.WebhookDelivery, retryAfter time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.deliveries[d.DeliveryID]; ok {
+		now := m.clock.Now()
+		stored.Status, stored.Attempts, stored.LastError = "PENDING", d.Attempts, d.LastError
+		stored.UpdatedAt, stored.nextAttemptAt = now, now.Add(retryAfter)
+	}
+	return nil
+}
+
+func (m *Memory) ReplayDelivery(ctx context.Context, d *models.WebhookDelivery) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.deliveries[d.DeliveryID]
+	if !ok || stored.Status != "FAILED" {
+		return false, nil
+	}
+	now := m.clock.Now()
+	stored.Status, stored.RunStartAttempts = "PENDING", stored.Attempts
+	stored.UpdatedAt, stored.nextAttemptAt = now, now
+	d.Status, d.RunStartAttempts, d.UpdatedAt = stored.Status, stored.RunStartAttempts, stored.UpdatedAt
+	return true, nil
+}
+
+func (m *Memory) AddDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.attempts {
+		if existing.DeliveryID == attempt.DeliveryID && existing.Attempt == attempt.Attempt {
+			return fmt.Errorf("attempt %d of delivery %d already exists", attempt.Attempt, attempt.DeliveryID)
+		}
+	}
+	copied := *attempt
+	copied.CreatedAt = m.clock.Now()
+	m.attempts = append(m.attempts, copied)
+	return nil
+}
+
+// Outbox functions
+
+// Save events with the changes they describe
+func (m *Memory) insertOutboxEvents(outbox []events.Event) error {
+	for _, event := range outbox {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		userIds := event.UserIDs
+		if userIds == nil {
+			userIds = []string{}
+		}
+		now := m.clock.Now()
+		m.outbox = append(m.outbox, &outboxEvent{
+			OutboxEvent: models.OutboxEvent{Seq: m.nextSeq(), EventID: event.ID, EventType: string(event.Type),
+				TeamName: event.TeamName, UserIDs: userIds, Payload: payload, CreatedAt: now},
+			nextAttemptAt: now,
+		})
+	}
+	return nil
+}
+
+// Events saved in outbox, published or not, in order of saving
+func (m *Memory) OutboxEvents() []models.OutboxEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outbox := make([]models.OutboxEvent, len(m.outbox))
+	for i, event := range m.outbox {
+		outbox[i] = event.OutboxEvent
+	}
+	return outbox
+}
+
+func (m *Memory) ClaimUnpublishedEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	var claimed []models.OutboxEvent
+	for _, event := range m.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		if !event.published && !event.dead && !event.nextAttemptAt.After(now) {
+			event.nextAttemptAt = now.Add(lease)
+			claimed = append(claimed, event.OutboxEvent)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *Memory) outboxEvent(seq int64) *outboxEvent {
+	for _, event := range m.outbox {
+		if event.Seq == seq {
+			return event
+		}
+	}
+	return nil
+}
+
+func (m *Memory) MarkEventPublished(ctx context.Context, seq int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event := m.outboxEvent(seq); event != nil && !event.published {
+		m.streamSeq++
+		event.published, event.StreamSeq, event.lastError = true, m.streamSeq, ""
+		event.Attempts++
+	}
+	return nil
+}
+
+func (m *Memory) MarkEventFailed(ctx context.Context, seq int64, reason string, retryAfter time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event := m.outboxEvent(seq); event != nil {
+		event.Attempts++
+		event.lastError, event.nextAttemptAt = reason, m.clock.Now().Add(retryAfter)
+	}
+	return nil
+}
+
+func (m *Memory) MarkEventDead(ctx context.Context, seq int64, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event := m.outboxEvent(seq); event != nil {
+		event.Attempts++
+		event.lastError, event.dead = reason, true
+	}
+	return nil
+}
+
+func (m *Memory) GetPublishedEvents(ctx context.Context, afterStreamSeq int64, filter models.EventStreamQuery, limit int) ([]models.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var published []models.OutboxEvent
+	for _, event := range m.outbox {
+		if !event.published || event.StreamSeq <= afterStreamSeq ||
+			filter.TeamName != "" && event.TeamName != filter.TeamName ||
+			filter.UserID != "" && !slices.Contains(event.UserIDs, filter.UserID) {
+			continue
+		}
+		published = append(published, event.OutboxEvent)
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].StreamSeq < published[j].StreamSeq })
+	return published[:min(limit, len(published))], nil
+}
+
+func (m *Memory) GetLastStreamSeq(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.streamSeq, nil
+}
+
+// API key functions
+
+func (m *Memory) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return nil, fmt.Errorf("API key with the same hash already exists")
+		}
+	}
+	key.KeyID = m.nextSeq()
+	key.CreatedAt = m.clock.Now()
+	stored := *key
+	stored.Key = ""
+	m.apiKeys[key.KeyID] = stored
+	return key, nil
+}
+
+func (m *Memory) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range m.apiKeys {
+		if key.KeyHash == hash {
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []models.APIKey
+	for _, key := range m.apiKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys, nil
+}
+
+func (m *Memory) RevokeAPIKey(ctx context.Context, id int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	now := m.clock.Now()
+	key.RevokedAt = &now
+	m.apiKeys[id] = key
+	return true, nil
+}
+
+// Idempotency functions
+
+func (m *Memory) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := [2]string{record.Scope, record.Key}
+	if existing, ok := m.idempotency[id]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return false, nil
+	}
+	m.idempotency[id] = *record
+	return true, nil
+}
+
+func (m *Memory) GetIdempotencyRecord(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.idempotency[[2]string{scope, key}]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (m *Memory) CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := [2]string{record.Scope, record.Key}
+	if stored, ok := m.idempotency[id]; ok {
+		stored.StatusCode, stored.Response = record.StatusCode, record.Response
+		m.idempotency[id] = stored
+	}
+	return nil
+}
+
+func (m *Memory) DeleteIdempotencyRecord(ctx context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotency, [2]string{scope, key})
+	return nil
+}
+
+func (m *Memory) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, record := range m.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(m.idempotency, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Background job functions
+
+func (m *Memory) RunExclusive(ctx context.Context, job string, fn func(ctx context.Context)) (bool, error) {
+	m.mu.Lock()
+	if m.runningJobs[job] {
+		m.mu.Unlock()
+		return false, nil
+	}
+	m.runningJobs[job] = true
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.runningJobs, job)
+		m.mu.Unlock()
+	}()
+	fn(ctx)
+	return true, nil
+}
+
+// Health functions
+
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *Memory) GetSchemaVersion(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.schemaVersion, nil
+}
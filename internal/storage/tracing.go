@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("PR_reviewer_assign_service/internal/storage")
+
+// Client span of database query
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "PostgresStorage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+		),
+	)
+}
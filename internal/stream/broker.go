@@ -0,0 +1,60 @@
+package stream
+
+import "sync"
+
+// Wakes up connected stream clients when new events are published
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan struct{}]struct{})}
+}
+
+// Subscribe for notifications, returned function unsubscribes.
+// Channel is closed when broker is closed.
+func (b *Broker) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// Notify all subscribers, never blocks
+func (b *Broker) Notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// Subscriber already has pending notification
+		}
+	}
+}
+
+// Close channels of all subscribers, so streams end on shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		close(ch)
+		delete(b.subscribers, ch)
+	}
+}
@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "PENDING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusFailed    = "FAILED"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Workers:      4,
+		PollInterval: time.Second,
+		MaxAttempts:  5,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   time.Minute,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// Sends events to subscribed webhooks in background.
+// Deliveries wait for their next attempt in storage, so slow endpoints don't hold workers between attempts.
+type Dispatcher struct {
+	storage storage.Storage
+	client  *http.Client
+	config  Config
+	wake    chan struct{}
+	wg      sync.WaitGroup
+	running atomic.Int32
+}
+
+func NewDispatcher(storage storage.Storage, config Config) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		client:  &http.Client{Timeout: config.Timeout},
+		config:  config,
+		wake:    make(chan struct{}, config.Workers),
+	}
+}
+
+// Start background workers, they stop when ctx is done
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.config.Workers; i++ {
+		d.wg.Add(1)
+		d.running.Add(1)
+		go func() {
+			defer d.wg.Done()
+			defer d.running.Add(-1)
+			d.work(ctx)
+		}()
+	}
+}
+
+// Wait until workers stop after ctx is done, deliveries in progress are finished or left pending
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Check that all delivery workers are running
+func (d *Dispatcher) Running() bool {
+	return int(d.running.Load()) == d.config.Workers
+}
+
+func (d *Dispatcher) Name() string {
+	return "webhook"
+}
+
+// Create deliveries for every subscribed webhook.
+// Deliveries are saved before Send returns, so sending them survives restarts, Send doesn't wait for workers.
+func (d *Dispatcher) Send(ctx context.Context, event models.OutboxEvent) error {
+	webhooks, err := d.storage.GetWebhooksForEvent(ctx, event.EventType)
+	if err != nil {
+		return err
+	}
+
+	created := false
+	for _, webhook := range webhooks {
+		delivery, err := d.storage.CreateDelivery(ctx, &models.WebhookDelivery{
+			WebhookID: webhook.WebhookID,
+			EventID:   event.EventID,
+			EventType: event.EventType,
+			Payload:   event.Payload,
+			Status:    StatusPending,
+		})
+		if err != nil {
+			return err
+		}
+		// Nil if created by previous attempt to send this event
+		created = created || delivery != nil
+	}
+	if created {
+		d.notify()
+	}
+	return nil
+}
+
+// Deliver failed delivery once more, with all attempts again. Returns false if delivery isn't failed
+func (d *Dispatcher) Replay(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	replayed, err := d.storage.ReplayDelivery(ctx, delivery)
+	if err != nil || !replayed {
+		return false, err
+	}
+	d.notify()
+	return true, nil
+}
+
+// Wake idle workers without waiting for them
+func (d *Dispatcher) notify() {
+	for i := 0; i < d.config.Workers; i++ {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+// Deliver due deliveries one by one, wait for new ones when there are none
+func (d *Dispatcher) work(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil && d.next(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Claim one due delivery and make one attempt, returns false if there was nothing to deliver
+func (d *Dispatcher) next(ctx context.Context) bool {
+	// Claim lasts longer than the attempt, so other workers and instances skip the delivery
+	deliveries, err := d.storage.ClaimDueDeliveries(ctx, 1, d.config.Timeout+time.Minute)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get due deliveries", "error", err)
+		return false
+	}
+	if len(deliveries) == 0 {
+		return false
+	}
+	d.deliver(ctx, &deliveries[0])
+	return true
+}
+
+// One attempt, failed delivery is retried after exponential backoff until attempts of the run are used
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := d.storage.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get webhook", "webhook_id", delivery.WebhookID, "error", err)
+		return
+	}
+	if webhook == nil {
+		// Webhook was removed, deliveries are removed with it
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, err := d.send(ctx, webhook, delivery)
+
+	record := &models.WebhookDeliveryAttempt{
+		DeliveryID: delivery.DeliveryID,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := d.storage.AddDeliveryAttempt(ctx, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to save delivery attempt", "delivery_id", delivery.DeliveryID, "error", err)
+	}
+
+	switch attempt := delivery.Attempts - delivery.RunStartAttempts; {
+	case err == nil:
+		delivery.Status = StatusSucceeded
+		delivery.LastError = ""
+		err = d.storage.UpdateDelivery(ctx, delivery)
+	case attempt >= d.config.MaxAttempts:
+		slog.WarnContext(ctx, "Delivery failed after all attempts", "delivery_id", delivery.DeliveryID, "webhook_id", webhook.WebhookID, "attempts", attempt)
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+		err = d.storage.UpdateDelivery(ctx, delivery)
+	default:
+		delivery.LastError = err.Error()
+		err = d.storage.RetryDelivery(ctx, delivery, d.backoff(attempt))
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update delivery", "delivery_id", delivery.DeliveryID, "error", err)
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := d.config.BaseBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > d.config.MaxBackoff {
+		return d.config.MaxBackoff
+	}
+	return backoff
+}
+
+// Send one signed request
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Signature of payload: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + payload))
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
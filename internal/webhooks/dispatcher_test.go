@@ -0,0 +1,228 @@
+package webhooks
+
+import (
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/models"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Endpoint that keeps received requests and answers with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header, body: body})
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest{}, r.requests...)
+}
+
+type dispatcherTest struct {
+	t          *testing.T
+	ctx        context.Context
+	clock      *clock.Manual
+	storage    *storagetest.Memory
+	dispatcher *Dispatcher
+	receiver   *receiver
+}
+
+// Webhook of pr.merged events, failed attempts are retried after 1s and 2s, the 3rd is the last one of a run
+func newDispatcherTest(t *testing.T) *dispatcherTest {
+	t.Helper()
+	clk := clock.NewManual(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	store := storagetest.NewMemory(clk)
+	config := Config{Workers: 1, PollInterval: time.Second, MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute, Timeout: 5 * time.Second}
+	receiver := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	tt := &dispatcherTest{t: t, ctx: context.Background(), clock: clk, storage: store, dispatcher: NewDispatcher(store, config), receiver: receiver}
+	if _, err := store.CreateWebhook(tt.ctx, &models.Webhook{URL: server.URL, Events: []string{"pr.merged"}, Secret: "s3cr3t"}); err != nil {
+		t.Fatal(err)
+	}
+	return tt
+}
+
+func (tt *dispatcherTest) send(eventID, eventType string) {
+	tt.t.Helper()
+	event := models.OutboxEvent{EventID: eventID, EventType: eventType, Payload: []byte(`{"event_id":"` + eventID + `"}`)}
+	if err := tt.dispatcher.Send(tt.ctx, event); err != nil {
+		tt.t.Fatal(err)
+	}
+}
+
+// Make attempts due after d, returns how many were made
+func (tt *dispatcherTest) deliverAfter(d time.Duration) int {
+	tt.clock.Advance(d)
+	attempts := 0
+	for tt.dispatcher.next(tt.ctx) {
+		attempts++
+	}
+	return attempts
+}
+
+// Delivery of the last received request
+func (tt *dispatcherTest) lastDelivery() *models.WebhookDelivery {
+	tt.t.Helper()
+	requests := tt.receiver.received()
+	if len(requests) == 0 {
+		tt.t.Fatal("nothing is delivered")
+	}
+	id, err := strconv.ParseInt(requests[len(requests)-1].header.Get(HeaderDelivery), 10, 64)
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	delivery, err := tt.storage.GetDelivery(tt.ctx, id)
+	if err != nil || delivery == nil {
+		tt.t.Fatalf("delivery %d: %v", id, err)
+	}
+	return delivery
+}
+
+func (tt *dispatcherTest) checkDelivery(status string, attempts int) *models.WebhookDelivery {
+	tt.t.Helper()
+	delivery := tt.lastDelivery()
+	if delivery.Status != status || delivery.Attempts != attempts {
+		tt.t.Fatalf("delivery %s after %d attempts, want %s after %d", delivery.Status, delivery.Attempts, status, attempts)
+	}
+	return delivery
+}
+
+func TestSign(t *testing.T) {
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := Sign("secret", "1700000000", []byte(`{"a":1}`)); got != want {
+		t.Errorf("signature %s, want %s", got, want)
+	}
+	if Sign("secret", "1700000001", []byte(`{"a":1}`)) == want || Sign("other", "1700000000", []byte(`{"a":1}`)) == want {
+		t.Error("signature doesn't depend on timestamp and secret")
+	}
+}
+
+func TestDeliverySigned(t *testing.T) {
+	tt := newDispatcherTest(t)
+	tt.send("e1", "pr.merged")
+	tt.send("e2", "pr.created")
+
+	if attempts := tt.deliverAfter(0); attempts != 1 {
+		t.Fatalf("%d attempts, want one of subscribed event", attempts)
+	}
+	request := tt.receiver.received()[0]
+	for header, want := range map[string]string{
+		"Content-Type":  "application/json",
+		HeaderEvent:     "pr.merged",
+		HeaderSignature: Sign("s3cr3t", request.header.Get(HeaderTimestamp), request.body),
+	} {
+		if got := request.header.Get(header); got != want {
+			t.Errorf("%s: %q, want %q", header, got, want)
+		}
+	}
+	if string(request.body) != `{"event_id":"e1"}` {
+		t.Errorf("body %s", request.body)
+	}
+	tt.checkDelivery(StatusSucceeded, 1)
+
+	// Event sent again by outbox isn't delivered twice
+	tt.send("e1", "pr.merged")
+	if attempts := tt.deliverAfter(time.Hour); attempts != 0 {
+		t.Errorf("%d attempts of delivered event", attempts)
+	}
+}
+
+func TestDeliveryRetriesWithBackoff(t *testing.T) {
+	tt := newDispatcherTest(t)
+	tt.receiver.setStatus(http.StatusInternalServerError)
+	tt.send("e1", "pr.merged")
+
+	tt.deliverAfter(0)
+	if delivery := tt.checkDelivery(StatusPending, 1); delivery.LastError != "unexpected response status: 500" {
+		t.Errorf("last error %q", delivery.LastError)
+	}
+	for _, test := range []struct {
+		after    time.Duration
+		status   string
+		attempts int
+	}{
+		{time.Second - time.Millisecond, StatusPending, 1},
+		{time.Millisecond, StatusPending, 2},
+		{2*time.Second - time.Millisecond, StatusPending, 2},
+		{time.Millisecond, StatusFailed, 3},
+		// Failed delivery waits for replay
+		{time.Hour, StatusFailed, 3},
+	} {
+		tt.deliverAfter(test.after)
+		tt.checkDelivery(test.status, test.attempts)
+	}
+	if requests := tt.receiver.received(); len(requests) != 3 || requests[0].header.Get(HeaderDelivery) != requests[2].header.Get(HeaderDelivery) {
+		t.Errorf("%d requests, want 3 of one delivery", len(requests))
+	}
+}
+
+func TestReplayFailedDelivery(t *testing.T) {
+	tt := newDispatcherTest(t)
+	tt.receiver.setStatus(http.StatusBadGateway)
+	tt.send("e1", "pr.merged")
+	tt.deliverAfter(0)
+	tt.deliverAfter(time.Second)
+	tt.deliverAfter(2 * time.Second)
+	failed := tt.checkDelivery(StatusFailed, 3)
+
+	// Replay has all attempts again
+	if replayed, err := tt.dispatcher.Replay(tt.ctx, failed); !replayed || err != nil {
+		t.Fatalf("replay: %v, %v", replayed, err)
+	}
+	tt.deliverAfter(0)
+	tt.deliverAfter(time.Second)
+	tt.checkDelivery(StatusPending, 5)
+	tt.deliverAfter(2 * time.Second)
+	failed = tt.checkDelivery(StatusFailed, 6)
+
+	tt.receiver.setStatus(http.StatusNoContent)
+	if replayed, err := tt.dispatcher.Replay(tt.ctx, failed); !replayed || err != nil {
+		t.Fatalf("second replay: %v, %v", replayed, err)
+	}
+	tt.deliverAfter(0)
+	delivered := tt.checkDelivery(StatusSucceeded, 7)
+
+	// Only failed deliveries are replayed
+	if replayed, err := tt.dispatcher.Replay(tt.ctx, delivered); replayed || err != nil {
+		t.Errorf("replay of delivered: %v, %v", replayed, err)
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	d := NewDispatcher(nil, Config{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 6: 32 * time.Second, 7: time.Minute, 100: time.Minute} {
+		if got := d.backoff(attempt); got != want {
+			t.Errorf("backoff of attempt %d: %v, want %v", attempt, got, want)
+		}
+	}
+}
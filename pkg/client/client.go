@@ -0,0 +1,203 @@
+// Package client is a Go client of the PR reviewer assign service HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client of the service API, safe for concurrent use
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	headers      http.Header
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// Use custom http client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Retry idempotent calls up to maxRetries times on network errors, 429 and 5xx responses
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// Send header with every request
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(name, value)
+	}
+}
+
+// Authenticate requests with API key
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		headers:      http.Header{},
+		maxRetries:   2,
+		retryBackoff: 200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Read request with query parameters, retried on failures
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out, true)
+}
+
+type idempotencyKey struct{}
+
+// Send Idempotency-Key with create requests (AddTeam, CreatePR) made with ctx, such requests are retried too.
+// Other changing requests are never retried, the service doesn't deduplicate them.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// Endpoints that replay responses by Idempotency-Key, other changes may be applied twice when retried
+var idempotentPaths = map[string]bool{
+	"/team/add":           true,
+	"/pullRequest/create": true,
+}
+
+// Key of ctx if endpoint supports it
+func requestIdempotencyKey(ctx context.Context, method, path string) string {
+	if method != http.MethodPost || !idempotentPaths[path] {
+		return ""
+	}
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// Changing request with JSON body
+func (c *Client) post(ctx context.Context, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	return c.do(ctx, http.MethodPost, path, body, out, requestIdempotencyKey(ctx, http.MethodPost, path) != "")
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}, idempotent bool) error {
+	attempts := 1
+	if idempotent {
+		attempts += c.maxRetries
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			// Wait at least as long as service asked
+			wait := c.retryBackoff << (attempt - 1)
+			if apiErr, ok := err.(*Error); ok && apiErr.RetryAfter > wait {
+				wait = apiErr.RetryAfter
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		var retry bool
+		retry, err = c.send(ctx, method, path, body, out)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// Send one request, returns whether it can be retried
+func (c *Client) send(ctx context.Context, method, path string, body []byte, out interface{}) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return false, err
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key := requestIdempotencyKey(ctx, method, path); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		apiErr := decodeError(resp)
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+			apiErr.Code == ErrorCodeRequestInProgress
+		return retry, apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return false, nil
+}
+
+func decodeError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var response errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || response.Error.Code == "" {
+		apiErr.Code = ErrorCodeInternal
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+
+	apiErr.Code = response.Error.Code
+	apiErr.Message = response.Error.Message
+	apiErr.Details = response.Error.Details
+	return apiErr
+}
@@ -0,0 +1,319 @@
+package client
+
+import (
+	"PR_reviewer_assign_service/internal/auth"
+	"PR_reviewer_assign_service/internal/clock"
+	"PR_reviewer_assign_service/internal/handlers"
+	"PR_reviewer_assign_service/internal/service"
+	"PR_reviewer_assign_service/internal/storage/storagetest"
+	"PR_reviewer_assign_service/internal/stream"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+const adminKey = "admin-key"
+
+// Server with the real handlers on memory storage, counts requests and fails them on demand
+type clientTest struct {
+	t       *testing.T
+	ctx     context.Context
+	storage *storagetest.Memory
+	client  *Client
+
+	mu sync.Mutex
+	// Requests and Idempotency-Key headers by "METHOD path"
+	requests map[string]int
+	keys     map[string][]string
+	// Statuses that replace next responses of "METHOD path"
+	faults map[string][]int
+}
+
+func newClientTest(t *testing.T) *clientTest {
+	t.Helper()
+	store := storagetest.NewMemory(clock.System{})
+	svc := service.NewService(store, nil)
+	if err := svc.EnsureAPIKey(context.Background(), "admin", adminKey, []auth.Scope{auth.ScopeAdmin}); err != nil {
+		t.Fatal(err)
+	}
+
+	access := handlers.NewAuth(svc)
+	router := handlers.NewRouter()
+	api := &handlers.API{
+		Users:       handlers.NewUserHandler(svc),
+		Teams:       handlers.NewTeamHandler(svc),
+		PRs:         handlers.NewPRHandler(svc),
+		Webhooks:    handlers.NewWebhookHandler(svc),
+		APIKeys:     handlers.NewAPIKeyHandler(svc),
+		Events:      handlers.NewEventHandler(svc, stream.NewBroker()),
+		Idempotency: handlers.NewIdempotency(svc),
+	}
+	api.Register(router, func(group string, scope auth.Scope) func(http.HandlerFunc) http.HandlerFunc {
+		return func(h http.HandlerFunc) http.HandlerFunc {
+			return access.Identify(access.Require(scope, h))
+		}
+	})
+
+	tt := &clientTest{
+		t:        t,
+		ctx:      context.Background(),
+		storage:  store,
+		requests: make(map[string]int),
+		keys:     make(map[string][]string),
+		faults:   make(map[string][]int),
+	}
+	server := httptest.NewServer(tt.wrap(router))
+	t.Cleanup(server.Close)
+	tt.client = New(server.URL, WithToken(adminKey), WithRetries(2, time.Millisecond))
+
+	team := Team{TeamName: "backend", Members: []TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true, IsTeamLead: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Carol", IsActive: true},
+		{UserID: "u4", Username: "Dave", IsActive: true},
+	}}
+	if _, err := tt.client.AddTeam(tt.ctx, team); err != nil {
+		t.Fatalf("add team: %v", err)
+	}
+	tt.sent(http.MethodPost, "/team/add")
+	return tt
+}
+
+// Faulted request is still handled, so its change is applied, but the client
+// gets the fault instead of the response, like when a proxy loses it
+func (tt *clientTest) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Method + " " + r.URL.Path
+		tt.mu.Lock()
+		tt.requests[name]++
+		tt.keys[name] = append(tt.keys[name], r.Header.Get(handlers.HeaderIdempotencyKey))
+		var status int
+		if faults := tt.faults[name]; len(faults) > 0 {
+			status, tt.faults[name] = faults[0], faults[1:]
+		}
+		tt.mu.Unlock()
+
+		if status == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(httptest.NewRecorder(), r)
+		code := ErrorCodeInternal
+		if status == http.StatusTooManyRequests {
+			code = ErrorCodeRateLimited
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"error":{"code":%q,"message":%q}}`, code, http.StatusText(status))
+	})
+}
+
+func (tt *clientTest) fail(method, path string, statuses ...int) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.faults[method+" "+path] = statuses
+}
+
+// Requests of "METHOD path" since previous call and their Idempotency-Key headers
+func (tt *clientTest) sent(method, path string) (int, []string) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	name := method + " " + path
+	count, keys := tt.requests[name], tt.keys[name]
+	delete(tt.requests, name)
+	delete(tt.keys, name)
+	return count, keys
+}
+
+func (tt *clientTest) expectSent(method, path string, want int) []string {
+	tt.t.Helper()
+	count, keys := tt.sent(method, path)
+	if count != want {
+		tt.t.Errorf("%s %s sent %d times, want %d", method, path, count, want)
+	}
+	return keys
+}
+
+func (tt *clientTest) createPR(ctx context.Context, id string) *PullRequest {
+	tt.t.Helper()
+	pr, err := tt.client.CreatePR(ctx, PullRequestCreateQuery{PullRequestID: id, PullRequestName: id, AuthorID: "u1"})
+	if err != nil {
+		tt.t.Fatalf("create %s: %v", id, err)
+	}
+	return pr
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	tt := newClientTest(t)
+
+	team, err := tt.client.GetTeam(tt.ctx, "backend")
+	if err != nil || len(team.Members) != 4 {
+		t.Fatalf("get team: %+v, %v", team, err)
+	}
+	if _, err := tt.client.GetTeam(tt.ctx, "mobile"); !IsCode(err, ErrorCodeNotFound) {
+		t.Errorf("get missing team: %v, want NOT_FOUND", err)
+	}
+	if _, err := tt.client.AddTeam(tt.ctx, Team{TeamName: "backend"}); !IsCode(err, ErrorCodeInvalidInput) {
+		t.Errorf("add team without members: %v, want INVALID_INPUT", err)
+	}
+
+	if _, err := tt.client.AddTeamMember(tt.ctx, "backend", TeamMember{UserID: "u5", Username: "Eve", IsActive: true}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	user, err := tt.client.RemoveTeamMember(tt.ctx, "backend", "u5")
+	if err != nil || user.IsActive {
+		t.Fatalf("remove member: %+v, %v", user, err)
+	}
+	if user, err := tt.client.SetIsActive(tt.ctx, "u4", false); err != nil || user.IsActive {
+		t.Fatalf("deactivate: %+v, %v", user, err)
+	}
+
+	// Reviewers are u2 and u3, the only active candidates
+	pr := tt.createPR(tt.ctx, "pr-1")
+	slices.Sort(pr.AssignedReviewers)
+	if !slices.Equal(pr.AssignedReviewers, []string{"u2", "u3"}) {
+		t.Fatalf("reviewers %v, want u2 and u3", pr.AssignedReviewers)
+	}
+	if _, _, err := tt.client.Reassign(tt.ctx, "pr-1", "u2"); !IsCode(err, ErrorCodeNoCandidate) {
+		t.Errorf("reassign without candidates: %v, want NO_CANDIDATE", err)
+	}
+	if _, err := tt.client.SetIsActive(tt.ctx, "u4", true); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	updated, replacedBy, err := tt.client.Reassign(tt.ctx, "pr-1", "u2")
+	if err != nil || replacedBy != "u4" || !slices.Contains(updated.AssignedReviewers, "u4") {
+		t.Fatalf("reassign: %+v by %s, %v", updated, replacedBy, err)
+	}
+
+	reviews, err := tt.client.GetReview(tt.ctx, "u4")
+	if err != nil || len(reviews) != 1 || reviews[0].PullRequestID != "pr-1" {
+		t.Errorf("reviews of u4: %+v, %v", reviews, err)
+	}
+	history, err := tt.client.GetReviewerHistory(tt.ctx, "pr-1")
+	if err != nil || len(history) != 1 || history[0].OldReviewerID != "u2" || history[0].NewReviewerID != "u4" {
+		t.Errorf("history: %+v, %v", history, err)
+	}
+
+	merged, err := tt.client.MergePR(tt.ctx, "pr-1")
+	if err != nil || merged.Status != "MERGED" || merged.MergedAt == nil {
+		t.Fatalf("merge: %+v, %v", merged, err)
+	}
+	if _, _, err := tt.client.Reassign(tt.ctx, "pr-1", "u3"); !IsCode(err, ErrorCodePRMerged) {
+		t.Errorf("reassign merged: %v, want PR_MERGED", err)
+	}
+
+	prs, cursor, err := tt.client.ListPullRequests(tt.ctx, ListOptions{Limit: 10})
+	if err != nil || len(prs) != 1 || cursor != "" {
+		t.Errorf("list pull requests: %+v, %q, %v", prs, cursor, err)
+	}
+	stats, err := tt.client.GetUserStatistics(tt.ctx, "u3")
+	if err != nil || stats.AssignmentsCount != 1 {
+		t.Errorf("statistics of u3: %+v, %v", stats, err)
+	}
+}
+
+func TestClientRetriesReads(t *testing.T) {
+	tt := newClientTest(t)
+
+	tt.fail(http.MethodGet, "/teams/backend", http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	if _, err := tt.client.GetTeam(tt.ctx, "backend"); err != nil {
+		t.Fatalf("get team after 2 failures: %v", err)
+	}
+	tt.expectSent(http.MethodGet, "/teams/backend", 3)
+
+	// Retries are exhausted
+	tt.fail(http.MethodGet, "/teams/backend", http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	_, err := tt.client.GetTeam(tt.ctx, "backend")
+	if apiErr, ok := err.(*Error); !ok || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("get team after 3 failures: %v, want 502", err)
+	}
+	tt.expectSent(http.MethodGet, "/teams/backend", 3)
+
+	// Client errors aren't retried
+	if _, err := tt.client.GetTeam(tt.ctx, "mobile"); !IsCode(err, ErrorCodeNotFound) {
+		t.Errorf("get missing team: %v, want NOT_FOUND", err)
+	}
+	tt.expectSent(http.MethodGet, "/teams/mobile", 1)
+}
+
+func TestClientRetriesCreateWithIdempotencyKey(t *testing.T) {
+	tt := newClientTest(t)
+	ctx := WithIdempotencyKey(tt.ctx, "create-pr-1")
+
+	// First response is lost, retry gets the replayed one
+	tt.fail(http.MethodPost, "/pullRequest/create", http.StatusBadGateway)
+	pr := tt.createPR(ctx, "pr-1")
+	if len(pr.AssignedReviewers) != 2 {
+		t.Errorf("replayed pr %+v", pr)
+	}
+	keys := tt.expectSent(http.MethodPost, "/pullRequest/create", 2)
+	if !slices.Equal(keys, []string{"create-pr-1", "create-pr-1"}) {
+		t.Errorf("idempotency keys %q", keys)
+	}
+	if prs, _, _ := tt.client.ListPullRequests(tt.ctx, ListOptions{}); len(prs) != 1 {
+		t.Errorf("%d pull requests, want 1", len(prs))
+	}
+
+	tt.fail(http.MethodPost, "/team/add", http.StatusServiceUnavailable)
+	team := Team{TeamName: "frontend", Members: []TeamMember{{UserID: "u5", Username: "Eve", IsActive: true}}}
+	if _, err := tt.client.AddTeam(WithIdempotencyKey(tt.ctx, "create-frontend"), team); err != nil {
+		t.Fatalf("add team: %v", err)
+	}
+	tt.expectSent(http.MethodPost, "/team/add", 2)
+
+	// Without key create isn't retried
+	tt.fail(http.MethodPost, "/pullRequest/create", http.StatusBadGateway)
+	if _, err := tt.client.CreatePR(tt.ctx, PullRequestCreateQuery{PullRequestID: "pr-2", PullRequestName: "pr-2", AuthorID: "u1"}); err == nil {
+		t.Error("create without key succeeded after lost response")
+	}
+	keys = tt.expectSent(http.MethodPost, "/pullRequest/create", 1)
+	if !slices.Equal(keys, []string{""}) {
+		t.Errorf("idempotency keys %q, want none", keys)
+	}
+}
+
+func TestClientDoesntRetryChangesWithoutIdempotency(t *testing.T) {
+	tt := newClientTest(t)
+	// Key in ctx applies only to create endpoints
+	ctx := WithIdempotencyKey(tt.ctx, "key-1")
+	pr := tt.createPR(tt.ctx, "pr-1")
+	tt.sent(http.MethodPost, "/pullRequest/create")
+
+	// Retry would replace the new reviewer too
+	tt.fail(http.MethodPost, "/pullRequest/reassign", http.StatusBadGateway)
+	if _, _, err := tt.client.Reassign(ctx, "pr-1", pr.AssignedReviewers[0]); err == nil {
+		t.Error("reassign succeeded after lost response")
+	}
+	keys := tt.expectSent(http.MethodPost, "/pullRequest/reassign", 1)
+	if !slices.Equal(keys, []string{""}) {
+		t.Errorf("reassign sent idempotency keys %q", keys)
+	}
+	if history, _ := tt.client.GetReviewerHistory(tt.ctx, "pr-1"); len(history) != 1 {
+		t.Errorf("history %+v, want one change", history)
+	}
+
+	tt.fail(http.MethodPost, "/pull-requests/pr-1/merge", http.StatusServiceUnavailable)
+	if _, err := tt.client.MergePR(ctx, "pr-1"); err == nil {
+		t.Error("merge succeeded after lost response")
+	}
+	keys = tt.expectSent(http.MethodPost, "/pull-requests/pr-1/merge", 1)
+	if !slices.Equal(keys, []string{""}) {
+		t.Errorf("merge sent idempotency keys %q", keys)
+	}
+
+	// Nor on rate limits
+	tt.fail(http.MethodPost, "/users/setIsActive", http.StatusTooManyRequests)
+	if _, err := tt.client.SetIsActive(ctx, "u4", false); !IsCode(err, ErrorCodeRateLimited) {
+		t.Errorf("set activity: %v, want RATE_LIMITED", err)
+	}
+	tt.expectSent(http.MethodPost, "/users/setIsActive", 1)
+	stored, _ := tt.storage.GetPR(tt.ctx, "pr-1")
+	if stored.Status != "MERGED" {
+		t.Errorf("pr status %s, want MERGED", stored.Status)
+	}
+}
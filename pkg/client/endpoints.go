@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Teams
+
+// Create team with members, existing users are moved to the team
+func (c *Client) AddTeam(ctx context.Context, team Team) (*Team, error) {
+	var response teamResponse
+	if err := c.post(ctx, "/team/add", team, &response); err != nil {
+		return nil, err
+	}
+	return response.Team, nil
+}
+
+func (c *Client) GetTeam(ctx context.Context, teamName string) (*Team, error) {
+	var team Team
+	if err := c.get(ctx, "/teams/"+url.PathEscape(teamName), nil, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Page of teams, next cursor is empty on the last page
+func (c *Client) ListTeams(ctx context.Context, options ListOptions) ([]TeamSummary, string, error) {
+	var response teamsResponse
+	if err := c.get(ctx, "/teams", options.values(), &response); err != nil {
+		return nil, "", err
+	}
+	return response.Teams, response.NextCursor, nil
+}
+
+// Add user to team, existing user is moved out of their team
+func (c *Client) AddTeamMember(ctx context.Context, teamName string, member TeamMember) (*Team, error) {
+	var response teamResponse
+	query := teamMemberAddQuery{
+		TeamName:   teamName,
+		UserID:     member.UserID,
+		Username:   member.Username,
+		IsActive:   member.IsActive,
+		IsTeamLead: member.IsTeamLead,
+	}
+	if err := c.post(ctx, "/team/addMember", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Team, nil
+}
+
+// Take user off team, the user stays in it deactivated and without team lead flag
+func (c *Client) RemoveTeamMember(ctx context.Context, teamName, userID string) (*User, error) {
+	var response userResponse
+	query := map[string]interface{}{"team_name": teamName, "user_id": userID}
+	if err := c.post(ctx, "/team/removeMember", query, &response); err != nil {
+		return nil, err
+	}
+	return response.User, nil
+}
+
+func (c *Client) GetTeamSettings(ctx context.Context, teamName string) (*TeamSettings, error) {
+	var settings TeamSettings
+	if err := c.get(ctx, "/team/getSettings", url.Values{"team_name": {teamName}}, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (c *Client) SetTeamSettings(ctx context.Context, settings TeamSettings) (*TeamSettings, error) {
+	var updated TeamSettings
+	if err := c.post(ctx, "/team/setSettings", settings, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Users
+
+func (c *Client) SetIsActive(ctx context.Context, userID string, isActive bool) (*User, error) {
+	var response userResponse
+	query := map[string]interface{}{"user_id": userID, "is_active": isActive}
+	if err := c.post(ctx, "/users/setIsActive", query, &response); err != nil {
+		return nil, err
+	}
+	return response.User, nil
+}
+
+// Open pull requests where user is reviewer, all pages are read
+func (c *Client) GetReview(ctx context.Context, userID string) ([]PullRequestShort, error) {
+	var prs []PullRequestShort
+	options := ListOptions{Limit: maxPageLimit}
+	for {
+		var response reviewResponse
+		if err := c.get(ctx, "/users/"+url.PathEscape(userID)+"/reviews", options.values(), &response); err != nil {
+			return nil, err
+		}
+		prs = append(prs, response.PullRequests...)
+		if response.NextCursor == "" {
+			return prs, nil
+		}
+		options.Cursor = response.NextCursor
+	}
+}
+
+// Page of users, next cursor is empty on the last page
+func (c *Client) ListUsers(ctx context.Context, options ListOptions) ([]User, string, error) {
+	var response usersResponse
+	if err := c.get(ctx, "/users", options.values(), &response); err != nil {
+		return nil, "", err
+	}
+	return response.Users, response.NextCursor, nil
+}
+
+// Pull requests
+
+// Page of pull requests, next cursor is empty on the last page
+func (c *Client) ListPullRequests(ctx context.Context, options ListOptions) ([]PullRequest, string, error) {
+	var response pullRequestsResponse
+	if err := c.get(ctx, "/pull-requests", options.values(), &response); err != nil {
+		return nil, "", err
+	}
+	return response.PullRequests, response.NextCursor, nil
+}
+
+// Page of pull requests found by options, next cursor is empty on the last page
+func (c *Client) SearchPullRequests(ctx context.Context, options SearchOptions) ([]PullRequest, string, error) {
+	var response pullRequestsResponse
+	if err := c.get(ctx, "/pull-requests/search", options.values(), &response); err != nil {
+		return nil, "", err
+	}
+	return response.PullRequests, response.NextCursor, nil
+}
+
+// Create pull request, reviewers are assigned by the service
+func (c *Client) CreatePR(ctx context.Context, query PullRequestCreateQuery) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.post(ctx, "/pullRequest/create", query, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (c *Client) MergePR(ctx context.Context, prID string) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.post(ctx, "/pull-requests/"+url.PathEscape(prID)+"/merge", nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// Replace reviewer, returns updated pull request and id of the new reviewer
+func (c *Client) Reassign(ctx context.Context, prID, oldUserID string) (*PullRequest, string, error) {
+	var response reassignResponse
+	query := map[string]string{"pull_request_id": prID, "old_user_id": oldUserID}
+	if err := c.post(ctx, "/pullRequest/reassign", query, &response); err != nil {
+		return nil, "", err
+	}
+	return response.PR, response.ReplacedBy, nil
+}
+
+// Open pull requests that exceeded review SLA, of all teams if teamName is empty
+func (c *Client) GetOverduePRs(ctx context.Context, teamName string) ([]OverduePR, error) {
+	var query url.Values
+	if teamName != "" {
+		query = url.Values{"team_name": {teamName}}
+	}
+	var response overdueResponse
+	if err := c.get(ctx, "/pullRequest/overdue", query, &response); err != nil {
+		return nil, err
+	}
+	return response.PullRequests, nil
+}
+
+func (c *Client) GetReviewerHistory(ctx context.Context, prID string) ([]ReviewerChange, error) {
+	var response historyResponse
+	if err := c.get(ctx, "/pullRequest/history", url.Values{"pull_request_id": {prID}}, &response); err != nil {
+		return nil, err
+	}
+	return response.History, nil
+}
+
+// Statistics
+
+func (c *Client) GetUsersStatistics(ctx context.Context) (*UsersStatistics, error) {
+	var statistics UsersStatistics
+	if err := c.get(ctx, "/users/statistics", nil, &statistics); err != nil {
+		return nil, err
+	}
+	return &statistics, nil
+}
+
+func (c *Client) GetUserStatistics(ctx context.Context, userID string) (*UserStats, error) {
+	var statistics UserStats
+	if err := c.get(ctx, "/users/get", url.Values{"user_id": {userID}}, &statistics); err != nil {
+		return nil, err
+	}
+	return &statistics, nil
+}
+
+func (c *Client) GetTeamsStatistics(ctx context.Context) (*TeamsStatistics, error) {
+	var statistics TeamsStatistics
+	if err := c.get(ctx, "/team/count", nil, &statistics); err != nil {
+		return nil, err
+	}
+	return &statistics, nil
+}
+
+func (c *Client) GetTeamStatistics(ctx context.Context, teamName string) (*TeamStats, error) {
+	var statistics TeamStats
+	if err := c.get(ctx, "/team/statistics", url.Values{"team_name": {teamName}}, &statistics); err != nil {
+		return nil, err
+	}
+	return &statistics, nil
+}
+
+func (c *Client) GetPRStatistics(ctx context.Context) (*PullRequestStatistics, error) {
+	var statistics PullRequestStatistics
+	if err := c.get(ctx, "/pullRequest/statistics", nil, &statistics); err != nil {
+		return nil, err
+	}
+	return &statistics, nil
+}
+
+// Webhooks
+
+// Register webhook, returned webhook contains secret for checking signatures
+func (c *Client) AddWebhook(ctx context.Context, query WebhookCreateQuery) (*Webhook, error) {
+	var response webhookResponse
+	if err := c.post(ctx, "/webhooks/add", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Webhook, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var response webhooksResponse
+	if err := c.get(ctx, "/webhooks/list", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Webhooks, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	return c.post(ctx, "/webhooks/delete", map[string]int64{"webhook_id": webhookID}, nil)
+}
+
+// Failed deliveries, of all webhooks if webhookID is 0
+func (c *Client) ListFailedDeliveries(ctx context.Context, webhookID int64) ([]WebhookDelivery, error) {
+	var query url.Values
+	if webhookID != 0 {
+		query = url.Values{"webhook_id": {strconv.FormatInt(webhookID, 10)}}
+	}
+	var response deliveriesResponse
+	if err := c.get(ctx, "/webhooks/deliveries/failed", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Deliveries, nil
+}
+
+func (c *Client) ReplayDelivery(ctx context.Context, deliveryID int64) (*WebhookDelivery, error) {
+	var response deliveryResponse
+	if err := c.post(ctx, "/webhooks/deliveries/replay", map[string]int64{"delivery_id": deliveryID}, &response); err != nil {
+		return nil, err
+	}
+	return response.Delivery, nil
+}
+
+// API keys
+
+// Create API key, returned key contains plain key that is shown only once
+func (c *Client) CreateAPIKey(ctx context.Context, query APIKeyCreateQuery) (*APIKey, error) {
+	var response apiKeyResponse
+	if err := c.post(ctx, "/apiKeys/add", query, &response); err != nil {
+		return nil, err
+	}
+	return response.APIKey, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var response apiKeysResponse
+	if err := c.get(ctx, "/apiKeys/list", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.APIKeys, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	return c.post(ctx, "/apiKeys/revoke", map[string]int64{"key_id": keyID}, nil)
+}
+
+// Service
+
+// Check that service is running
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil, true)
+}
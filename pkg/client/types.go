@@ -0,0 +1,264 @@
+package client
+
+import (
+	"PR_reviewer_assign_service/internal/errors"
+	"PR_reviewer_assign_service/internal/models"
+	goerrors "errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Models of the service API
+type (
+	Team                   = models.Team
+	TeamMember             = models.TeamMember
+	User                   = models.User
+	PullRequest            = models.PullRequest
+	PullRequestShort       = models.PullRequestShort
+	PullRequestCreateQuery = models.PullRequestCreateQuery
+	UserStats              = models.UserStats
+	UsersStatistics        = models.UsersStatistics
+	TeamStats              = models.TeamStats
+	TeamsStatistics        = models.TeamsStatistics
+	PullRequestStatistics  = models.PullRequestStatistics
+	TeamSettings           = models.TeamSettings
+	OverduePR              = models.OverduePR
+	ReviewerChange         = models.ReviewerChange
+	Webhook                = models.Webhook
+	WebhookCreateQuery     = models.WebhookCreateQuery
+	WebhookDelivery        = models.WebhookDelivery
+	APIKey                 = models.APIKey
+	APIKeyCreateQuery      = models.APIKeyCreateQuery
+	Violation              = models.Violation
+	TeamSummary            = models.TeamSummary
+)
+
+// Largest page of list endpoints
+const maxPageLimit = 100
+
+// Page and filters of list requests, zero fields are not sent.
+// Not every list supports every filter, unsupported ones are rejected by the service.
+type ListOptions struct {
+	// 1..100, service default is 50
+	Limit int
+	// Next cursor of the previous page
+	Cursor string
+	// OPEN or MERGED
+	Status      string
+	AuthorID    string
+	TeamName    string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+func (o ListOptions) values() url.Values {
+	values := url.Values{}
+	if o.Limit != 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	for name, value := range map[string]string{
+		"cursor":    o.Cursor,
+		"status":    o.Status,
+		"author_id": o.AuthorID,
+		"team_name": o.TeamName,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	if !o.CreatedFrom.IsZero() {
+		values.Set("created_from", o.CreatedFrom.Format(time.RFC3339))
+	}
+	if !o.CreatedTo.IsZero() {
+		values.Set("created_to", o.CreatedTo.Format(time.RFC3339))
+	}
+	return values
+}
+
+// Filters of pull request search, empty fields don't filter
+type SearchOptions struct {
+	// Substring of pull request name, case insensitive
+	Name       string
+	AuthorID   string
+	ReviewerID string
+	TeamName   string
+	// OPEN or MERGED
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	MergedFrom  time.Time
+	MergedTo    time.Time
+	// created_at (default), merged_at or name
+	Sort string
+	// asc or desc, service default is desc for times and asc for name
+	Order string
+	// 1..100, service default is 50
+	Limit int
+	// Next cursor of the previous page with the same sort and order
+	Cursor string
+}
+
+func (o SearchOptions) values() url.Values {
+	values := url.Values{}
+	if o.Limit != 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	for name, value := range map[string]string{
+		"name":        o.Name,
+		"author_id":   o.AuthorID,
+		"reviewer_id": o.ReviewerID,
+		"team_name":   o.TeamName,
+		"status":      o.Status,
+		"sort":        o.Sort,
+		"order":       o.Order,
+		"cursor":      o.Cursor,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	for name, value := range map[string]time.Time{
+		"created_from": o.CreatedFrom,
+		"created_to":   o.CreatedTo,
+		"merged_from":  o.MergedFrom,
+		"merged_to":    o.MergedTo,
+	} {
+		if !value.IsZero() {
+			values.Set(name, value.Format(time.RFC3339))
+		}
+	}
+	return values
+}
+
+// Error codes of the service API
+type ErrorCode = errors.ErrorCode
+
+const (
+	ErrorCodeTeamExists           = errors.ErrorCodeTeamExists
+	ErrorCodePRExists             = errors.ErrorCodePRExists
+	ErrorCodePRMerged             = errors.ErrorCodePRMerged
+	ErrorCodeNotAssigned          = errors.ErrorCodeNotAssigned
+	ErrorCodeNoCandidate          = errors.ErrorCodeNoCandidate
+	ErrorCodeNotFound             = errors.ErrorCodeNotFound
+	ErrorCodeDeliveryNotFailed    = errors.ErrorCodeDeliveryNotFailed
+	ErrorCodeUnauthorized         = errors.ErrorCodeUnauthorized
+	ErrorCodeInsufficientScope    = errors.ErrorCodeInsufficientScope
+	ErrorCodeIdempotencyKeyReused = errors.ErrorCodeIdempotencyKeyReused
+	ErrorCodeRequestInProgress    = errors.ErrorCodeRequestInProgress
+	ErrorCodeRateLimited          = errors.ErrorCodeRateLimited
+	ErrorCodeInvalidInput         = errors.ErrorCodeInvalidInput
+	ErrorCodeMethodNotAllowed     = errors.ErrorCodeMethodNotAllowed
+	ErrorCodePayloadTooLarge      = errors.ErrorCodePayloadTooLarge
+	ErrorCodeUnsupportedMediaType = errors.ErrorCodeUnsupportedMediaType
+	ErrorCodeInternal             = errors.ErrorCodeInternal
+)
+
+// Error returned by the service
+type Error struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+	// Invalid fields of INVALID_INPUT errors
+	Details []Violation
+	// From Retry-After header of 429 responses
+	RetryAfter time.Duration
+	// From X-Request-ID header, to find the request in service logs
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Check that err is service error with given code
+func IsCode(err error, code ErrorCode) bool {
+	var apiErr *Error
+	return goerrors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Request bodies
+type teamMemberAddQuery struct {
+	TeamName   string `json:"team_name"`
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	IsActive   bool   `json:"is_active"`
+	IsTeamLead bool   `json:"is_team_lead"`
+}
+
+// Response wrappers
+type teamResponse struct {
+	Team *Team `json:"team"`
+}
+
+type userResponse struct {
+	User *User `json:"user"`
+}
+
+type reassignResponse struct {
+	PR         *PullRequest `json:"pr"`
+	ReplacedBy string       `json:"replaced_by"`
+}
+
+type reviewResponse struct {
+	UserID       string             `json:"user_id"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	NextCursor   string             `json:"next_cursor"`
+}
+
+type teamsResponse struct {
+	Teams      []TeamSummary `json:"teams"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+type usersResponse struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor"`
+}
+
+type pullRequestsResponse struct {
+	PullRequests []PullRequest `json:"pull_requests"`
+	NextCursor   string        `json:"next_cursor"`
+}
+
+type overdueResponse struct {
+	PullRequests []OverduePR `json:"pull_requests"`
+}
+
+type historyResponse struct {
+	PullRequestID string           `json:"pull_request_id"`
+	History       []ReviewerChange `json:"history"`
+}
+
+type webhookResponse struct {
+	Webhook *Webhook `json:"webhook"`
+}
+
+type webhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type deliveryResponse struct {
+	Delivery *WebhookDelivery `json:"delivery"`
+}
+
+type deliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type apiKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+}
+
+type apiKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    ErrorCode   `json:"code"`
+		Message string      `json:"message"`
+		Details []Violation `json:"details"`
+	} `json:"error"`
+}